@@ -0,0 +1,408 @@
+// Package address standardizes US street addresses to the forms in USPS Publication 28, so
+// that the same place is spelled the same way no matter which voter file it came from.
+package address
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Address is a street address broken into its Pub 28 components
+type Address struct {
+	Number    string // primary number, e.g. 1400 or 12A
+	Fraction  string // 1/2 for half house numbers
+	PreDir    string // predirectional, e.g. the N in N MAIN ST
+	Name      string // street name
+	Suffix    string // street suffix, e.g. ST, RD, BLVD
+	PostDir   string // postdirectional, e.g. the SW in PEACHTREE ST SW
+	Qualifier string // what follows the suffix that isn't a direction: EXT, BUS, BYP...
+	UnitType  string // secondary unit designator, e.g. APT or STE; # when unknown
+	UnitNum   string // secondary unit number
+	POBox     string // box number, for PO BOX addresses; the street parts are then empty
+	City      string
+	State     string
+	Zip       string // ZIP or ZIP+4, as 12345 or 12345-6789
+}
+
+// Parts are the Address field names that a layout can map columns onto
+var Parts = []string{"Number", "Fraction", "PreDir", "Name", "Suffix", "PostDir", "Qualifier", "UnitType", "UnitNum"}
+
+// Set fills in the component named part, one of Parts
+func (a *Address) Set(part string, val string) error {
+	switch part {
+	case "Number":
+		a.Number = val
+	case "Fraction":
+		a.Fraction = val
+	case "PreDir":
+		a.PreDir = val
+	case "Name":
+		a.Name = val
+	case "Suffix":
+		a.Suffix = val
+	case "PostDir":
+		a.PostDir = val
+	case "Qualifier":
+		a.Qualifier = val
+	case "UnitType":
+		a.UnitType = val
+	case "UnitNum":
+		a.UnitNum = val
+	default:
+		return fmt.Errorf("no address part %s", part)
+	}
+	return nil
+}
+
+// Street is the delivery line without the unit, e.g. 123 1/2 N MAIN ST EXT, or PO BOX 12
+func (a Address) Street() string {
+	if a.POBox != "" && a.Name == "" {
+		return "PO BOX " + a.POBox
+	}
+	return join(a.Number, a.Fraction, a.PreDir, a.Name, a.Suffix, a.PostDir, a.Qualifier)
+}
+
+// Unit is the secondary unit, e.g. APT 4B
+func (a Address) Unit() string {
+	return join(a.UnitType, a.UnitNum)
+}
+
+// Line is the full delivery line, e.g. 123 N MAIN ST APT 4B
+func (a Address) Line() string {
+	return join(a.Street(), a.Unit())
+}
+
+// String is the whole address on one line, e.g. 123 N MAIN ST APT 4B, RALEIGH NC 27601
+func (a Address) String() string {
+	line, last := a.Line(), join(a.City, a.State, a.Zip)
+	if line == "" || last == "" {
+		return line + last
+	}
+	return line + ", " + last
+}
+
+// Standardize rewrites each component of a into its Pub 28 form.  It can be applied any
+// number of times.
+func Standardize(a Address) Address {
+	a.Number = clean(a.Number)
+	a.Fraction = clean(a.Fraction)
+	a.PreDir = clean(a.PreDir)
+	a.Name = clean(a.Name)
+	a.Suffix = clean(a.Suffix)
+	a.PostDir = clean(a.PostDir)
+	a.Qualifier = clean(a.Qualifier)
+	a.UnitType = clean(a.UnitType)
+	a.UnitNum = clean(a.UnitNum)
+	a.POBox = clean(a.POBox)
+	a.City = clean(a.City)
+	a.State = clean(a.State)
+
+	if halves[a.Fraction] {
+		a.Fraction = "1/2"
+	} else if len(a.Fraction) == 1 && isLetter(a.Fraction[0]) {
+		a.Number += a.Fraction // a lettered house number, e.g. 12A
+		a.Fraction = ""
+	}
+
+	a.PreDir = direction(a.PreDir)
+	a.Name = numberedStreet(a.Name)
+	if abbrev, ok := suffixes[a.Suffix]; ok {
+		a.Suffix = abbrev
+	}
+
+	// NC keeps directionals and qualifiers like EXT in the same column
+	if dir := direction(a.PostDir); isDirection(dir) {
+		a.PostDir = dir
+	} else if _, ok := qualifiers[a.PostDir]; ok && a.Qualifier == "" {
+		a.Qualifier, a.PostDir = a.PostDir, ""
+	}
+	if abbrev, ok := qualifiers[a.Qualifier]; ok {
+		a.Qualifier = abbrev
+	}
+
+	a.UnitType, a.UnitNum = unit(a.UnitType, a.UnitNum)
+	a.Zip = zip(a.Zip)
+	return a
+}
+
+// unit standardizes a secondary unit designator and number.  Some files put the designator in
+// with the number, and most put a # in front of the number when they have no designator.
+func unit(kind string, num string) (string, string) {
+	num = strings.TrimSpace(strings.TrimPrefix(num, "#"))
+	if kind == "" {
+		if i := strings.IndexByte(num, ' '); i > 0 {
+			if abbrev, ok := units[num[:i]]; ok {
+				kind, num = abbrev, strings.TrimSpace(num[i+1:])
+			}
+		} else if abbrev, ok := units[num]; ok && numberless[abbrev] {
+			kind, num = abbrev, ""
+		}
+	}
+	if abbrev, ok := units[kind]; ok {
+		kind = abbrev
+	}
+	if kind == "" && num != "" {
+		kind = "#"
+	}
+	return kind, num
+}
+
+// numberless are the unit designators that don't take a number, e.g. REAR
+var numberless = map[string]bool{
+	"BSMT": true,
+	"FRNT": true,
+	"LBBY": true,
+	"LOWR": true,
+	"OFC":  true,
+	"PH":   true,
+	"REAR": true,
+	"SIDE": true,
+	"UPPR": true,
+}
+
+// ParseStreet breaks a delivery line like "123 North Main Street Apt 4" into its components
+// and standardizes them.  It's meant for layouts that keep the whole street in one column;
+// anything it can't place ends up in Name.
+func ParseStreet(line string) Address {
+	var a Address
+	tokens := strings.Fields(clean(line))
+	start := 0
+	if len(tokens) > 1 && isNumber(tokens[0]) {
+		a.Number = tokens[0]
+		start = 1
+		if tokens[1] == "1/2" || tokens[1] == "½" {
+			a.Fraction = tokens[1]
+			start = 2
+		}
+	}
+
+	end := len(tokens)
+	if i := findUnit(tokens, start+1); i >= 0 {
+		if strings.HasPrefix(tokens[i], "#") {
+			a.UnitType = "#"
+			a.UnitNum = strings.Join(append([]string{tokens[i][1:]}, tokens[i+1:]...), " ")
+		} else {
+			a.UnitType = tokens[i]
+			a.UnitNum = strings.Join(tokens[i+1:], " ")
+		}
+		end = i
+	} else if n := len(tokens); n > start+2 && strings.ContainsAny(tokens[n-1], "0123456789") && isSuffixOrDirection(tokens[n-2]) && !isRoute(tokens[start:n-1]) {
+		a.UnitNum = tokens[n-1] // a bare unit number after the street, e.g. 55 TRINITY AVE SW 4B
+		end = n - 1
+	}
+
+	street := tokens[start:end]
+	if n := len(street); n > 2 {
+		if _, ok := qualifiers[street[n-1]]; ok && isSuffixOrDirection(street[n-2]) {
+			a.Qualifier = street[n-1]
+			street = street[:n-1]
+		}
+	}
+	if n := len(street); n > 1 && isDirection(direction(street[n-1])) {
+		a.PostDir = street[n-1]
+		street = street[:n-1]
+	}
+	if n := len(street); n > 1 {
+		if _, ok := suffixes[street[n-1]]; ok {
+			a.Suffix = street[n-1]
+			street = street[:n-1]
+		}
+	}
+	if len(street) > 1 && isDirection(direction(street[0])) {
+		a.PreDir = street[0]
+		street = street[1:]
+	}
+	a.Name = strings.Join(street, " ")
+	return Standardize(a)
+}
+
+// routeTypes are the suffixes a numbered route is named with, and routePrefixes the words that
+// come before them, as in COUNTY ROAD 12, STATE ROUTE 9 or US HWY 1.  A state's abbreviation
+// is a prefix too, as in NC HWY 54.
+var routeTypes = map[string]bool{"RD": true, "RTE": true, "HWY": true}
+
+var routePrefixes = map[string]bool{
+	"CO":         true,
+	"COUNTY":     true,
+	"CR":         true,
+	"FARM":       true,
+	"FM":         true,
+	"FOREST":     true,
+	"INTERSTATE": true,
+	"MARKET":     true, // FARM TO MARKET ROAD
+	"PARISH":     true,
+	"RANCH":      true,
+	"SR":         true,
+	"ST":         true, // ST RD, as FL writes State Road
+	"STATE":      true,
+	"TOWNSHIP":   true,
+	"TWP":        true,
+	"US":         true,
+}
+
+// isRoute is whether a street ends in the name of a numbered route, so that a number after it
+// is the route's, not a unit
+func isRoute(street []string) bool {
+	n := len(street)
+	if n < 2 {
+		return false
+	}
+	if !routeTypes[suffixes[street[n-1]]] {
+		return false
+	}
+	_, state := states[street[n-2]]
+	return state || routePrefixes[street[n-2]]
+}
+
+// findUnit returns where the secondary unit starts in a tokenized delivery line, or -1.  The
+// rightmost designator wins, and it has to look like a unit: followed by a single number, or
+// last on the line if it's one that takes no number.
+func findUnit(tokens []string, from int) int {
+	for i := len(tokens) - 1; i >= from; i-- {
+		tok := tokens[i]
+		if strings.HasPrefix(tok, "#") {
+			return i
+		}
+		abbrev, ok := units[tok]
+		if !ok {
+			continue
+		}
+		if numberless[abbrev] && i == len(tokens)-1 {
+			return i
+		}
+		if i == len(tokens)-2 {
+			next := tokens[i+1]
+			if _, isSuffix := suffixes[next]; !isSuffix || strings.ContainsAny(next, "0123456789") {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+var nonAddressChars = regexp.MustCompile(`[.,;]+`)
+
+// clean upper-cases s, drops Pub 28's unwanted punctuation, and collapses the whitespace
+func clean(s string) string {
+	s = nonAddressChars.ReplaceAllString(strings.ToUpper(s), " ")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func join(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
+
+// direction abbreviates a spelled-out directional, and passes anything else through
+func direction(s string) string {
+	if abbrev, ok := directionals[s]; ok {
+		return abbrev
+	}
+	return s
+}
+
+func isDirection(s string) bool {
+	switch s {
+	case "N", "S", "E", "W", "NE", "NW", "SE", "SW":
+		return true
+	}
+	return false
+}
+
+func isSuffixOrDirection(s string) bool {
+	_, ok := suffixes[s]
+	return ok || isDirection(direction(s))
+}
+
+// tens are the words that start a spelled-out ordinal past the twentieth, e.g. TWENTY FIRST
+var tens = map[string]string{
+	"TWENTY":  "2",
+	"THIRTY":  "3",
+	"FORTY":   "4",
+	"FIFTY":   "5",
+	"SIXTY":   "6",
+	"SEVENTY": "7",
+	"EIGHTY":  "8",
+	"NINETY":  "9",
+}
+
+// numberedStreet writes the ordinals in a street name as numerals
+func numberedStreet(name string) string {
+	words := strings.Fields(name)
+	out := make([]string, 0, len(words))
+	for i := 0; i < len(words); i++ {
+		if ten, ok := tens[words[i]]; ok && i+1 < len(words) {
+			if num, ok := ordinals[words[i+1]]; ok && len(num) == 3 {
+				out = append(out, ordinal(ten+num[:1]+"TH"))
+				i++
+				continue
+			}
+		}
+		out = append(out, ordinal(words[i]))
+	}
+	return strings.Join(out, " ")
+}
+
+var numberedName = regexp.MustCompile(`^([0-9]+)(ST|ND|RD|TH|D)$`)
+
+// ordinal writes a numbered street name the Pub 28 way: FIRST and 1ST both become 1ST, 2D 2ND
+func ordinal(word string) string {
+	if num, ok := ordinals[word]; ok {
+		return num
+	}
+	m := numberedName.FindStringSubmatch(word)
+	if m == nil {
+		return word
+	}
+	n := m[1]
+	suffix := "TH"
+	if len(n) < 2 || n[len(n)-2] != '1' { // 11TH, 12TH and 13TH, but 21ST, 22ND and 23RD
+		switch n[len(n)-1] {
+		case '1':
+			suffix = "ST"
+		case '2':
+			suffix = "ND"
+		case '3':
+			suffix = "RD"
+		}
+	}
+	return n + suffix
+}
+
+// isNumber reports whether a token is a house number rather than the start of the street
+// name: it starts with a digit, but isn't a numbered street like 1ST
+func isNumber(tok string) bool {
+	return tok[0] >= '0' && tok[0] <= '9' && !numberedName.MatchString(tok)
+}
+
+func isLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
+
+// zip writes a ZIP code as 12345 or 12345-6789, restoring the leading zero that spreadsheets
+// like to drop from New England ZIPs
+func zip(s string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	switch len(digits) {
+	case 4, 8:
+		digits = "0" + digits
+	}
+	switch len(digits) {
+	case 5:
+		return digits
+	case 9:
+		return digits[:5] + "-" + digits[5:]
+	}
+	return strings.TrimSpace(s)
+}
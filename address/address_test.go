@@ -0,0 +1,74 @@
+package address
+
+import "testing"
+
+func TestParseStreet(t *testing.T) {
+	tests := []struct {
+		line string
+		want Address
+	}{
+		{"123 North Main Street Apt 4", Address{Number: "123", PreDir: "N", Name: "MAIN", Suffix: "ST", UnitType: "APT", UnitNum: "4"}},
+		{"1400 1/2 JOHN F KENNEDY BLVD #210", Address{Number: "1400", Fraction: "1/2", Name: "JOHN F KENNEDY", Suffix: "BLVD", UnitType: "#", UnitNum: "210"}},
+		{"55 TRINITY AVE SW 4B", Address{Number: "55", Name: "TRINITY", Suffix: "AVE", PostDir: "SW", UnitType: "#", UnitNum: "4B"}},
+		{"220 GRAND RIVER AVE E APT 7", Address{Number: "220", Name: "GRAND RIVER", Suffix: "AVE", PostDir: "E", UnitType: "APT", UnitNum: "7"}},
+		{"12 Oak Rd Ext", Address{Number: "12", Name: "OAK", Suffix: "RD", Qualifier: "EXT"}},
+		{"9 Elm St Rear", Address{Number: "9", Name: "ELM", Suffix: "ST", UnitType: "REAR"}},
+		{"500 Twenty First Street", Address{Number: "500", Name: "21ST", Suffix: "ST"}},
+
+		// a number after a numbered route is the route's, not a unit
+		{"100 COUNTY ROAD 12", Address{Number: "100", Name: "COUNTY ROAD 12"}},
+		{"100 County Rd 12", Address{Number: "100", Name: "COUNTY RD 12"}},
+		{"4410 STATE ROUTE 7", Address{Number: "4410", Name: "STATE ROUTE 7"}},
+		{"250 US HIGHWAY 41", Address{Number: "250", Name: "US HIGHWAY 41"}},
+		{"250 US HWY 41 N", Address{Number: "250", Name: "US HWY 41", PostDir: "N"}},
+		{"16 ST RD 50", Address{Number: "16", Name: "ST RD 50"}},
+		{"77 FM ROAD 1960", Address{Number: "77", Name: "FM ROAD 1960"}},
+		{"100 NC HWY 54", Address{Number: "100", Name: "NC HWY 54"}},
+		{"100 NC HWY 54 APT 3", Address{Number: "100", Name: "NC HWY 54", UnitType: "APT", UnitNum: "3"}},
+		{"8 SR 520", Address{Number: "8", Name: "SR 520"}},
+		{"2 WA-500", Address{Number: "2", Name: "WA-500"}},
+		{"100 COUNTY ROAD 12 APT 3", Address{Number: "100", Name: "COUNTY ROAD 12", UnitType: "APT", UnitNum: "3"}},
+		{"8 OAK RD 12", Address{Number: "8", Name: "OAK", Suffix: "RD", UnitType: "#", UnitNum: "12"}},
+	}
+	for _, tt := range tests {
+		if got := ParseStreet(tt.line); got != tt.want {
+			t.Errorf("ParseStreet(%q) = %#v, want %#v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestStandardize(t *testing.T) {
+	tests := []struct {
+		in   Address
+		want Address
+	}{
+		{Address{Number: "12", Fraction: "A", PreDir: "north", Name: "main", Suffix: "street", PostDir: "EXT"},
+			Address{Number: "12A", PreDir: "N", Name: "MAIN", Suffix: "ST", Qualifier: "EXT"}},
+		{Address{Number: "5", Fraction: "½", Name: "Oak", Suffix: "Avenue", UnitNum: "#4"},
+			Address{Number: "5", Fraction: "1/2", Name: "OAK", Suffix: "AVE", UnitType: "#", UnitNum: "4"}},
+		{Address{Number: "3", Name: "PINE", Suffix: "LN", UnitNum: "Apartment 2", Zip: "276011234"},
+			Address{Number: "3", Name: "PINE", Suffix: "LN", UnitType: "APT", UnitNum: "2", Zip: "27601-1234"}},
+	}
+	for _, tt := range tests {
+		got := Standardize(tt.in)
+		if got != tt.want {
+			t.Errorf("Standardize(%+v) = %#v, want %#v", tt.in, got, tt.want)
+		}
+		if again := Standardize(got); again != got {
+			t.Errorf("Standardize isn't idempotent on %#v: %#v", got, again)
+		}
+	}
+}
+
+func TestLine(t *testing.T) {
+	a := Address{Number: "55", Name: "TRINITY", Suffix: "AVE", PostDir: "SW", UnitType: "#", UnitNum: "4B", City: "ATLANTA", State: "GA", Zip: "30303"}
+	if got, want := a.Line(), "55 TRINITY AVE SW # 4B"; got != want {
+		t.Errorf("Line() = %q, want %q", got, want)
+	}
+	if got, want := a.String(), "55 TRINITY AVE SW # 4B, ATLANTA GA 30303"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, want := (Address{POBox: "12"}).Street(), "PO BOX 12"; got != want {
+		t.Errorf("Street() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,201 @@
+package address
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Confidence says how sure Parse is that it put every part of an address in the right place
+type Confidence int
+
+const (
+	Low    Confidence = iota // no street or no city could be found; the parts are best guesses
+	Medium                   // the parts were found, but some had to be inferred (missing commas, state or ZIP)
+	High                     // number, street, city, state and ZIP were all where they belong
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case High:
+		return "high"
+	case Medium:
+		return "medium"
+	}
+	return "low"
+}
+
+var (
+	countryTail = regexp.MustCompile(`[\s,]*\b(USA|US|UNITED STATES( OF AMERICA)?)$`)
+	zipTail     = regexp.MustCompile(`(^|[\s,])([0-9]{5}(-?[0-9]{4})?)$`)
+	poBox       = regexp.MustCompile(`^(P ?O|POST OFFICE|POB)? ?BOX ([0-9A-Z-]+)\b`)
+)
+
+// Parse breaks a free-form, one-line US address like "123 Main St Suite 4, Raleigh, NC
+// 27601-1234" into its parts and standardizes them.  Commas help but aren't required; without
+// them the street is taken to end at its suffix and the city starts after it.
+func Parse(s string) (Address, Confidence) {
+	var a Address
+	s = strings.ToUpper(strings.Join(strings.Fields(strings.Replace(s, "\n", ", ", -1)), " "))
+	s = strings.Replace(strings.Replace(s, ".", "", -1), ";", ",", -1)
+	s = countryTail.ReplaceAllString(s, "")
+
+	if m := zipTail.FindStringSubmatchIndex(s); m != nil {
+		a.Zip = s[m[4]:m[5]]
+		s = s[:m[0]]
+	}
+	segments := splitSegments(s)
+	segments, a.State = takeState(segments)
+
+	inferred := a.Zip == "" || a.State == ""
+	var delivery []string
+	switch len(segments) {
+	case 0:
+		return Standardize(a), Low
+	case 1:
+		tokens := strings.Fields(segments[0])
+		street, city := splitStreetCity(tokens)
+		if city == "" && !isNumber(tokens[0]) && !poBox.MatchString(segments[0]) {
+			a.City = segments[0] // just a city, e.g. "Raleigh, NC"
+			break
+		}
+		delivery = []string{strings.Join(street, " ")}
+		a.City = city
+		inferred = true
+	default:
+		a.City = segments[len(segments)-1]
+		delivery = segments[:len(segments)-1]
+	}
+
+	var street, unit string
+	for _, seg := range delivery {
+		tokens := strings.Fields(seg)
+		switch {
+		case poBox.MatchString(seg):
+			a.POBox = poBox.FindStringSubmatch(seg)[2]
+		case findUnit(tokens, 0) == 0:
+			unit = seg
+		case street == "" || isNumber(tokens[0]):
+			street = seg // a building or place name can come first; the numbered line wins
+		}
+	}
+	if street != "" {
+		parsed := ParseStreet(street + " " + unit)
+		parsed.POBox, parsed.City, parsed.State, parsed.Zip = a.POBox, a.City, a.State, a.Zip
+		a = parsed
+	} else if unit != "" {
+		a.UnitType, a.UnitNum = "", unit
+	}
+	a = Standardize(a)
+
+	switch {
+	case a.Name == "" && a.POBox == "", a.City == "":
+		return a, Low
+	case inferred || (a.Number == "" && a.POBox == ""):
+		return a, Medium
+	}
+	return a, High
+}
+
+// splitSegments splits an address at its commas, dropping empty pieces
+func splitSegments(s string) []string {
+	var segments []string
+	for _, seg := range strings.Split(s, ",") {
+		if seg = strings.TrimSpace(seg); seg != "" {
+			segments = append(segments, seg)
+		}
+	}
+	return segments
+}
+
+// takeState finds the state at the end of the last segment, either as its own segment or
+// after the city, and returns the segments without it
+func takeState(segments []string) ([]string, string) {
+	if len(segments) == 0 {
+		return segments, ""
+	}
+	last := strings.Fields(segments[len(segments)-1])
+	for n := 4; n >= 1; n-- {
+		if len(last) < n {
+			continue
+		}
+		words := strings.Join(last[len(last)-n:], " ")
+		state, ok := stateNames[words]
+		if !ok && n == 1 {
+			if _, ok = states[words]; ok {
+				state = words
+			}
+		}
+		if !ok {
+			continue
+		}
+		if len(last) == n {
+			return segments[:len(segments)-1], state
+		}
+		out := append([]string{}, segments...)
+		out[len(out)-1] = strings.Join(last[:len(last)-n], " ")
+		return out, state
+	}
+	return segments, ""
+}
+
+// commonSuffixes are the street types that almost never start a city name, unlike LAKE or FOREST
+var commonSuffixes = map[string]bool{
+	"AVE": true, "BLVD": true, "CIR": true, "CT": true, "DR": true, "HWY": true, "LN": true,
+	"LOOP": true, "PKWY": true, "PL": true, "RD": true, "ST": true, "TER": true, "TRL": true,
+	"WAY": true,
+}
+
+// splitStreetCity divides a line with no commas into the delivery line and the city.  The
+// street is taken to end at the first common suffix after its name, or failing that at the first
+// suffix of any kind, along with any route number, postdirectional, qualifier and unit that
+// follow; everything after that is the city.  Words like LAKE and FOREST make this a guess.
+func splitStreetCity(tokens []string) ([]string, string) {
+	if m := poBox.FindStringSubmatchIndex(strings.Join(tokens, " ")); m != nil {
+		n := len(strings.Fields(strings.Join(tokens, " ")[:m[1]]))
+		return tokens[:n], strings.Join(tokens[n:], " ")
+	}
+
+	start := 0
+	if len(tokens) > 1 && isNumber(tokens[0]) {
+		start = 1
+		if tokens[1] == "1/2" || tokens[1] == "½" {
+			start = 2
+		}
+	}
+	end := -1
+	for _, wanted := range []map[string]bool{commonSuffixes, nil} {
+		for i := start + 1; i < len(tokens) && end < 0; i++ {
+			if abbrev, ok := suffixes[tokens[i]]; ok && (wanted == nil || wanted[abbrev]) {
+				end = i + 1
+			}
+		}
+	}
+	if end < 0 {
+		return tokens, "" // no idea where the street stops
+	}
+
+	if end < len(tokens) && isRoute(tokens[start:end]) && strings.ContainsAny(tokens[end], "0123456789") {
+		end++ // the route's number, e.g. COUNTY RD 12
+	}
+	if end < len(tokens) && isDirection(direction(tokens[end])) {
+		end++
+	}
+	if end < len(tokens) {
+		if _, ok := qualifiers[tokens[end]]; ok {
+			end++
+		}
+	}
+	if end < len(tokens) {
+		if tokens[end] == "#" && end+1 < len(tokens) {
+			end += 2
+		} else if strings.HasPrefix(tokens[end], "#") {
+			end++
+		} else if abbrev, ok := units[tokens[end]]; ok {
+			end++
+			if !numberless[abbrev] && end < len(tokens) {
+				end++
+			}
+		}
+	}
+	return tokens[:end], strings.Join(tokens[end:], " ")
+}
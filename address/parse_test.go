@@ -0,0 +1,40 @@
+package address
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Address
+		conf Confidence
+	}{
+		{"123 Main St Suite 4, Raleigh, NC 27601-1234",
+			Address{Number: "123", Name: "MAIN", Suffix: "ST", UnitType: "STE", UnitNum: "4", City: "RALEIGH", State: "NC", Zip: "27601-1234"}, High},
+		{"123 Main St Raleigh NC 27601",
+			Address{Number: "123", Name: "MAIN", Suffix: "ST", City: "RALEIGH", State: "NC", Zip: "27601"}, Medium},
+		{"55 Trinity Ave SW Apt 4B Atlanta Georgia",
+			Address{Number: "55", Name: "TRINITY", Suffix: "AVE", PostDir: "SW", UnitType: "APT", UnitNum: "4B", City: "ATLANTA", State: "GA"}, Medium},
+		{"Town Hall, 1 Main St., Apex, NC 27502, USA",
+			Address{Number: "1", Name: "MAIN", Suffix: "ST", City: "APEX", State: "NC", Zip: "27502"}, High},
+		{"1 Main St, Apt 2, Apex, NC 27502",
+			Address{Number: "1", Name: "MAIN", Suffix: "ST", UnitType: "APT", UnitNum: "2", City: "APEX", State: "NC", Zip: "27502"}, High},
+		{"PO Box 12, Cary, NC 27511",
+			Address{POBox: "12", City: "CARY", State: "NC", Zip: "27511"}, High},
+		{"P.O. Box 12 Cary NC",
+			Address{POBox: "12", City: "CARY", State: "NC"}, Medium},
+		{"100 County Road 12 Gainesville FL 32601",
+			Address{Number: "100", Name: "COUNTY ROAD 12", City: "GAINESVILLE", State: "FL", Zip: "32601"}, Medium},
+		{"100 NC HWY 54, Chapel Hill, NC 27516",
+			Address{Number: "100", Name: "NC HWY 54", City: "CHAPEL HILL", State: "NC", Zip: "27516"}, High},
+		{"100 NC HWY 54 Chapel Hill NC 27516",
+			Address{Number: "100", Name: "NC HWY 54", City: "CHAPEL HILL", State: "NC", Zip: "27516"}, Medium},
+		{"Raleigh, NC", Address{City: "RALEIGH", State: "NC"}, Low},
+		{"", Address{}, Low},
+	}
+	for _, tt := range tests {
+		got, conf := Parse(tt.in)
+		if got != tt.want || conf != tt.conf {
+			t.Errorf("Parse(%q) = %#v, %s; want %#v, %s", tt.in, got, conf, tt.want, tt.conf)
+		}
+	}
+}
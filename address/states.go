@@ -0,0 +1,70 @@
+package address
+
+// states maps the USPS state and territory abbreviations (Appendix B) onto their names
+var states = map[string]string{
+	"AL": "ALABAMA",
+	"AK": "ALASKA",
+	"AS": "AMERICAN SAMOA",
+	"AZ": "ARIZONA",
+	"AR": "ARKANSAS",
+	"CA": "CALIFORNIA",
+	"CO": "COLORADO",
+	"CT": "CONNECTICUT",
+	"DE": "DELAWARE",
+	"DC": "DISTRICT OF COLUMBIA",
+	"FL": "FLORIDA",
+	"GA": "GEORGIA",
+	"GU": "GUAM",
+	"HI": "HAWAII",
+	"ID": "IDAHO",
+	"IL": "ILLINOIS",
+	"IN": "INDIANA",
+	"IA": "IOWA",
+	"KS": "KANSAS",
+	"KY": "KENTUCKY",
+	"LA": "LOUISIANA",
+	"ME": "MAINE",
+	"MD": "MARYLAND",
+	"MA": "MASSACHUSETTS",
+	"MI": "MICHIGAN",
+	"MN": "MINNESOTA",
+	"MS": "MISSISSIPPI",
+	"MO": "MISSOURI",
+	"MT": "MONTANA",
+	"NE": "NEBRASKA",
+	"NV": "NEVADA",
+	"NH": "NEW HAMPSHIRE",
+	"NJ": "NEW JERSEY",
+	"NM": "NEW MEXICO",
+	"NY": "NEW YORK",
+	"NC": "NORTH CAROLINA",
+	"ND": "NORTH DAKOTA",
+	"MP": "NORTHERN MARIANA ISLANDS",
+	"OH": "OHIO",
+	"OK": "OKLAHOMA",
+	"OR": "OREGON",
+	"PA": "PENNSYLVANIA",
+	"PR": "PUERTO RICO",
+	"RI": "RHODE ISLAND",
+	"SC": "SOUTH CAROLINA",
+	"SD": "SOUTH DAKOTA",
+	"TN": "TENNESSEE",
+	"TX": "TEXAS",
+	"UT": "UTAH",
+	"VT": "VERMONT",
+	"VI": "VIRGIN ISLANDS",
+	"VA": "VIRGINIA",
+	"WA": "WASHINGTON",
+	"WV": "WEST VIRGINIA",
+	"WI": "WISCONSIN",
+	"WY": "WYOMING",
+}
+
+// stateNames is states turned around, for addresses that spell the state out
+var stateNames = func() map[string]string {
+	names := make(map[string]string, len(states))
+	for abbrev, name := range states {
+		names[name] = abbrev
+	}
+	return names
+}()
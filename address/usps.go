@@ -0,0 +1,245 @@
+package address
+
+// The tables below follow USPS Publication 28, Appendices B and C.  Each standard abbreviation
+// is listed with the spellings we've seen voter files use for it; the abbreviation itself is
+// always accepted too.
+
+// directionals maps spelled-out directions onto their Pub 28 abbreviations
+var directionals = map[string]string{
+	"NORTH":     "N",
+	"SOUTH":     "S",
+	"EAST":      "E",
+	"WEST":      "W",
+	"NORTHEAST": "NE",
+	"NORTHWEST": "NW",
+	"SOUTHEAST": "SE",
+	"SOUTHWEST": "SW",
+}
+
+// suffixSpellings lists the primary street suffix abbreviations (Appendix C1) and their variants
+var suffixSpellings = map[string][]string{
+	"ALY":  {"ALLEE", "ALLEY", "ALLY"},
+	"ANX":  {"ANEX", "ANNEX", "ANNX"},
+	"ARC":  {"ARCADE"},
+	"AVE":  {"AV", "AVEN", "AVENU", "AVENUE", "AVN", "AVNUE"},
+	"BYU":  {"BAYOO", "BAYOU"},
+	"BCH":  {"BEACH"},
+	"BND":  {"BEND"},
+	"BLF":  {"BLUF", "BLUFF"},
+	"BTM":  {"BOT", "BOTTM", "BOTTOM"},
+	"BLVD": {"BOUL", "BOULEVARD", "BOULV"},
+	"BR":   {"BRNCH", "BRANCH"},
+	"BRG":  {"BRDGE", "BRIDGE"},
+	"BRK":  {"BROOK"},
+	"BG":   {"BURG"},
+	"BYP":  {"BYPA", "BYPAS", "BYPASS", "BYPS"},
+	"CP":   {"CAMP", "CMP"},
+	"CYN":  {"CANYN", "CANYON", "CNYN"},
+	"CPE":  {"CAPE"},
+	"CSWY": {"CAUSEWAY", "CAUSWA"},
+	"CTR":  {"CEN", "CENT", "CENTER", "CENTR", "CENTRE", "CNTER", "CNTR"},
+	"CIR":  {"CIRC", "CIRCL", "CIRCLE", "CRCL", "CRCLE"},
+	"CLF":  {"CLIFF"},
+	"CLFS": {"CLIFFS"},
+	"CLB":  {"CLUB"},
+	"CMN":  {"COMMON"},
+	"COR":  {"CORNER"},
+	"CORS": {"CORNERS"},
+	"CRSE": {"COURSE"},
+	"CT":   {"COURT"},
+	"CTS":  {"COURTS"},
+	"CV":   {"COVE"},
+	"CRK":  {"CREEK"},
+	"CRES": {"CRESCENT", "CRSENT", "CRSNT"},
+	"CRST": {"CREST"},
+	"XING": {"CROSSING", "CRSSNG"},
+	"XRD":  {"CROSSROAD"},
+	"CURV": {"CURVE"},
+	"DL":   {"DALE"},
+	"DM":   {"DAM"},
+	"DV":   {"DIV", "DIVIDE", "DVD"},
+	"DR":   {"DRIV", "DRIVE", "DRV"},
+	"DRS":  {"DRIVES"},
+	"EST":  {"ESTATE"},
+	"ESTS": {"ESTATES"},
+	"EXPY": {"EXP", "EXPR", "EXPRESS", "EXPRESSWAY", "EXPW"},
+	"FLS":  {"FALLS"},
+	"FRY":  {"FERRY", "FRRY"},
+	"FLD":  {"FIELD"},
+	"FLDS": {"FIELDS"},
+	"FLT":  {"FLAT"},
+	"FRD":  {"FORD"},
+	"FRST": {"FOREST", "FORESTS"},
+	"FRG":  {"FORG", "FORGE"},
+	"FRK":  {"FORK"},
+	"FRKS": {"FORKS"},
+	"FT":   {"FORT", "FRT"},
+	"FWY":  {"FREEWAY", "FREEWY", "FRWAY", "FRWY"},
+	"GDN":  {"GARDEN", "GARDN", "GRDEN", "GRDN"},
+	"GDNS": {"GARDENS"},
+	"GTWY": {"GATEWAY", "GATEWY", "GATWAY", "GTWAY"},
+	"GLN":  {"GLEN"},
+	"GRN":  {"GREEN"},
+	"GRV":  {"GROV", "GROVE"},
+	"HBR":  {"HARB", "HARBOR", "HARBR", "HRBOR"},
+	"HVN":  {"HAVEN"},
+	"HTS":  {"HT", "HEIGHTS"},
+	"HWY":  {"HIGHWAY", "HIGHWY", "HIWAY", "HIWY", "HWAY"},
+	"HL":   {"HILL"},
+	"HLS":  {"HILLS"},
+	"HOLW": {"HLLW", "HOLLOW", "HOLLOWS", "HOLWS"},
+	"INLT": {"INLET"},
+	"IS":   {"ISLAND", "ISLND"},
+	"ISLE": {"ISLES"},
+	"JCT":  {"JCTION", "JCTN", "JUNCTION", "JUNCTN", "JUNCTON"},
+	"KNL":  {"KNOL", "KNOLL"},
+	"LK":   {"LAKE"},
+	"LKS":  {"LAKES"},
+	"LNDG": {"LANDING", "LNDNG"},
+	"LN":   {"LANE"},
+	"LOOP": {"LOOPS"},
+	"MALL": {},
+	"MNR":  {"MANOR"},
+	"MDW":  {"MEADOW"},
+	"MDWS": {"MEADOWS", "MEDOWS"},
+	"ML":   {"MILL"},
+	"MTWY": {"MOTORWAY"},
+	"MT":   {"MOUNT", "MNT"},
+	"MTN":  {"MNTAIN", "MNTN", "MOUNTAIN", "MOUNTIN", "MTIN"},
+	"OPAS": {"OVERPASS"},
+	"PARK": {"PRK"},
+	"PKWY": {"PARKWAY", "PARKWY", "PKWAY", "PKY"},
+	"PASS": {},
+	"PATH": {"PATHS"},
+	"PIKE": {"PIKES"},
+	"PNE":  {"PINE"},
+	"PNES": {"PINES"},
+	"PL":   {"PLACE"},
+	"PLN":  {"PLAIN"},
+	"PLNS": {"PLAINS"},
+	"PLZ":  {"PLAZA", "PLZA"},
+	"PT":   {"POINT"},
+	"PTS":  {"POINTS"},
+	"PRT":  {"PORT"},
+	"PR":   {"PRAIRIE", "PRR"},
+	"RADL": {"RAD", "RADIAL", "RADIEL"},
+	"RNCH": {"RANCH", "RANCHES", "RNCHS"},
+	"RDG":  {"RDGE", "RIDGE"},
+	"RIV":  {"RIVER", "RVR", "RIVR"},
+	"RD":   {"ROAD"},
+	"RTE":  {"ROUTE"},
+	"ROW":  {},
+	"RUN":  {},
+	"SHR":  {"SHORE"},
+	"SHRS": {"SHORES"},
+	"SKWY": {"SKYWAY"},
+	"SPG":  {"SPNG", "SPRING", "SPRNG"},
+	"SPGS": {"SPNGS", "SPRINGS", "SPRNGS"},
+	"SQ":   {"SQR", "SQRE", "SQU", "SQUARE"},
+	"STA":  {"STATION", "STATN", "STN"},
+	"STRM": {"STREAM", "STREME"},
+	"ST":   {"STREET", "STRT", "STR"},
+	"SMT":  {"SUMIT", "SUMITT", "SUMMIT"},
+	"TER":  {"TERR", "TERRACE"},
+	"TRCE": {"TRACE", "TRACES"},
+	"TRAK": {"TRACK", "TRACKS", "TRK", "TRKS"},
+	"TRL":  {"TRAIL", "TRAILS", "TRLS"},
+	"TRLR": {"TRAILER", "TRLRS"},
+	"TUNL": {"TUNEL", "TUNLS", "TUNNEL", "TUNNELS", "TUNNL"},
+	"TPKE": {"TRNPK", "TURNPIKE", "TURNPK"},
+	"UN":   {"UNION"},
+	"VLY":  {"VALLEY", "VALLY", "VLLY"},
+	"VW":   {"VIEW"},
+	"VLG":  {"VILL", "VILLAG", "VILLAGE", "VILLG", "VILLIAGE"},
+	"VL":   {"VILLE"},
+	"VIS":  {"VIST", "VISTA", "VST", "VSTA"},
+	"WALK": {"WALKS"},
+	"WAY":  {"WY"},
+	"WLS":  {"WELLS"},
+}
+
+// qualifierSpellings are the words NC's Street_sufx_cd puts after the suffix, other than directionals
+var qualifierSpellings = map[string][]string{
+	"EXT":  {"EXTENSION", "EXTN", "EXTNSN"},
+	"BUS":  {"BUSINESS", "BUSN"},
+	"BYP":  {"BYPASS"},
+	"ALT":  {"ALTERNATE"},
+	"CONN": {"CONNECTOR"},
+}
+
+// unitSpellings lists the secondary unit designators (Appendix C2) and their variants
+var unitSpellings = map[string][]string{
+	"APT":  {"APARTMENT", "APPT", "APRT"},
+	"BSMT": {"BASEMENT"},
+	"BLDG": {"BUILDING", "BLD"},
+	"DEPT": {"DEPARTMENT"},
+	"FL":   {"FLOOR", "FLR"},
+	"FRNT": {"FRONT"},
+	"HNGR": {"HANGAR"},
+	"KEY":  {},
+	"LBBY": {"LOBBY"},
+	"LOT":  {},
+	"LOWR": {"LOWER"},
+	"OFC":  {"OFFICE"},
+	"PH":   {"PENTHOUSE"},
+	"PIER": {},
+	"REAR": {},
+	"RM":   {"ROOM"},
+	"SIDE": {},
+	"SLIP": {},
+	"SPC":  {"SPACE"},
+	"STOP": {},
+	"STE":  {"SUITE"},
+	"TRLR": {"TRAILER"},
+	"UNIT": {},
+	"UPPR": {"UPPER"},
+	"#":    {"NUM", "NUMBER"},
+}
+
+// ordinals spells out the numbered street names Pub 28 wants written as numerals
+var ordinals = map[string]string{
+	"FIRST":       "1ST",
+	"SECOND":      "2ND",
+	"THIRD":       "3RD",
+	"FOURTH":      "4TH",
+	"FIFTH":       "5TH",
+	"SIXTH":       "6TH",
+	"SEVENTH":     "7TH",
+	"EIGHTH":      "8TH",
+	"NINTH":       "9TH",
+	"TENTH":       "10TH",
+	"ELEVENTH":    "11TH",
+	"TWELFTH":     "12TH",
+	"THIRTEENTH":  "13TH",
+	"FOURTEENTH":  "14TH",
+	"FIFTEENTH":   "15TH",
+	"SIXTEENTH":   "16TH",
+	"SEVENTEENTH": "17TH",
+	"EIGHTEENTH":  "18TH",
+	"NINETEENTH":  "19TH",
+	"TWENTIETH":   "20TH",
+}
+
+// halves are the ways a voter file writes a half house number
+var halves = map[string]bool{
+	"1/2":  true,
+	"½":    true,
+	"H":    true,
+	"HALF": true,
+}
+
+var suffixes = invert(suffixSpellings)
+var qualifiers = invert(qualifierSpellings)
+var units = invert(unitSpellings)
+
+// invert turns a table of abbreviation -> spellings into a lookup of spelling -> abbreviation
+func invert(spellings map[string][]string) map[string]string {
+	lookup := make(map[string]string)
+	for abbrev, words := range spellings {
+		lookup[abbrev] = abbrev
+		for _, word := range words {
+			lookup[word] = abbrev
+		}
+	}
+	return lookup
+}
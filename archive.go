@@ -0,0 +1,135 @@
+package hcip2
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// OpenSource opens a voter file for streaming, decompressing it on the way if its name ends in
+// .zip, .gz or .zst.  A particular entry of a ZIP can be asked for as archive.zip:entry.txt;
+// otherwise the biggest text entry is used, which is the snapshot in every archive the states
+// publish.  Close the result when done.
+func OpenSource(name string) (io.ReadCloser, error) {
+	archive, entry := name, ""
+	if i := strings.Index(strings.ToLower(name), ".zip:"); i >= 0 {
+		archive, entry = name[:i+4], name[i+5:]
+	}
+
+	switch strings.ToLower(path.Ext(archive)) {
+	case ".zip":
+		return openZip(archive, entry)
+	case ".gz":
+		file, err := os.Open(archive)
+		if err != nil {
+			return nil, err
+		}
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s: %s", archive, err)
+		}
+		return &stackedCloser{Reader: gz, closers: []io.Closer{gz, file}}, nil
+	case ".zst":
+		file, err := os.Open(archive)
+		if err != nil {
+			return nil, err
+		}
+		zr, err := zstd.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s: %s", archive, err)
+		}
+		return &stackedCloser{Reader: zr, closers: []io.Closer{zstdCloser{zr}, file}}, nil
+	}
+	return os.Open(name)
+}
+
+// openZip opens the named entry of a ZIP archive, or picks one if entry is empty
+func openZip(archive string, entry string) (io.ReadCloser, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	var chosen *zip.File
+	if entry != "" {
+		for _, f := range zr.File {
+			if f.Name == entry || path.Base(f.Name) == entry {
+				chosen = f
+				break
+			}
+		}
+		if chosen == nil {
+			zr.Close()
+			return nil, fmt.Errorf("%s has no entry %s", archive, entry)
+		}
+	} else {
+		chosen = pickEntry(zr.File)
+		if chosen == nil {
+			zr.Close()
+			return nil, fmt.Errorf("%s has no voter file in it; name one as %s:<entry>", archive, archive)
+		}
+	}
+
+	rc, err := chosen.Open()
+	if err != nil {
+		zr.Close()
+		return nil, fmt.Errorf("%s:%s: %s", archive, chosen.Name, err)
+	}
+	return &stackedCloser{Reader: rc, closers: []io.Closer{rc, zr}}, nil
+}
+
+// pickEntry chooses the voter file among a ZIP's entries: the biggest .txt, .csv or .tsv,
+// passing over the layout documents and READMEs that sometimes ride along
+func pickEntry(files []*zip.File) *zip.File {
+	var best *zip.File
+	for _, f := range files {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		switch strings.ToLower(path.Ext(f.Name)) {
+		case ".txt", ".csv", ".tsv":
+		default:
+			continue
+		}
+		if strings.Contains(strings.ToLower(path.Base(f.Name)), "readme") {
+			continue
+		}
+		if best == nil || f.UncompressedSize64 > best.UncompressedSize64 {
+			best = f
+		}
+	}
+	return best
+}
+
+// stackedCloser reads from the innermost of a stack of readers and closes all of them, in order
+type stackedCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (sc *stackedCloser) Close() error {
+	var first error
+	for _, c := range sc.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// zstdCloser adapts zstd.Decoder, whose Close returns nothing, to io.Closer
+type zstdCloser struct {
+	*zstd.Decoder
+}
+
+func (zc zstdCloser) Close() error {
+	zc.Decoder.Close()
+	return nil
+}
@@ -0,0 +1,94 @@
+package hcip2
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const snapshot = "id\tcity\n1\tRALEIGH\n"
+
+// createFile writes the file at path with write
+func createFile(t *testing.T, path string, write func(w io.Writer) error) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err = write(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readSource(t *testing.T, name string) string {
+	rc, err := OpenSource(name)
+	if err != nil {
+		t.Fatalf("OpenSource(%s): %s", name, err)
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("reading %s: %s", name, err)
+	}
+	return string(data)
+}
+
+func TestOpenSource(t *testing.T) {
+	dir := t.TempDir()
+
+	plain := filepath.Join(dir, "voters.txt")
+	createFile(t, plain, func(w io.Writer) error {
+		_, err := io.WriteString(w, snapshot)
+		return err
+	})
+	gz := filepath.Join(dir, "voters.txt.gz")
+	createFile(t, gz, func(w io.Writer) error {
+		zw := gzip.NewWriter(w)
+		io.WriteString(zw, snapshot)
+		return zw.Close()
+	})
+	zst := filepath.Join(dir, "voters.txt.zst")
+	createFile(t, zst, func(w io.Writer) error {
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		io.WriteString(zw, snapshot)
+		return zw.Close()
+	})
+	archive := filepath.Join(dir, "voters.zip")
+	createFile(t, archive, func(w io.Writer) error {
+		zw := zip.NewWriter(w)
+		for name, body := range map[string]string{
+			"layout.pdf":      "not a voter file, and bigger than one" + snapshot,
+			"README.txt":      "also not a voter file" + snapshot,
+			"data/voters.txt": snapshot,
+			"data/other.txt":  "1\n",
+		} {
+			entry, err := zw.Create(name)
+			if err != nil {
+				return err
+			}
+			io.WriteString(entry, body)
+		}
+		return zw.Close()
+	})
+
+	for _, name := range []string{plain, gz, zst, archive, archive + ":voters.txt", archive + ":data/voters.txt"} {
+		if got := readSource(t, name); got != snapshot {
+			t.Errorf("%s read as %q", name, got)
+		}
+	}
+	if got := readSource(t, archive+":other.txt"); got != "1\n" {
+		t.Errorf("the other.txt entry read as %q", got)
+	}
+	if _, err := OpenSource(archive + ":missing.txt"); err == nil {
+		t.Error("a missing entry opened")
+	}
+}
@@ -0,0 +1,364 @@
+package hcip2
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// The voter cache is a columnar copy of a parsed snapshot.  Its layout, all little-endian:
+//
+//	magic "HCIPVC01", 32-byte key, uint32 voter count, uint32 string count
+//	the string table: uint32 length + bytes, for every distinct string
+//	one column per cached Voter field, in voterColumns order:
+//	  strings as uint32 indexes into the string table, bytes as bytes, bools as a 0 or 1 byte,
+//	  ints as int32, dates as int32 days since 1970 (math.MinInt32 for none), floats as float64
+//
+// The key covers the source file, the layout, the filters and the Voter fields, so any change
+// to those makes a new cache instead of reading a stale one.
+
+const cacheMagic = "HCIPVC01"
+
+// cacheColumn is one Voter field as it's stored in the cache
+type cacheColumn struct {
+	name   string
+	offset uintptr // where the field is in a Voter; reflect is too slow for millions of voters
+	kind   reflect.Kind
+	date   bool
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// voterColumns lists the Voter fields the cache stores, descending into structs like Address
+// and arrays like Districts
+var voterColumns = cacheColumns(reflect.TypeOf(Voter{}), "", 0)
+
+func cacheColumns(t reflect.Type, prefix string, base uintptr) []cacheColumn {
+	var cols []cacheColumn
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		cols = append(cols, cacheColumnsOf(f.Type, prefix+f.Name, base+f.Offset)...)
+	}
+	return cols
+}
+
+// cacheColumnsOf lists the columns of one value of type t, named name, at offset
+func cacheColumnsOf(t reflect.Type, name string, offset uintptr) []cacheColumn {
+	col := cacheColumn{name: name, offset: offset, kind: t.Kind()}
+	switch {
+	case t == timeType:
+		col.date = true
+	case col.kind == reflect.Struct:
+		return cacheColumns(t, name+".", offset)
+	case col.kind == reflect.Array:
+		var cols []cacheColumn
+		for i := 0; i < t.Len(); i++ {
+			cols = append(cols, cacheColumnsOf(t.Elem(), fmt.Sprintf("%s[%d]", name, i), offset+uintptr(i)*t.Elem().Size())...)
+		}
+		return cols
+	case col.kind != reflect.String && col.kind != reflect.Uint8 && col.kind != reflect.Bool && col.kind != reflect.Int && col.kind != reflect.Float64:
+		panic(fmt.Sprintf("voter cache can't store %s %s", name, t))
+	}
+	return []cacheColumn{col}
+}
+
+// field points at a column's value in a Voter
+func (col *cacheColumn) field(voter *Voter) unsafe.Pointer {
+	return unsafe.Pointer(uintptr(unsafe.Pointer(voter)) + col.offset)
+}
+
+// CacheKey identifies a parsed snapshot: the source file, by a hash of its contents, plus
+// everything about the layout that changes which voters come out of it and how.  A layout read
+// from a file is known by the file's contents, so the rules compiled out of it count too; the
+// rules built into NC, FL and the rest only change with the code.
+func CacheKey(path string, config HciConfig) ([32]byte, error) {
+	var key [32]byte
+	file, err := os.Open(path)
+	if err != nil {
+		return key, err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return key, fmt.Errorf("Error hashing %s: %s", path, err)
+	}
+	fmt.Fprintf(h, "\x00%d\x00", len(config.Source))
+	h.Write(config.Source)
+	fmt.Fprintf(h, "\x00%d,%d,%d,%d,%d\x00", config.CITY, config.STATE, config.ZIP, config.STATE_VOTER_ID, config.MaxLineLength)
+	fmt.Fprintf(h, "%d,%q,%d,%d\x00", config.Header, config.Delimiter, config.Quoting, config.Encoding)
+	fmt.Fprintf(h, "\x00%s\x00%q\x00%q\x00%s\x00%q\x00%q\x00", config.StateAbbrev, config.Columns, config.DateFormat, sortedFields(config.Fields), config.FilterExprs, config.Road)
+	fmt.Fprintf(h, "%s\x00%s\x00", sortedFields(config.AddressFields), config.statusPolicy())
+	codes := make(map[string]int, len(config.StatusCodes))
+	for code, status := range config.StatusCodes {
+		codes[code] = int(status)
+	}
+	fmt.Fprintf(h, "%s\x00", sortedFields(codes))
+	districts := make(map[string]int, 2*len(config.Districts))
+	for t, cols := range config.Districts {
+		districts[t.String()+".Code"] = cols.Code
+		districts[t.String()+".Name"] = cols.Name
+	}
+	fmt.Fprintf(h, "%s\x00", sortedFields(districts))
+	for _, col := range voterColumns {
+		fmt.Fprintf(h, "%s:%v:%t\x00", col.name, col.kind, col.date)
+	}
+	copy(key[:], h.Sum(nil))
+	return key, nil
+}
+
+func sortedFields(fields map[string]int) string {
+	names := make([]string, 0, len(fields))
+	for name, col := range fields {
+		names = append(names, fmt.Sprintf("%s=%d", name, col))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// CachePath is where the cache of a snapshot with the given key lives: next to the snapshot
+func CachePath(path string, key [32]byte) string {
+	return fmt.Sprintf("%s.%s.vcache", path, hex.EncodeToString(key[:6]))
+}
+
+// LoadVotersCached is LoadVoters, except that the parsed snapshot is kept in a cache file next
+// to the source (see CachePath).  The first load parses the source and writes the cache; later
+// loads of the same file with the same layout and filters map the cache in instead.  Ages worked
+// out from birth dates are worked out again, as the cache may be older than today.
+func LoadVotersCached(path string, config HciConfig) (map[string]*Voter, error) {
+	key, err := CacheKey(path, config)
+	if err != nil {
+		return nil, err
+	}
+	cachePath := CachePath(path, key)
+	if voters, err := ReadVoterCache(cachePath, key); err == nil {
+		today := time.Now()
+		for _, voter := range voters {
+			setAge(voter, config, today)
+		}
+		return voters, nil
+	} else if !os.IsNotExist(err) {
+		fmt.Printf("WARNING: ignoring voter cache %s: %s\n", cachePath, err)
+	}
+
+	rejected := config.Rejects.Rejected()
+	voters, err := LoadVoters(path, config)
+	if err != nil {
+		return nil, err
+	}
+	if n := config.Rejects.Rejected() - rejected; n > 0 {
+		// cached, the rejected rows would silently disappear from later runs
+		fmt.Printf("WARNING: not caching %s, since %d rows were rejected\n", path, n)
+		return voters, nil
+	}
+	if err = WriteVoterCache(cachePath, key, voters); err != nil {
+		fmt.Printf("WARNING: couldn't write voter cache %s: %s\n", cachePath, err)
+	}
+	return voters, nil
+}
+
+// WriteVoterCache writes voters out as a cache file with the given key.  The file is written
+// under a temporary name and renamed into place, so a half-written cache is never read.
+func WriteVoterCache(path string, key [32]byte, voters map[string]*Voter) error {
+	ids := make([]string, 0, len(voters))
+	for id := range voters {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	values := make([]*Voter, len(ids))
+	for i, id := range ids {
+		values[i] = voters[id]
+	}
+
+	// intern every string, so each is stored once no matter how many voters share it
+	interned := map[string]uint32{}
+	var table []string
+	for _, col := range voterColumns {
+		if col.kind != reflect.String {
+			continue
+		}
+		for _, v := range values {
+			s := *(*string)(col.field(v))
+			if _, ok := interned[s]; !ok {
+				interned[s] = uint32(len(table))
+				table = append(table, s)
+			}
+		}
+	}
+
+	tmp, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriterSize(tmp, 1<<20)
+	var scratch [8]byte
+	put32 := func(n uint32) {
+		binary.LittleEndian.PutUint32(scratch[:], n)
+		w.Write(scratch[:4])
+	}
+	put64 := func(n uint64) {
+		binary.LittleEndian.PutUint64(scratch[:], n)
+		w.Write(scratch[:])
+	}
+
+	w.WriteString(cacheMagic)
+	w.Write(key[:])
+	put32(uint32(len(values)))
+	put32(uint32(len(table)))
+	for _, s := range table {
+		put32(uint32(len(s)))
+		w.WriteString(s)
+	}
+	for _, col := range voterColumns {
+		for _, v := range values {
+			f := col.field(v)
+			switch {
+			case col.date:
+				put32(uint32(toDays(*(*time.Time)(f))))
+			case col.kind == reflect.String:
+				put32(interned[*(*string)(f)])
+			case col.kind == reflect.Uint8:
+				w.WriteByte(*(*byte)(f))
+			case col.kind == reflect.Bool:
+				if *(*bool)(f) {
+					w.WriteByte(1)
+				} else {
+					w.WriteByte(0)
+				}
+			case col.kind == reflect.Int:
+				put32(uint32(int32(*(*int)(f))))
+			case col.kind == reflect.Float64:
+				put64(math.Float64bits(*(*float64)(f)))
+			}
+		}
+	}
+
+	if err = w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadVoterCache loads a cache file written by WriteVoterCache, provided its key matches
+func ReadVoterCache(path string, key [32]byte) (map[string]*Voter, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unmap()
+
+	r := &cacheReader{data: data}
+	if string(r.next(len(cacheMagic))) != cacheMagic {
+		return nil, fmt.Errorf("not a voter cache")
+	}
+	if !bytes.Equal(r.next(len(key)), key[:]) {
+		return nil, fmt.Errorf("cache is for a different file or layout")
+	}
+	n := int(r.uint32())
+	table := make([]string, r.uint32())
+	for i := range table {
+		table[i] = string(r.next(int(r.uint32())))
+	}
+
+	voters := make([]Voter, n)
+	for _, col := range voterColumns {
+		for i := range voters {
+			f := col.field(&voters[i])
+			switch {
+			case col.date:
+				*(*time.Time)(f) = fromDays(int32(r.uint32()))
+			case col.kind == reflect.String:
+				idx := r.uint32()
+				if int(idx) >= len(table) {
+					r.err = fmt.Errorf("bad string index %d", idx)
+				} else {
+					*(*string)(f) = table[idx]
+				}
+			case col.kind == reflect.Uint8:
+				if b := r.next(1); b != nil {
+					*(*byte)(f) = b[0]
+				}
+			case col.kind == reflect.Bool:
+				if b := r.next(1); b != nil {
+					*(*bool)(f) = b[0] != 0
+				}
+			case col.kind == reflect.Int:
+				*(*int)(f) = int(int32(r.uint32()))
+			case col.kind == reflect.Float64:
+				*(*float64)(f) = math.Float64frombits(r.uint64())
+			}
+		}
+		if r.err != nil {
+			return nil, fmt.Errorf("%s: %s", col.name, r.err)
+		}
+	}
+
+	byID := make(map[string]*Voter, n)
+	for i := range voters {
+		byID[voters[i].StateVoterID] = &voters[i]
+	}
+	return byID, nil
+}
+
+// cacheReader walks through a mapped cache file, remembering the first read past the end
+type cacheReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *cacheReader) next(n int) []byte {
+	if r.err != nil || r.pos+n > len(r.data) {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *cacheReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *cacheReader) uint64() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+func toDays(t time.Time) int32 {
+	if t.IsZero() {
+		return math.MinInt32
+	}
+	return int32(t.Unix() / secondsPerDay)
+}
+
+func fromDays(days int32) time.Time {
+	if days == math.MinInt32 {
+		return time.Time{}
+	}
+	return time.Unix(int64(days)*secondsPerDay, 0).UTC()
+}
@@ -0,0 +1,124 @@
+package hcip2
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVoterCacheRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	data, err := ioutil.ReadFile("testdata/ga_voters.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeFile(t, dir, "ga_voters.txt", string(data))
+
+	config := GA
+	config.Policy = StatusPolicy{Active: true, Inactive: true, Removed: true}
+	want, err := LoadVoters(path, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := LoadVotersCached(path, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := CacheKey(path, config)
+	if _, err := os.Stat(CachePath(path, key)); err != nil {
+		t.Fatalf("no cache was written: %s", err)
+	}
+	cached, err := LoadVotersCached(path, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, got := range []map[string]*Voter{first, cached} {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("cached voters differ:\n%+v\nwant\n%+v", got, want)
+		}
+	}
+
+	if _, err := ReadVoterCache(CachePath(path, key), [32]byte{1}); err == nil {
+		t.Error("a cache was read under the wrong key")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "voters.txt", "snapshot")
+	const layout = `{"columns": ["id", "city", "zip", "flag"], "voter_id": "id", "city": "city", "zip": "zip"`
+	base := loadLayout(t, dir, layout+`}`)
+
+	keys := make(map[[32]byte]string)
+	add := func(name string, config HciConfig) {
+		key, err := CacheKey(path, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other, ok := keys[key]; ok {
+			t.Errorf("%s has the same key as %s", name, other)
+		}
+		keys[key] = name
+	}
+	add("the base layout", base)
+	add("a filter rule", loadLayout(t, dir, layout+`, "filters": [{"column": "flag", "op": "in", "values": ["Y"]}]}`))
+	add("a confidential rule", loadLayout(t, dir, layout+`, "confidential": {"column": "flag", "op": "in", "values": ["Y"]}}`))
+	add("a longer line", loadLayout(t, dir, layout+`, "max_line_length": 2000}`))
+
+	moved := base
+	moved.ZIP = 3
+	add("another ZIP column", moved)
+
+	filtered := base
+	if err := filtered.AddFilter(`flag == "Y"`); err != nil {
+		t.Fatal(err)
+	}
+	add("a -filter", filtered)
+
+	// the snapshot is known by its contents, not when it was written
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(path, later, later)
+	if key, _ := CacheKey(path, base); keys[key] != "the base layout" {
+		t.Errorf("touching the snapshot changed its key")
+	}
+	writeFile(t, dir, "voters.txt", "snapshop")
+	os.Chtimes(path, later, later)
+	add("a rewritten snapshot", base)
+}
+
+func TestVoterCacheAge(t *testing.T) {
+	dir := t.TempDir()
+	config := loadLayout(t, dir, `{
+		"columns": ["id", "city", "zip", "born"],
+		"delimiter": "|",
+		"voter_id": "id",
+		"city": "city",
+		"zip": "zip",
+		"fields": {"Birthdate": "born"}
+	}`)
+	born := time.Now().AddDate(-30, 0, 0).Format("2006-01-02")
+	path := writeFile(t, dir, "voters.txt", "id|city|zip|born\n1|APEX|27502|"+born+"\n")
+
+	voters, err := LoadVotersCached(path, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if voters["1"].Age != 30 {
+		t.Fatalf("age %d, want 30", voters["1"].Age)
+	}
+
+	// a cache written a year ago holds the age the voter was then
+	key, _ := CacheKey(path, config)
+	voters["1"].Age = 29
+	if err = WriteVoterCache(CachePath(path, key), key, voters); err != nil {
+		t.Fatal(err)
+	}
+	if voters, err = LoadVotersCached(path, config); err != nil {
+		t.Fatal(err)
+	}
+	if voters["1"].Age != 30 {
+		t.Errorf("cached age %d, want 30", voters["1"].Age)
+	}
+}
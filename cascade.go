@@ -0,0 +1,152 @@
+package hcip2
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// Strategy is one way of asking a geocoder about an address
+type Strategy int
+
+const (
+	StrategyRoad        Strategy = iota // the whole street address, unit and all
+	StrategyNoUnit                      // without the unit
+	StrategyNoCity                      // without the unit or the city
+	StrategyZip5                        // just the street and the five-digit ZIP
+	StrategyFreeForm                    // the address without its unit, as free-form text
+	StrategyZipCentroid                 // the ZIP alone, as a last resort
+	numStrategies
+)
+
+// strategyNames are the names -cascade and strategies.csv use
+var strategyNames = [numStrategies]string{"road", "no_unit", "no_city", "zip5", "freeform", "zip_centroid"}
+
+// DefaultCascade is every strategy, from the most exact to the least
+const DefaultCascade = "road,no_unit,no_city,zip5,freeform,zip_centroid"
+
+func (st Strategy) String() string {
+	if st < 0 || st >= numStrategies {
+		return fmt.Sprintf("Strategy(%d)", int(st))
+	}
+	return strategyNames[st]
+}
+
+// ParseCascade reads a comma-separated list of strategies, to be tried in that order
+func ParseCascade(s string) ([]Strategy, error) {
+	var steps []Strategy
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for st, n := range strategyNames {
+			if n == name {
+				steps = append(steps, Strategy(st))
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown strategy %q; use some of %s", name, strings.Join(strategyNames[:], ", "))
+		}
+	}
+	return steps, nil
+}
+
+// query is what the strategy asks about addr: a structured address, or free-form text.  ok is
+// false when the strategy has nothing to ask, like a ZIP for an address without one.
+func (st Strategy) query(addr address.Address) (q address.Address, text string, ok bool) {
+	if st != StrategyRoad {
+		addr.UnitType, addr.UnitNum = "", ""
+	}
+	switch st {
+	case StrategyNoCity:
+		addr.City = ""
+	case StrategyZip5:
+		addr.City, addr.State, addr.Zip = "", "", zip5(addr.Zip)
+		if addr.Zip == "" {
+			return addr, "", false
+		}
+	case StrategyFreeForm:
+		return addr, addr.String(), addr.Street() != ""
+	case StrategyZipCentroid:
+		addr = address.Address{State: addr.State, Zip: zip5(addr.Zip)}
+		return addr, "", addr.Zip != ""
+	}
+	return addr, "", addr.Street() != ""
+}
+
+// Answer is what a cascade made of an address
+type Answer struct {
+	Matches  []Match
+	Strategy Strategy // the strategy that found them
+	Err      error    // the geocoder failing, which ends the cascade for the address
+}
+
+// Cascade geocodes addrs with each strategy of steps in turn, asking with the next only about
+// the addresses the ones before it didn't settle.  settled says whether the matches for addrs[i]
+// are good enough to stop at.  An address no strategy settles is answered by the first that
+// found anything for it, or by nothing.  A strategy that would ask the same as one before it is
+// skipped, and a geocoder that can't search free-form text just finds nothing.
+func (p *GeocodePool) Cascade(addrs []address.Address, steps []Strategy, settled func(i int, matches []Match) bool) []Answer {
+	answers := make([]Answer, len(addrs))
+	asked := make([]map[string]bool, len(addrs))
+	todo := make([]int, len(addrs))
+	for i := range todo {
+		todo[i] = i
+		asked[i] = make(map[string]bool)
+	}
+
+	for _, step := range steps {
+		// ask about every address still to do at once, on the workers
+		var ask []int
+		var queries []address.Address
+		var texts []string
+		for _, i := range todo {
+			q, text, ok := step.query(addrs[i])
+			key := AddressQuery(q)
+			if step == StrategyFreeForm {
+				key = textQuery(text)
+			}
+			if !ok || asked[i][key] {
+				continue
+			}
+			asked[i][key] = true
+			ask = append(ask, i)
+			queries = append(queries, q)
+			texts = append(texts, text)
+		}
+		if len(ask) == 0 {
+			continue
+		}
+		var matches [][]Match
+		var errs []error
+		if step == StrategyFreeForm {
+			matches, errs = p.SearchAll(texts)
+		} else {
+			matches, errs = p.GeocodeAll(queries)
+		}
+
+		done := make(map[int]bool)
+		for j, i := range ask {
+			switch {
+			case errs[j] == ErrFreeForm:
+			case errs[j] != nil:
+				answers[i] = Answer{Strategy: step, Err: errs[j]}
+				done[i] = true
+			case settled(i, matches[j]):
+				answers[i] = Answer{Matches: matches[j], Strategy: step}
+				done[i] = true
+			case len(answers[i].Matches) == 0 && len(matches[j]) > 0:
+				answers[i] = Answer{Matches: matches[j], Strategy: step}
+			}
+		}
+		var next []int
+		for _, i := range todo {
+			if !done[i] {
+				next = append(next, i)
+			}
+		}
+		todo = next
+	}
+	return answers
+}
@@ -0,0 +1,169 @@
+package hcip2
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/skemper/hcip2/address"
+)
+
+func TestParseCascade(t *testing.T) {
+	steps, err := ParseCascade(DefaultCascade)
+	if err != nil || len(steps) != int(numStrategies) {
+		t.Fatalf("ParseCascade(DefaultCascade) = %v, %v", steps, err)
+	}
+	for i, st := range steps {
+		if st != Strategy(i) {
+			t.Errorf("step %d is %s", i, st)
+		}
+	}
+	steps, err = ParseCascade(" ZIP5, road ")
+	if err != nil || !reflect.DeepEqual(steps, []Strategy{StrategyZip5, StrategyRoad}) {
+		t.Errorf("ParseCascade = %v, %v", steps, err)
+	}
+	for _, bad := range []string{"", "road,,zip5", "street"} {
+		if _, err := ParseCascade(bad); err == nil {
+			t.Errorf("ParseCascade(%q) should have failed", bad)
+		}
+	}
+	if s := Strategy(99).String(); s != "Strategy(99)" {
+		t.Errorf("Strategy(99).String() = %q", s)
+	}
+}
+
+func TestStrategyQuery(t *testing.T) {
+	addr := address.Address{Number: "100", Name: "MAIN", Suffix: "ST", UnitType: "APT", UnitNum: "2", City: "APEX", State: "NC", Zip: "27502-1234"}
+	tests := []struct {
+		st   Strategy
+		q    string // AddressQuery of the structured query
+		text string
+		ok   bool
+	}{
+		{StrategyRoad, "geocode|100 MAIN ST APT 2|APEX|NC|27502", "", true},
+		{StrategyNoUnit, "geocode|100 MAIN ST|APEX|NC|27502", "", true},
+		{StrategyNoCity, "geocode|100 MAIN ST||NC|27502", "", true},
+		{StrategyZip5, "geocode|100 MAIN ST|||27502", "", true},
+		{StrategyFreeForm, "geocode|100 MAIN ST|APEX|NC|27502", "100 MAIN ST, APEX NC 27502-1234", true},
+		{StrategyZipCentroid, "geocode|||NC|27502", "", true},
+	}
+	for _, test := range tests {
+		q, text, ok := test.st.query(addr)
+		if AddressQuery(q) != test.q || text != test.text || ok != test.ok {
+			t.Errorf("%s: %q, %q, %v", test.st, AddressQuery(q), text, ok)
+		}
+	}
+
+	noZip := address.Address{Number: "100", Name: "MAIN", Suffix: "ST", City: "APEX", State: "NC"}
+	for _, st := range []Strategy{StrategyZip5, StrategyZipCentroid} {
+		if _, _, ok := st.query(noZip); ok {
+			t.Errorf("%s asked about an address without a ZIP", st)
+		}
+	}
+	if _, _, ok := StrategyRoad.query(address.Address{City: "APEX", Zip: "27502"}); ok {
+		t.Error("road asked about an address without a street")
+	}
+}
+
+// scriptedGeocoder answers the queries it knows, by AddressQuery or textQuery, and fails on
+// the ZIP 99999.  It counts what it was asked.
+type scriptedGeocoder struct {
+	answers  map[string][]Match
+	freeForm bool
+	asked    map[string]int
+	mu       sync.Mutex
+}
+
+func (g *scriptedGeocoder) Name() string { return "scripted" }
+
+func (g *scriptedGeocoder) answer(query string) ([]Match, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.asked[query]++
+	return g.answers[query], nil
+}
+
+func (g *scriptedGeocoder) Geocode(addr address.Address) ([]Match, error) {
+	if addr.Zip == "99999" {
+		return nil, errors.New("down")
+	}
+	return g.answer(AddressQuery(addr))
+}
+
+func (g *scriptedGeocoder) Search(text string) ([]Match, error) {
+	if !g.freeForm {
+		return nil, ErrFreeForm
+	}
+	return g.answer(textQuery(text))
+}
+
+func TestCascade(t *testing.T) {
+	house := Match{Lat: 35.7, Lon: -78.8, Kind: "house"}
+	postcode := Match{Lat: 35.6, Lon: -78.9, Kind: "postcode"}
+	g := &scriptedGeocoder{
+		answers: map[string][]Match{
+			"geocode|1 A ST APT 1|APEX|NC|27502": {house},
+			"geocode|2 B ST|APEX|NC|27502":       {house}, // only found without its unit
+			"search|3 C ST, APEX NC 27502":       {house}, // only found free-form
+			"geocode|||NC|27502":                 {postcode},
+		},
+		freeForm: true,
+		asked:    make(map[string]int),
+	}
+	street := func(number, name, unit, zip string) address.Address {
+		addr := address.Address{Number: number, Name: name, Suffix: "ST", City: "APEX", State: "NC", Zip: zip}
+		if unit != "" {
+			addr.UnitType, addr.UnitNum = "APT", unit
+		}
+		return addr
+	}
+	addrs := []address.Address{
+		street("1", "A", "1", "27502"),
+		street("2", "B", "2", "27502"),
+		street("3", "C", "", "27502"),
+		street("4", "D", "", "27502"), // nowhere to be found but its ZIP
+		street("5", "E", "", "99999"),
+		street("6", "F", "", ""),
+	}
+	settled := func(i int, matches []Match) bool {
+		return len(matches) > 0 && matches[0].Kind == "house"
+	}
+	steps, _ := ParseCascade(DefaultCascade)
+	answers := NewGeocodePool(g, 3, 0).Cascade(addrs, steps, settled)
+
+	want := []Answer{
+		{Matches: []Match{house}, Strategy: StrategyRoad},
+		{Matches: []Match{house}, Strategy: StrategyNoUnit},
+		{Matches: []Match{house}, Strategy: StrategyFreeForm},
+		{Matches: []Match{postcode}, Strategy: StrategyZipCentroid},
+		{Strategy: StrategyRoad},
+		{},
+	}
+	for i := range want {
+		got := answers[i]
+		if (got.Err != nil) != (i == 4) {
+			t.Errorf("address %d: error %v", i, got.Err)
+		}
+		got.Err = nil
+		if !reflect.DeepEqual(got.Matches, want[i].Matches) || got.Strategy != want[i].Strategy {
+			t.Errorf("address %d: %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	// the settled address isn't asked about again, and an address without a unit is only asked
+	// about once for road and no_unit
+	if n := g.asked["geocode|1 A ST|APEX|NC|27502"]; n != 0 {
+		t.Errorf("a settled address was asked about %d more times", n)
+	}
+	if n := g.asked["geocode|4 D ST|APEX|NC|27502"]; n != 1 {
+		t.Errorf("road and no_unit asked the same %d times", n)
+	}
+
+	// a geocoder without free-form search just finds nothing for that step
+	g.freeForm = false
+	answers = NewGeocodePool(g, 1, 0).Cascade(addrs[2:3], []Strategy{StrategyFreeForm, StrategyZipCentroid}, settled)
+	if answers[0].Err != nil || answers[0].Strategy != StrategyZipCentroid {
+		t.Errorf("without free-form search: %+v", answers[0])
+	}
+}
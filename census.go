@@ -0,0 +1,156 @@
+package hcip2
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// censusBatchSize is the most addresses the Census batch geocoder takes in one file
+const censusBatchSize = 10000
+
+// Census geocodes with the US Census Bureau's batch geocoder, which takes a CSV file of
+// addresses and answers with a CSV of matches.  It matches against TIGER address ranges, so
+// its coordinates are interpolated along the street.  It only takes structured addresses, and
+// it reports a tie without saying what tied, so a tie comes back as two matches with Kind "tie"
+// and no coordinates.
+type Census struct {
+	BaseURL   string       // e.g. https://geocoding.geo.census.gov/geocoder
+	Client    *http.Client // http.DefaultClient if nil
+	Benchmark string       // the address vintage to match against; Public_AR_Current if empty
+}
+
+func (c *Census) Name() string { return "census" }
+
+// Geocode sends a batch of one
+func (c *Census) Geocode(addr address.Address) ([]Match, error) {
+	matches, err := c.GeocodeBatch([]address.Address{addr})
+	if err != nil {
+		return nil, err
+	}
+	return matches[0], nil
+}
+
+// Search isn't something the batch geocoder can do
+func (c *Census) Search(text string) ([]Match, error) {
+	return nil, ErrFreeForm
+}
+
+// GeocodeBatch sends the addresses censusBatchSize at a time
+func (c *Census) GeocodeBatch(addrs []address.Address) ([][]Match, error) {
+	matches := make([][]Match, 0, len(addrs))
+	for start := 0; start < len(addrs); start += censusBatchSize {
+		end := start + censusBatchSize
+		if end > len(addrs) {
+			end = len(addrs)
+		}
+		batch, err := c.batch(addrs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, batch...)
+	}
+	return matches, nil
+}
+
+// batch sends one file of addresses, each identified by its index
+func (c *Census) batch(addrs []address.Address) ([][]Match, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	benchmark := c.Benchmark
+	if benchmark == "" {
+		benchmark = "Public_AR_Current"
+	}
+	form.WriteField("benchmark", benchmark)
+	file, err := form.CreateFormFile("addressFile", "addresses.csv")
+	if err != nil {
+		return nil, err
+	}
+	w := csv.NewWriter(file)
+	for i, addr := range addrs {
+		w.Write([]string{strconv.Itoa(i), addr.Line(), addr.City, addr.State, addr.Zip})
+	}
+	w.Flush()
+	if err = form.Close(); err != nil {
+		return nil, err
+	}
+
+	u := c.BaseURL + "/locations/addressbatch"
+	resp, err := httpClient(c.Client).Post(u, form.FormDataContentType(), &body)
+	if err != nil {
+		return nil, fmt.Errorf("Error calling the Census geocoder: %s", err)
+	}
+	defer closeBody(resp)
+	if err = checkResponse(resp, u); err != nil {
+		return nil, fmt.Errorf("Error calling the Census geocoder: %s", err)
+	}
+
+	// the rows come back in whatever order the geocoder finished them
+	matches := make([][]Match, len(addrs))
+	r := csv.NewReader(resp.Body)
+	r.FieldsPerRecord = -1
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error reading the Census geocoder's answer: %s", err)
+		}
+		i, err := strconv.Atoi(strings.TrimSpace(row[0]))
+		if err != nil || i < 0 || i >= len(addrs) {
+			return nil, fmt.Errorf("Census geocoder answered for unknown address %q", row[0])
+		}
+		if matches[i], err = censusMatches(row); err != nil {
+			return nil, err
+		}
+	}
+	for i := range matches {
+		if matches[i] == nil {
+			matches[i] = []Match{}
+		}
+	}
+	return matches, nil
+}
+
+// censusMatches reads one row of the answer: ID, input address, Match/No_Match/Tie, then for
+// a match Exact/Non_Exact, the matched address, "lon,lat", the TIGER line ID and its side
+func censusMatches(row []string) ([]Match, error) {
+	if len(row) < 3 {
+		return nil, fmt.Errorf("short row from the Census geocoder: %q", row)
+	}
+	switch row[2] {
+	case "Match":
+	case "Tie":
+		return []Match{{Kind: "tie"}, {Kind: "tie"}}, nil
+	default:
+		return []Match{}, nil
+	}
+	if len(row) < 6 {
+		return nil, fmt.Errorf("short row from the Census geocoder: %q", row)
+	}
+	coords := strings.Split(row[5], ",")
+	if len(coords) != 2 {
+		return nil, fmt.Errorf("bad coordinates %q from the Census geocoder", row[5])
+	}
+	lon, lonErr := strconv.ParseFloat(strings.TrimSpace(coords[0]), 64)
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(coords[1]), 64)
+	if lonErr != nil || latErr != nil {
+		return nil, fmt.Errorf("bad coordinates %q from the Census geocoder", row[5])
+	}
+	score := 1.0
+	if row[3] != "Exact" {
+		score = 0.5
+	}
+	// the matched address ends with its ZIP
+	parts := strings.Split(row[4], ",")
+	zip := strings.TrimSpace(parts[len(parts)-1])
+	return []Match{{Lat: lat, Lon: lon, Label: row[4], Kind: "address", Zip: zip, Score: score}}, nil
+}
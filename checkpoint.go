@@ -0,0 +1,96 @@
+package hcip2
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint is how far a geocoding run has got.  It's saved after each batch's output is on
+// disk, never before, so a run resumed from it neither repeats nor skips a record.
+type Checkpoint struct {
+	Path     string           `json:"-"`
+	Settings []string         `json:"settings"` // what the run was started with; a resume has to match
+	Line     int              `json:"line"`     // the last input line whose output is committed
+	Batch    int              `json:"batch"`    // how many batches are committed
+	Outputs  map[string]int64 `json:"outputs"`  // the size of each output file at the checkpoint
+	Rejects  RejectState      `json:"rejects"`
+	Done     bool             `json:"done"` // the run got to the end of its input
+}
+
+// NewCheckpoint starts the checkpoint of a fresh run, saved at path
+func NewCheckpoint(path string, settings []string) *Checkpoint {
+	return &Checkpoint{Path: path, Settings: settings, Outputs: make(map[string]int64)}
+}
+
+// LoadCheckpoint reads the checkpoint a run saved at path, checking that it was started with
+// the same settings
+func LoadCheckpoint(path string, settings []string) (*Checkpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading checkpoint: %s", err)
+	}
+	cp := &Checkpoint{Path: path}
+	if err = json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("Error parsing checkpoint %s: %s", path, err)
+	}
+	if fmt.Sprintf("%q", cp.Settings) != fmt.Sprintf("%q", settings) {
+		return nil, fmt.Errorf("checkpoint %s is of a run with %q, not %q", path, cp.Settings, settings)
+	}
+	return cp, nil
+}
+
+// Commit syncs the outputs and the reject file to disk, then saves the checkpoint as having got
+// through line
+func (cp *Checkpoint) Commit(line int, rejects *Rejects, outputs ...*os.File) error {
+	for _, f := range outputs {
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("Error syncing %s: %s", f.Name(), err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		cp.Outputs[f.Name()] = info.Size()
+	}
+	state, err := rejects.Sync()
+	if err != nil {
+		return fmt.Errorf("Error syncing rejects: %s", err)
+	}
+	cp.Rejects = state
+	cp.Line = line
+	cp.Batch++
+	return cp.Save()
+}
+
+// Save writes the checkpoint atomically: to a temporary file that then replaces the old one
+func (cp *Checkpoint) Save() error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(cp.Path), filepath.Base(cp.Path)+".*")
+	if err != nil {
+		return fmt.Errorf("Error saving checkpoint: %s", err)
+	}
+	defer os.Remove(tmp.Name()) // a no-op once it's renamed
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), cp.Path)
+	}
+	if err != nil {
+		return fmt.Errorf("Error saving checkpoint: %s", err)
+	}
+	if dir, err := os.Open(filepath.Dir(cp.Path)); err == nil {
+		dir.Sync() // so the rename itself survives a crash
+		dir.Close()
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package hcip2
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	dir := t.TempDir()
+	prefix := filepath.Join(dir, "run_")
+	cpPath := filepath.Join(dir, "run.checkpoint")
+	settings := []string{"NC", "nominatim", "voters.txt"}
+
+	goods, bads, multis, err := OpenOutputs(prefix, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejects, err := NewRejects(filepath.Join(dir, "rejects.csv"), Budget{Count: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := NewCheckpoint(cpPath, settings)
+	goods.WriteString("1,35.7,-78.8\n")
+	rejects.Add(2, "parse", "bad", "x")
+	if err = cp.Commit(2, rejects, goods, bads, multis); err != nil {
+		t.Fatal(err)
+	}
+	// the batch after the checkpoint is half written when the run dies
+	goods.WriteString("3,35.")
+	bads.WriteString("4\n")
+	rejects.Add(5, "parse", "bad", "y")
+	goods.Close()
+	bads.Close()
+	multis.Close()
+	rejects.Close()
+
+	if _, err := LoadCheckpoint(cpPath, []string{"WA", "nominatim", "voters.txt"}); err == nil {
+		t.Error("resumed a run started with other settings")
+	}
+	cp, err = LoadCheckpoint(cpPath, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Line != 2 || cp.Batch != 1 || cp.Done || cp.Rejects.Count != 1 || len(cp.Outputs) != 3 {
+		t.Errorf("checkpoint %+v", cp)
+	}
+
+	goods, bads, multis, err = OpenOutputs(prefix, cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goods.WriteString("3,35.9,-78.6\n")
+	goods.Close()
+	bads.Close()
+	multis.Close()
+	for name, want := range map[string]string{"goods.csv": "1,35.7,-78.8\n3,35.9,-78.6\n", "bads.csv": ""} {
+		if data, _ := ioutil.ReadFile(prefix + name); string(data) != want {
+			t.Errorf("%s is %q after resuming, want %q", name, data, want)
+		}
+	}
+	rejects, err = ResumeRejects(filepath.Join(dir, "rejects.csv"), Budget{Count: -1}, cp.Rejects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejects.Rejected() != 1 {
+		t.Errorf("%d rejects after resuming, want 1", rejects.Rejected())
+	}
+	rejects.Close()
+
+	// a fresh run starts the outputs over
+	goods, bads, multis, err = OpenOutputs(prefix, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goods.Close()
+	bads.Close()
+	multis.Close()
+	if info, _ := os.Stat(prefix + "goods.csv"); info.Size() != 0 {
+		t.Errorf("a fresh run left %d bytes in goods.csv", info.Size())
+	}
+}
+
+func TestOpenOutputAgainstCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "goods.csv", "1,2,3\n")
+
+	cp := NewCheckpoint(filepath.Join(dir, "cp"), nil)
+	if _, err := OpenOutput(path, cp); err == nil {
+		t.Error("opened an output the checkpoint doesn't know")
+	}
+	cp.Outputs[path] = 100
+	if _, err := OpenOutput(path, cp); err == nil {
+		t.Error("opened an output shorter than it was at the checkpoint")
+	}
+	if _, err := LoadCheckpoint(filepath.Join(dir, "missing"), nil); err == nil {
+		t.Error("loaded a checkpoint that isn't there")
+	}
+}
+
+func TestCheckpointSave(t *testing.T) {
+	dir := t.TempDir()
+	cp := NewCheckpoint(filepath.Join(dir, "cp"), []string{"a"})
+	cp.Done = true
+	for i := 0; i < 2; i++ {
+		if err := cp.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loaded, err := LoadCheckpoint(cp.Path, []string{"a"})
+	if err != nil || !loaded.Done {
+		t.Errorf("LoadCheckpoint = %+v, %v", loaded, err)
+	}
+	// the temporary files are renamed over the checkpoint, not left beside it
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("%d files beside the checkpoint", len(files)-1)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/skemper/hcip2/geofake"
+)
+
+var backend = flag.String("backend", "nominatim", "which geocoder to pretend to be: "+strings.Join(geofake.Backends, ", "))
+var listen = flag.String("listen", "localhost:8080", "address to serve on")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Printf("Usage: %s [flags] places.csv\n", os.Args[0])
+		fmt.Printf("places.csv has a header row and the columns name, street, city, state, zip, lat and lon\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	places, err := geofake.LoadPlaces(flag.Arg(0))
+	if err != nil {
+		fmt.Printf("Error loading places: %s\n", err)
+		os.Exit(1)
+	}
+	handler, err := geofake.Handler(*backend, places)
+	if err != nil {
+		fmt.Printf("Error in -backend: %s\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Serving a fake %s geocoder with %d places; use -geocoder %s -geocoder-url http://%s\n", *backend, len(places), *backend, *listen)
+	if err = http.ListenAndServe(*listen, handler); err != nil {
+		fmt.Printf("Error serving: %s\n", err)
+		os.Exit(1)
+	}
+}
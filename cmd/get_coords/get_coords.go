@@ -1,261 +1,596 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"encoding/json"
+	"encoding/csv"
+	"flag"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"io"
 	"os"
-	"strings"
+	"strconv"
 	"time"
 
-	"github.com/TomOnTime/utfutil"
 	"github.com/skemper/hcip2"
+	"github.com/skemper/hcip2/address"
 )
 
 const readBatchSize = 10000
-const maxLineLength = 1000
 
 var newline = []byte{'\n'}
 
-func copyByteArray(dst *[maxLineLength]byte, src []byte) int {
-	slen := len(src)
-	for i := 0; i < slen; i++ {
-		(*dst)[i] = src[i]
+var private = flag.Bool("private", false, "drop or pseudonymize the layout's PII columns in everything written out")
+var keyFile = flag.String("key", "", "file holding the pseudonymization key for -private; $"+hcip2.PrivacyKeyEnv+" if not given")
+var statuses = flag.String("status", "active,inactive", "registration statuses to geocode: any of active, inactive, removed and denied, or all")
+var confidential = flag.Bool("confidential", false, "geocode confidential and exempt voters too")
+var rejectsFile = flag.String("rejects", "rejects.csv", "where to put the records too short to read and the ones the geocoder failed on")
+var maxRejects = flag.String("max-rejects", "1%", "how many records can be rejected before giving up: a count, a percentage of the records, or none")
+var geocoderName = flag.String("geocoder", "nominatim", "geocoder to use: nominatim, photon, pelias or census")
+var geocoderURL = flag.String("geocoder-url", "", "where the geocoder is; the backend's usual local or public URL if empty")
+var workers = flag.Int("workers", 8, "how many geocoding requests to have in flight at once")
+var rate = flag.Float64("rate", 0, "most geocoding requests a second, across all workers; no limit if 0")
+var retryFlags = hcip2.NewRetryFlags(flag.CommandLine)
+var checkpointFile = flag.String("checkpoint", "get_coords.checkpoint", "where to record how far the run has got, after every batch")
+var resume = flag.Bool("resume", false, "carry on from the checkpoint rather than starting over")
+var geocacheFile = flag.String("geocache", "geocode.cache", "where to keep the geocoder's answers between runs; no cache if empty")
+var geocacheTTL = flag.Duration("geocache-ttl", 365*24*time.Hour, "how long cached answers are good for; forever if 0")
+var unique = flag.Bool("unique", false, "read the file twice: first to geocode each distinct address once, then to write out every voter's")
+var centroidsFile = flag.String("centroids", "", "Census Gazetteer counties file, to score the places the geocoder finds by how near they are to the voter's county")
+var minConfidence = flag.Float64("min-confidence", 0.15, "how far ahead of the rest the best of several places has to score to be taken")
+var picksFile = flag.String("picks", "picks.csv", "where to list the records the best of several places was taken for, with its confidence")
+var reviewFile = flag.String("review", "review.csv", "where to list the places found for records too close to call, for someone to look over")
+var cascade = flag.String("cascade", hcip2.DefaultCascade, "the ways to ask about each address, tried in order until one finds it: any of "+hcip2.DefaultCascade)
+var strategiesFile = flag.String("strategies", "strategies.csv", "where to list which way of asking found each record in goods.csv")
+var geocacheVersion = flag.String("geocache-version", "", "tags the cached answers, e.g. with the date of the geocoder's map data; answers with another tag aren't used")
+
+// outputs are the files a checkpoint covers
+var outputs []*os.File
+
+// geocache is the -geocache cache, if there is one
+var geocache *hcip2.GeocodeCache
+
+// strategies is the -strategies file
+var strategies *csv.Writer
+
+// picker is the -picks and -review files and the scorer that fills them
+var picker *disambiguator
+
+// disambiguator settles the records the geocoder finds more than one place for: the best is
+// taken if it's clearly ahead, and otherwise every place goes in the review file
+type disambiguator struct {
+	scorer   *hcip2.Scorer
+	private  bool
+	picks    *csv.Writer
+	review   *csv.Writer
+	picked   int
+	reviewed int
+}
+
+// settles is whether a cascade can stop at the matches for a record: one place, or several
+// with a clear best
+func (d *disambiguator) settles(v []hcip2.Match, addr address.Address, fips string) bool {
+	return len(v) == 1 || len(v) > 1 && d.scorer.Pick(v, addr, fips).OK
+}
+
+// settle scores the matches found for a record, filing the pick or the candidates.  ok is
+// whether one was taken.
+func (d *disambiguator) settle(v []hcip2.Match, addr address.Address, fips string, id string) (hcip2.Match, bool) {
+	pick := d.scorer.Pick(v, addr, fips)
+	query := addr.String()
+	if d.private {
+		pick, query = pick.WithoutLabels(), ""
 	}
-	return slen
+	if pick.OK {
+		d.picked++
+		d.picks.Write(pick.PickRow(id))
+		return pick.Best(), true
+	}
+	d.reviewed++
+	d.review.WriteAll(pick.ReviewRows(id, query))
+	return hcip2.Match{}, false
 }
 
-func copyByteArray2(dst *[25]byte, src []byte) int {
-	slen := len(src)
-	for i := 0; i < slen; i++ {
-		(*dst)[i] = src[i]
+// flush writes out the rows still buffered
+func (d *disambiguator) flush() error {
+	d.picks.Flush()
+	d.review.Flush()
+	if err := d.picks.Error(); err != nil {
+		return err
 	}
-	return slen
+	return d.review.Error()
+}
+
+// statsString is how many records were settled, for the progress lines
+func (d *disambiguator) statsString() string {
+	return fmt.Sprintf(" (%d multiple matches picked, %d for review)", d.picked, d.reviewed)
 }
 
+// geocodeFunc geocodes a batch's addresses, those of voters in the counties with the given FIPS
+// codes, answering in the same order
+type geocodeFunc func(addrs []address.Address, fipses []string) []hcip2.Answer
+
 func main() {
-	goods, bads, multis := hcip2.MakeFiles()
+	flag.Usage = func() {
+		fmt.Printf("Usage: %s [flags] state voter_file b|s [filter]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 3 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	config, err := hcip2.GetConfig(flag.Arg(0))
+	if err != nil {
+		fmt.Printf("Error loading config: %s\n", err)
+		os.Exit(1)
+	}
+	config.OnHeaderDrift = hcip2.WarnHeaderDrift
+	if config.Policy, err = hcip2.ParseStatusPolicy(*statuses, *confidential); err != nil {
+		fmt.Printf("Error in -status: %s\n", err)
+		os.Exit(1)
+	}
+	steps, err := hcip2.ParseCascade(*cascade)
+	if err != nil {
+		fmt.Printf("Error in -cascade: %s\n", err)
+		os.Exit(1)
+	}
+	if *unique && steps[0] == hcip2.StrategyRoad {
+		steps = steps[1:] // -unique geocodes addresses without their units
+	}
+	if flag.NArg() > 3 {
+		if err = config.AddFilter(flag.Arg(3)); err != nil {
+			fmt.Printf("Error in filter: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// a nil redactor leaves everything as it is
+	var redactor *hcip2.Redactor
+	if *private {
+		key, err := hcip2.LoadPrivacyKey(*keyFile)
+		if err == nil {
+			redactor, err = hcip2.NewRedactor(config, key)
+		}
+		if err != nil {
+			fmt.Printf("Error starting privacy mode: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// a resumed run has to pick the same records out of the same file
+	settings := []string{flag.Arg(0), flag.Arg(1), flag.Arg(2), flag.Arg(3), config.Policy.String(), fmt.Sprint(*private), fmt.Sprint(steps)}
+	cp := hcip2.NewCheckpoint(*checkpointFile, settings)
+	if *resume {
+		if cp, err = hcip2.LoadCheckpoint(*checkpointFile, settings); err != nil {
+			fmt.Printf("Error resuming: %s\n", err)
+			os.Exit(1)
+		}
+		if cp.Done {
+			fmt.Printf("Nothing to resume; the run in %s finished\n", *checkpointFile)
+			return
+		}
+		fmt.Printf("Resuming after line %d (batch %d)...\n", cp.Line, cp.Batch)
+	}
+
+	budget, err := hcip2.ParseBudget(*maxRejects)
+	if err == nil {
+		if *resume {
+			config.Rejects, err = hcip2.ResumeRejects(*rejectsFile, budget, cp.Rejects)
+		} else {
+			config.Rejects, err = hcip2.NewRejects(*rejectsFile, budget)
+		}
+	}
+	if err != nil {
+		fmt.Printf("Error setting up rejects: %s\n", err)
+		os.Exit(1)
+	}
+	defer config.Rejects.Close()
+
+	geocoder, err := hcip2.NewGeocoder(*geocoderName, *geocoderURL, hcip2.NewHTTPClient(*workers, retryFlags.Policy(*geocoderName)))
+	if err != nil {
+		fmt.Printf("Error in -geocoder: %s\n", err)
+		os.Exit(1)
+	}
+	if *geocacheFile != "" {
+		if geocache, err = hcip2.OpenGeocodeCache(*geocacheFile, *geocacheTTL); err != nil {
+			fmt.Printf("Error opening geocode cache: %s\n", err)
+			os.Exit(1)
+		}
+		defer geocache.Close()
+		geocoder = geocache.Wrap(geocoder, *geocacheVersion)
+	}
+	pool := hcip2.NewGeocodePool(geocoder, *workers, *rate)
+
+	var resumeFrom *hcip2.Checkpoint
+	if *resume {
+		resumeFrom = cp
+	}
+	goods, bads, multis, err := hcip2.OpenOutputs("", resumeFrom)
+	if err != nil {
+		fmt.Printf("Error opening output files: %s\n", err)
+		os.Exit(1)
+	}
+	defer goods.Close()
+	defer bads.Close()
+	defer multis.Close()
+	outputs = []*os.File{goods, bads, multis}
+	var files [3]*csv.Writer
+	for i, name := range []string{*picksFile, *reviewFile, *strategiesFile} {
+		f, err := hcip2.OpenOutput(name, resumeFrom)
+		if err != nil {
+			fmt.Printf("Error opening output files: %s\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		outputs = append(outputs, f)
+		files[i] = csv.NewWriter(f)
+	}
+	strategies = files[2]
+	if !*resume {
+		files[0].Write(hcip2.PickHeader)
+		files[1].Write(hcip2.ReviewHeader)
+		strategies.Write([]string{"id", "strategy"})
+	}
 
-	var config hcip2.HciConfig = hcip2.Configs[os.Args[1]]
+	var centroids map[string]hcip2.Point
+	if *centroidsFile != "" {
+		if centroids, err = hcip2.LoadCentroids(*centroidsFile); err != nil {
+			fmt.Printf("Error loading centroids: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	scorer := hcip2.NewScorer(centroids)
+	scorer.MinConfidence = *minConfidence
+	picker = &disambiguator{scorer: scorer, private: *private, picks: files[0], review: files[1]}
+
+	geocode := func(addrs []address.Address, fipses []string) []hcip2.Answer {
+		return pool.Cascade(addrs, steps, func(i int, v []hcip2.Match) bool {
+			return picker.settles(v, addrs[i], fipses[i])
+		})
+	}
+	if *unique {
+		book := collectAddresses(&config, cp, flag.Arg(1), flag.Arg(2))
+		fmt.Printf("Found %d distinct addresses for %d records\n", book.Len(), book.Voters())
+		start := time.Now()
+		settled := func(i int, v []hcip2.Match) bool {
+			return picker.settles(v, book.Addrs[i], book.FIPS[i])
+		}
+		book.Geocode(pool, steps, settled, readBatchSize, func(n int) {
+			if err := geocache.Flush(); err != nil {
+				fmt.Printf("Error writing geocode cache: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Geocoded %d of %d addresses in %s%s...\n", n, book.Len(), time.Since(start), geocache.StatsString())
+		})
+		geocode = func(addrs []address.Address, _ []string) []hcip2.Answer {
+			return book.Lookup(addrs)
+		}
+	}
 
-	switch os.Args[3] {
+	switch flag.Arg(2) {
 	case "b":
-		doBytes(&config, goods, bads, multis)
+		doBytes(&config, redactor, geocode, cp, flag.Arg(1), goods, bads, multis)
 		break
 	case "s":
-		doStrings(&config, goods, bads, multis)
+		doStrings(&config, redactor, geocode, cp, flag.Arg(1), goods, bads, multis)
+	}
+
+	cp.Done = true
+	if err = cp.Save(); err != nil {
+		fmt.Printf("Error finishing checkpoint: %s\n", err)
+		os.Exit(1)
 	}
 }
 
-func makeCall(url *string) ([]hcip2.JSONResult, error) {
-	// Call Nominatim to geocode their address
-	resp, err := http.Get(*url)
-	if err != nil {
-		return nil, fmt.Errorf("Error calling Nominatim: %s", err.Error())
+// skipCommitted reads past the records a resumed run already has output for
+func skipCommitted(rr *hcip2.RecordReader, cp *hcip2.Checkpoint) {
+	for rr.Line < cp.Line {
+		if _, err := rr.Read(); err != nil {
+			fmt.Printf("Error skipping to line %d of the checkpoint: %s\n", cp.Line, err)
+			os.Exit(1)
+		}
 	}
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Non-OK response code from %s: %d %s\n", *url, resp.StatusCode, resp.Body)
+}
+
+// rejectShort files a record too short for the layout's columns in the reject file, as a
+// parse failure; line is the record as the outputs would have it
+func rejectShort(config *hcip2.HciConfig, lineNum int, err error, line string) {
+	config.Rejects.Row()
+	if err = config.Rejects.Add(lineNum, "parse", err.Error(), line); err != nil {
+		fmt.Printf("Error reading VRDB file: %s\n", err)
+		os.Exit(1)
 	}
+}
 
-	v := []hcip2.JSONResult{}
-	err = json.NewDecoder(resp.Body).Decode(&v)
+// collectAddresses reads the addresses of the records a run will geocode, for -unique.  A
+// resumed run only needs the ones after the checkpoint.
+func collectAddresses(config *hcip2.HciConfig, cp *hcip2.Checkpoint, vrdbFilename string, mode string) *hcip2.AddressBook {
+	rr, err := hcip2.OpenRecords(vrdbFilename, *config)
 	if err != nil {
-		fmt.Printf("Error decoding JSON: %s\n", err.Error())
-		fmt.Printf("From URL: %s\n", *url)
-		body, _ := ioutil.ReadAll(resp.Body)
-		fmt.Printf("Full response was: %s\n", body)
-		return nil, err
+		fmt.Printf("Error opening VRDB file %s: %s\n", vrdbFilename, err.Error())
+		os.Exit(1)
+	}
+	defer rr.Close()
+	skipCommitted(rr, cp)
+
+	book := hcip2.NewAddressBook()
+	for {
+		pieces, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Printf("Error reading %s: %s\n", vrdbFilename, err)
+			os.Exit(1)
+		}
+		// keep the same records the second read will
+		keep := false
+		if mode == "b" {
+			record := make([][]byte, len(pieces))
+			for j, piece := range pieces {
+				record[j] = []byte(piece)
+			}
+			keep = config.KeepBytes(record)
+		} else {
+			keep = config.Keep(pieces)
+		}
+		if keep {
+			fips := ""
+			if county, ok := config.County(pieces); ok {
+				fips = county.FIPS
+			}
+			book.Add(config.Address(pieces), fips)
+		}
+	}
+	return book
+}
+
+// commit checkpoints a batch once its output is written
+func commit(cp *hcip2.Checkpoint, config *hcip2.HciConfig, line int) {
+	if err := geocache.Flush(); err != nil {
+		fmt.Printf("Error writing geocode cache: %s\n", err)
+		os.Exit(1)
+	}
+	if err := picker.flush(); err != nil {
+		fmt.Printf("Error writing %s or %s: %s\n", *picksFile, *reviewFile, err)
+		os.Exit(1)
+	}
+	if strategies.Flush(); strategies.Error() != nil {
+		fmt.Printf("Error writing %s: %s\n", *strategiesFile, strategies.Error())
+		os.Exit(1)
+	}
+	if err := cp.Commit(line, config.Rejects, outputs...); err != nil {
+		fmt.Printf("Error checkpointing: %s\n", err)
+		os.Exit(1)
 	}
-	return v, nil
 }
 
-func doBytes(config *hcip2.HciConfig, goods *os.File, bads *os.File, multis *os.File) {
-	vrdbFilename := os.Args[2]
-	vrdb, err := os.Open(vrdbFilename)
-	defer vrdb.Close()
+// formatCoord writes a latitude or longitude for goods.csv
+func formatCoord(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func doBytes(config *hcip2.HciConfig, redactor *hcip2.Redactor, geocode geocodeFunc, cp *hcip2.Checkpoint, vrdbFilename string, goods *os.File, bads *os.File, multis *os.File) {
+	rr, err := hcip2.OpenRecords(vrdbFilename, *config)
 	if err != nil {
 		fmt.Printf("Error opening VRDB file %s: %s\n", vrdbFilename, err.Error())
 		os.Exit(1)
 	}
-	scanner := bufio.NewScanner(vrdb)
-	scanner.Scan() // skip the first line
+	defer rr.Close()
+	skipCommitted(rr, cp)
 
-	numCycles := 0
+	numCycles := cp.Batch
 	done := false
 
 	for !done {
 		start := time.Now()
-		var lines [readBatchSize][maxLineLength]byte
-		var lineLengths [readBatchSize]int
-		var badlines [readBatchSize][maxLineLength]byte
-		var badlineLengths [readBatchSize]int
+		// lines are as long as the layout allows, and redacting or decoding can make them longer
+		var lines [readBatchSize][]byte
+		var lineNums [readBatchSize]int
+		var badlines [readBatchSize][]byte
 		var numBads = 0
-		var multilines [readBatchSize][maxLineLength]byte
-		var multilineLengths [readBatchSize]int
+		var multilines [readBatchSize][]byte
 		var numMultis = 0
 
-		var goodlines [readBatchSize]hcip2.JSONResult
-		var goodlineVoterIDLengths [readBatchSize]int
+		var records [readBatchSize][][]byte
+		var ids [readBatchSize]string
+		var addrs [readBatchSize]address.Address
+		var fipses [readBatchSize]string
+
+		var goodlines [readBatchSize]hcip2.Match
+		var goodlineVoterIDs [readBatchSize][]byte
 		var numGoods = 0
 
 		// we're going to read these in batches
 		for i := 0; i < readBatchSize; i++ {
-			if !scanner.Scan() {
+			pieces, err := rr.Read()
+			if err == io.EOF {
 				done = true
 				break
 			}
-			line := scanner.Bytes()
-			lineLengths[i] = copyByteArray(&lines[i], line)
+			if err != nil {
+				fmt.Printf("Error reading %s: %s\n", vrdbFilename, err)
+				os.Exit(1)
+			}
+			lines[i] = []byte(redactor.Line(pieces, rr.Raw))
+			lineNums[i] = rr.Line
+			ids[i] = config.VoterID(pieces)
+			addrs[i] = config.Address(pieces)
+			if county, ok := config.County(pieces); ok {
+				fipses[i] = county.FIPS
+			}
+			records[i] = make([][]byte, len(pieces))
+			for j, piece := range pieces {
+				records[i][j] = []byte(piece)
+			}
 		}
 
-		for i, line := range lines {
-			// we're going to cobble their street address together
-			// fmt.Printf("Working on %s\n", line)
-			pieces := bytes.Split(line[:], []byte{'|'})
-			// fmt.Printf("Split to %s\n", pieces)
-			roadBuilder := strings.Builder{}
-			for _, piece := range config.RoadNoUnit {
-				for i := range pieces[piece] {
-					if pieces[piece][i] == ' ' {
-						pieces[piece][i] = '+'
-					}
-				}
-				roadBuilder.Write(pieces[piece])
-				roadBuilder.WriteRune('+')
+		// geocode the whole batch at once; the answers come back in file order
+		var todo []int
+		var todoAddrs []address.Address
+		var todoFips []string
+		for i := range lines {
+			if lines[i] == nil {
+				continue // the last batch isn't full
 			}
-			streetAddr := roadBuilder.String()
-
-			// now we can construct the URL for querying the database
-			urlBuilder := strings.Builder{}
-			urlBuilder.WriteString("http://localhost/nominatim/search?country=us&format=jsonv2&street=")
-			urlBuilder.WriteString(streetAddr)
-			urlBuilder.WriteString("&city=")
-			for i := range pieces[config.CITY] {
-				if pieces[config.CITY][i] == ' ' {
-					pieces[config.CITY][i] = '+'
-				}
+			if !config.KeepBytes(records[i]) {
+				continue
+			}
+			if err := config.CheckWidth(len(records[i])); err != nil {
+				rejectShort(config, lineNums[i], err, string(lines[i]))
+				continue
 			}
-			urlBuilder.Write(pieces[config.CITY])
-			urlBuilder.WriteString("&state=")
-			urlBuilder.Write(pieces[config.STATE])
-			urlBuilder.WriteString("&postalcode=")
-			urlBuilder.Write(pieces[config.ZIP])
-			url := urlBuilder.String()
-
-			v, err := makeCall(&url)
+			todo = append(todo, i)
+			todoAddrs = append(todoAddrs, addrs[i])
+			todoFips = append(todoFips, fipses[i])
+		}
+		answers := geocode(todoAddrs, todoFips)
+
+		for j, i := range todo {
+			line := lines[i]
+
+			config.Rejects.Row()
+			v, err := answers[j].Matches, answers[j].Err
 			if err != nil {
-				fmt.Println("Error geocoding: %s\n", err)
-				fmt.Println("Line was %s\n", line)
+				// the geocoder failing says nothing about the address; don't file it as bad
+				if err = config.Rejects.Add(lineNums[i], "geocode", redactor.Reason(err), string(line)); err != nil {
+					fmt.Printf("Error geocoding: %s\n", err)
+					os.Exit(1)
+				}
+				continue
+			}
+
+			id := redactor.Pseudonym(ids[i])
+			if len(v) > 1 {
+				if m, ok := picker.settle(v, addrs[i], fipses[i], id); ok {
+					v = []hcip2.Match{m}
+				}
 			}
 
 			if len(v) == 0 {
 				badlines[numBads] = line
-				badlineLengths[numBads] = lineLengths[i]
 				numBads++
 			} else if len(v) > 1 {
 				multilines[numMultis] = line
-				multilineLengths[numMultis] = lineLengths[i]
 				numMultis++
 			} else {
 				// one record - the good case
 				goodlines[numGoods] = v[0]
-				goodlineVoterIDLengths[numGoods] = copyByteArray2(&goodlines[numGoods].StateVoterIDBytes, pieces[config.STATE_VOTER_ID])
+				goodlineVoterIDs[numGoods] = []byte(id)
 				numGoods++
+				strategies.Write([]string{id, answers[j].Strategy.String()})
 			}
 		}
 
 		for i := 0; i < numBads; i++ {
-			bads.Write(badlines[i][:badlineLengths[i]])
+			bads.Write(badlines[i])
 			bads.Write(newline)
 		}
 
 		for i := 0; i < numMultis; i++ {
-			multis.Write(multilines[i][:multilineLengths[i]])
+			multis.Write(multilines[i])
 			multis.Write(newline)
 		}
 
 		for i := 0; i < numGoods; i++ {
-			goods.WriteString(fmt.Sprintf("%s,%s,%s\n", goodlines[i].StateVoterIDBytes[:goodlineVoterIDLengths[i]], goodlines[i].Lat, goodlines[i].Lon))
+			goods.WriteString(fmt.Sprintf("%s,%s,%s\n", goodlineVoterIDs[i], formatCoord(goodlines[i].Lat), formatCoord(goodlines[i].Lon)))
 		}
 
+		commit(cp, config, rr.Line)
 		numCycles++
 		end := time.Now()
-		fmt.Printf("Finished %d records in %s...\n", numCycles*readBatchSize, end.Sub(start))
+		fmt.Printf("Finished %d records in %s%s%s...\n", numCycles*readBatchSize, end.Sub(start), picker.statsString(), geocache.StatsString())
 	}
 }
 
-func doStrings(config *hcip2.HciConfig, goods *os.File, bads *os.File, multis *os.File) {
-	// we are reading just one file: 202011_VRDB_Extract.txt
-	vrdb, err := utfutil.OpenFile("VR_Snapshot_20201103.txt", utfutil.WINDOWS)
-	defer vrdb.Close()
+func doStrings(config *hcip2.HciConfig, redactor *hcip2.Redactor, geocode geocodeFunc, cp *hcip2.Checkpoint, vrdbFilename string, goods *os.File, bads *os.File, multis *os.File) {
+	rr, err := hcip2.OpenRecords(vrdbFilename, *config)
 	if err != nil {
-		fmt.Printf("Error opening VRDB: %s\n", err.Error())
+		fmt.Printf("Error opening VRDB file %s: %s\n", vrdbFilename, err.Error())
 		os.Exit(1)
 	}
-	scanner := bufio.NewScanner(vrdb)
-	scanner.Scan() // skip the first line
+	defer rr.Close()
+	skipCommitted(rr, cp)
 
-	numCycles := 0
+	numCycles := cp.Batch
 	done := false
-	splitchar := "\t"
 
 	for !done {
 		start := time.Now()
 		var lines [readBatchSize]string
+		var lineNums [readBatchSize]int
 		var badlines [readBatchSize]string
 		var numBads = 0
 		var multilines [readBatchSize]string
 		var numMultis = 0
 
-		var goodlines [readBatchSize]hcip2.JSONResult
+		var records [readBatchSize][]string
+
+		var goodlines [readBatchSize]hcip2.Match
+		var goodlineVoterIDs [readBatchSize]string
 		var numGoods = 0
 
 		// we're going to read these in batches
 		for i := 0; i < readBatchSize; i++ {
-			if !scanner.Scan() {
+			pieces, err := rr.Read()
+			if err == io.EOF {
 				done = true
 				break
 			}
-			lines[i] = scanner.Text()
+			if err != nil {
+				fmt.Printf("Error reading %s: %s\n", vrdbFilename, err)
+				os.Exit(1)
+			}
+			lines[i] = redactor.Line(pieces, rr.Raw)
+			lineNums[i] = rr.Line
+			records[i] = pieces
 		}
 
-		for _, line := range lines {
+		// geocode the whole batch at once; the answers come back in file order
+		var todo []int
+		var todoAddrs []address.Address
+		var todoFips []string
+		for i, line := range lines {
+			if line == "" {
+				continue // the last batch isn't full
+			}
+			if !config.Keep(records[i]) {
+				continue
+			}
+			if err := config.CheckWidth(len(records[i])); err != nil {
+				rejectShort(config, lineNums[i], err, line)
+				continue
+			}
 			// we're going to cobble their street address together
-			// fmt.Printf("Working on %s\n", line)
-			pieces := strings.Split(line[:], splitchar)
+			fips := ""
+			if county, ok := config.County(records[i]); ok {
+				fips = county.FIPS
+			}
+			todo = append(todo, i)
+			todoAddrs = append(todoAddrs, config.Address(records[i]))
+			todoFips = append(todoFips, fips)
+		}
+		answers := geocode(todoAddrs, todoFips)
+
+		for j, i := range todo {
+			line := lines[i]
+			pieces := records[i]
 
-			if !config.FilterStr(pieces) {
+			config.Rejects.Row()
+			v, err := answers[j].Matches, answers[j].Err
+			if err != nil {
+				// the geocoder failing says nothing about the address; don't file it as bad
+				if err = config.Rejects.Add(lineNums[i], "geocode", redactor.Reason(err), line); err != nil {
+					fmt.Printf("Error geocoding: %s\n", err)
+					os.Exit(1)
+				}
 				continue
 			}
 
-			// fmt.Printf("Split to %s\n", pieces)
-			roadBuilder := strings.Builder{}
-			for _, piece := range config.RoadNoUnit {
-				val := strings.ReplaceAll(pieces[piece], " ", "+")
-				roadBuilder.WriteString(val)
-				roadBuilder.WriteRune('+')
-			}
-			streetAddr := roadBuilder.String()
-
-			// now we can construct the URL for querying the database
-			urlBuilder := strings.Builder{}
-			urlBuilder.WriteString("http://localhost/nominatim/search?country=us&format=jsonv2&street=")
-			urlBuilder.WriteString(streetAddr)
-			urlBuilder.WriteString("&city=")
-			urlBuilder.WriteString(strings.ReplaceAll(pieces[config.CITY], " ", "+"))
-			urlBuilder.WriteString("&state=")
-			urlBuilder.WriteString(pieces[config.STATE])
-			urlBuilder.WriteString("&postalcode=")
-			urlBuilder.WriteString(pieces[config.ZIP])
-			url := urlBuilder.String()
-
-			v, err := makeCall(&url)
-			if err != nil {
-				fmt.Println("Error geocoding: %s\n", err)
-				fmt.Println("Line was %s\n", line)
+			id := redactor.Pseudonym(config.VoterID(pieces))
+			if len(v) > 1 {
+				if m, ok := picker.settle(v, todoAddrs[j], todoFips[j], id); ok {
+					v = []hcip2.Match{m}
+				}
 			}
 
 			if len(v) == 0 {
@@ -267,8 +602,9 @@ func doStrings(config *hcip2.HciConfig, goods *os.File, bads *os.File, multis *o
 			} else {
 				// one record - the good case
 				goodlines[numGoods] = v[0]
-				goodlines[numGoods].StateVoterIDStr = pieces[config.STATE_VOTER_ID]
+				goodlineVoterIDs[numGoods] = id
 				numGoods++
+				strategies.Write([]string{id, answers[j].Strategy.String()})
 			}
 		}
 
@@ -283,11 +619,12 @@ func doStrings(config *hcip2.HciConfig, goods *os.File, bads *os.File, multis *o
 		}
 
 		for i := 0; i < numGoods; i++ {
-			goods.WriteString(fmt.Sprintf("%s,%s,%s\n", goodlines[i].StateVoterIDStr, goodlines[i].Lat, goodlines[i].Lon))
+			goods.WriteString(fmt.Sprintf("%s,%s,%s\n", goodlineVoterIDs[i], formatCoord(goodlines[i].Lat), formatCoord(goodlines[i].Lon)))
 		}
 
+		commit(cp, config, rr.Line)
 		numCycles++
 		end := time.Now()
-		fmt.Printf("Finished %d records in %s...\n", numCycles*readBatchSize, end.Sub(start))
+		fmt.Printf("Finished %d records in %s%s%s...\n", numCycles*readBatchSize, end.Sub(start), picker.statsString(), geocache.StatsString())
 	}
 }
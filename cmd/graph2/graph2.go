@@ -1,123 +1,19 @@
 package main
 
 import (
-	"bufio"
 	"encoding/csv"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/TomOnTime/utfutil"
 	"github.com/skemper/hcip2"
 )
 
-// county ID -> name mappings
-var counties = []string{"", // nothing at ID 0
-	"ALAMANCE",
-	"ALEXANDER",
-	"ALLEGHANY",
-	"ANSON",
-	"ASHE",
-	"AVERY",
-	"BEAUFORT",
-	"BERTIE",
-	"BLADEN",
-	"BRUNSWICK",
-	"BUNCOMBE",
-	"BURKE",
-	"CABARRUS",
-	"CALDWELL",
-	"CAMDEN",
-	"CARTERET",
-	"CASWELL",
-	"CATAWBA",
-	"CHATHAM",
-	"CHEROKEE",
-	"CHOWAN",
-	"CLAY",
-	"CLEVELAND",
-	"COLUMBUS",
-	"CRAVEN",
-	"CUMBERLAND",
-	"CURRITUCK",
-	"DARE",
-	"DAVIDSON",
-	"DAVIE",
-	"DUPLIN",
-	"DURHAM",
-	"EDGECOMBE",
-	"FORSYTH",
-	"FRANKLIN",
-	"GASTON",
-	"GATES",
-	"GRAHAM",
-	"GRANVILLE",
-	"GREENE",
-	"GUILFORD",
-	"HALIFAX",
-	"HARNETT",
-	"HAYWOOD",
-	"HENDERSON",
-	"HERTFORD",
-	"HOKE",
-	"HYDE",
-	"IREDELL",
-	"JACKSON",
-	"JOHNSTON",
-	"JONES",
-	"LEE",
-	"LENOIR",
-	"LINCOLN",
-	"MACON",
-	"MADISON",
-	"MARTIN",
-	"MCDOWELL",
-	"MECKLENBURG",
-	"MITCHELL",
-	"MONTGOMERY",
-	"MOORE",
-	"NASH",
-	"NEWHANOVER",
-	"NORTHAMPTON",
-	"ONSLOW",
-	"ORANGE",
-	"PAMLICO",
-	"PASQUOTANK",
-	"PENDER",
-	"PERQUIMANS",
-	"PERSON",
-	"PITT",
-	"POLK",
-	"RANDOLPH",
-	"RICHMOND",
-	"ROBESON",
-	"ROCKINGHAM",
-	"ROWAN",
-	"RUTHERFORD",
-	"SAMPSON",
-	"SCOTLAND",
-	"STANLY",
-	"STOKES",
-	"SURRY",
-	"SWAIN",
-	"TRANSYLVANIA",
-	"TYRRELL",
-	"UNION",
-	"VANCE",
-	"WAKE",
-	"WARREN",
-	"WASHINGTON",
-	"WATAUGA",
-	"WAYNE",
-	"WILKES",
-	"WILSON",
-	"YADKIN",
-	"YANCEY",
-}
-
 const (
 	countyID        int = iota //                County identification number
 	countyDesc                 //            varchar(20)        County name
@@ -173,34 +69,6 @@ const (
 	Popage65plus
 )
 
-type Voter struct {
-	County_id                int       //			char  3         County identification number
-	Ncid                     string    //				char 12         North Carolina identification number (NCID) of voter
-	Status_cd                byte      //			char  1	        Status code for voter registration
-	Voter_status_desc        string    //		char 10         Satus code descriptions.
-	Reason_cd                string    //			char  2         Reason code for voter registration status
-	Voter_status_reason_desc string    //	char 60         Reason code description
-	Race_code                string    // char  3         Race code
-	Race_desc                string    //			char 35         Race description
-	Ethnic_code              string    //			char  2         Ethnicity code
-	Ethnic_desc              string    //			char 30         Ethnicity description
-	Party_cd                 string    //			char  3         Party affiliation code
-	Sex_code                 byte      //			char  1         Gender code
-	Age                      int       //				char  3         Age
-	Registr_dt               time.Time //			char 10         Voter registration date
-	Precinct_abbrv           string    //			char  6         Precinct abbreviation
-	Precinct_desc            string    //			char 30         Precinct name
-	// Cong_dist_abbrv                     //			char  4         Congressional district abbreviation
-	// Super_court_abbrv                   //		char  4         Supreme Court abbreviation
-	// Judic_dist_abbrv                    //		char  4         Judicial district abbreviation
-	// NC_senate_abbrv                     //			char  4         NC Senate district abbreviation
-	// NC_house_abbrv                      //			char  4         NC House district abbreviation
-	Cancellation_dt time.Time //			char 10         Cancellation date
-	Vtd_abbrv       string    //			char  6         Voter tabuluation district abbreviation
-	Vtd_desc        string    //			char 30         Voter tabuluation district name
-	Age_group       string    //			char 35         Age group range
-}
-
 // output: election, ethnicity, age_group, gender, party_affil, method, count
 
 type Stat struct {
@@ -211,12 +79,21 @@ type Stat struct {
 
 var buckets map[string]*Stat = make(map[string]*Stat)
 
-var voters map[string]Voter = make(map[string]Voter)
+var voters map[string]*hcip2.Voter
 
 var elections map[string]bool = make(map[string]bool)
 
+// countyFIPS is how buckets key a county, so that the history, the projections and the voters
+// all agree on it
+func countyFIPS(county hcip2.CountyInfo, ok bool) string {
+	if !ok {
+		return "UNKNOWN"
+	}
+	return county.FIPS
+}
+
 func getBucketNameHistory(pieces []string) string {
-	if voter, ok := voters[strings.Trim(pieces[ncid], "\"")]; ok {
+	if voter, ok := voters[pieces[ncid]]; ok {
 		ageBucket := "BADAGE"
 		for k, v := range agebuckets {
 			if voter.Age >= v[1] && voter.Age <= v[2] {
@@ -224,8 +101,8 @@ func getBucketNameHistory(pieces []string) string {
 			}
 		}
 		return fmt.Sprintf("%s_%s_%s_%c%s",
-			strings.Trim(pieces[electionDesc], "\""),
-			strings.Trim(pieces[countyDesc], "\""),
+			pieces[electionDesc],
+			countyFIPS(hcip2.LookupCounty("NC", pieces[countyID])),
 			voter.Race_desc,
 			voter.Sex_code,
 			ageBucket)
@@ -267,93 +144,60 @@ func getBucketNamePopulation(pieces []string) string {
 		sex = 'U'
 		break
 	}
+	var county hcip2.CountyInfo
+	var ok bool
+	switch fips := pieces[PopFips]; len(fips) {
+	case 5:
+		county, ok = hcip2.CountyByFIPS(fips)
+	case 3: // just the county part
+		county, ok = hcip2.CountyByFIPS("37" + fips)
+	default:
+		county, ok = hcip2.CountyByName("NC", pieces[PopCounty])
+	}
 	return fmt.Sprintf("%s_%s_%c",
-		strings.ToUpper(pieces[PopCounty]),
+		countyFIPS(county, ok),
 		race,
 		sex)
 }
 
+var voterFlags = hcip2.NewVoterFlags(flag.CommandLine)
+
 func loadVoterDatabase() {
 	start := time.Now()
-	vrdb, err := utfutil.OpenFile("VR_Snapshot_20201103.txt", utfutil.WINDOWS)
-	defer vrdb.Close()
+	var err error
+	voters, _, err = hcip2.LoadVotersFromFlags(voterFlags)
 	if err != nil {
-		fmt.Printf("Error opening VRDB: %s\n", err)
+		fmt.Printf("Error loading VRDB: %s\n", err)
 		os.Exit(1)
 	}
-	scanner := bufio.NewScanner(vrdb)
-	scanner.Scan() // skip the header line
-	for scanner.Scan() {
-		line := scanner.Text()
-		pieces := strings.Split(line, "\t")
-		countyID, err := strconv.Atoi(strings.TrimSpace(pieces[hcip2.County_id]))
-		if err != nil {
-			fmt.Printf("Error converting county %s to integer for %s: %s\n", pieces[hcip2.County_id], pieces[hcip2.Ncid], err)
-			os.Exit(1)
-		}
-		age, err := strconv.Atoi(strings.TrimSpace(pieces[hcip2.Age]))
-		if err != nil {
-			fmt.Printf("Error converting age %s to integer for %s: %s\n", pieces[hcip2.County_id], pieces[hcip2.Ncid], err)
-			os.Exit(1)
-		}
-		regDate, err := time.Parse("2006-01-02", pieces[hcip2.Registr_dt])
-		if err != nil {
-			fmt.Printf("Error converting regdate %s to date for %s: %s\n", pieces[hcip2.Registr_dt], pieces[hcip2.Ncid], err)
-			os.Exit(1)
-		}
-		canDate, err := time.Parse("2006-01-02", pieces[hcip2.Cancellation_dt])
-		if err != nil {
-			fmt.Printf("Error converting candate %s to date for %s: %s\n", pieces[hcip2.Cancellation_dt], pieces[hcip2.Ncid], err)
-			os.Exit(1)
-		}
-		voter := Voter{
-			County_id:                countyID,
-			Ncid:                     strings.Trim(pieces[hcip2.Ncid], "\""),
-			Status_cd:                pieces[hcip2.Status_cd][0],
-			Voter_status_desc:        strings.Trim(pieces[hcip2.Voter_status_desc], "\""),
-			Reason_cd:                strings.Trim(pieces[hcip2.Reason_cd], "\""),
-			Voter_status_reason_desc: strings.Trim(pieces[hcip2.Voter_status_reason_desc], "\""),
-			Race_code:                strings.Trim(pieces[hcip2.Race_code], "\""),
-			Race_desc:                strings.Trim(pieces[hcip2.Race_desc], "\""),
-			Ethnic_code:              strings.Trim(pieces[hcip2.Ethnic_code], "\""),
-			Ethnic_desc:              strings.Trim(pieces[hcip2.Ethnic_desc], "\""),
-			Party_cd:                 strings.Trim(pieces[hcip2.Party_cd], "\""),
-			Sex_code:                 pieces[hcip2.Sex_code][0],
-			Age:                      age,
-			Registr_dt:               regDate,
-			Precinct_abbrv:           strings.Trim(pieces[hcip2.Precinct_abbrv], "\""),
-			Precinct_desc:            strings.Trim(pieces[hcip2.Precinct_desc], "\""),
-			Cancellation_dt:          canDate,
-			Vtd_abbrv:                strings.Trim(pieces[hcip2.Vtd_abbrv], "\""),
-			Vtd_desc:                 strings.Trim(pieces[hcip2.Vtd_desc], "\""),
-			Age_group:                strings.Trim(pieces[hcip2.Age_group], "\""),
-		}
-		voters[voter.Ncid] = voter
-		// fmt.Printf("Added voter %s\n", voter.Ncid)
-	}
 	fmt.Printf("Loaded VRDB in %s...\n", time.Now().Sub(start))
 }
 
 var electRegex = regexp.MustCompile(`^\d+/\d+/\d+\s+(CONGRESSIONAL\s+)?(GENERAL|PRIMARY)$`)
 
 func processVoterHistory() {
-	theFile, err := os.Open("ncvhis_Statewide.txt")
+	historyConfig := hcip2.NCHistory
+	historyConfig.OnHeaderDrift = hcip2.WarnHeaderDrift
+	history, err := hcip2.OpenRecords("ncvhis_Statewide.txt", historyConfig)
 	if err != nil {
 		fmt.Printf("Error reading voting history database: %s\n", err)
 		os.Exit(1)
 	}
-
-	scanner := bufio.NewScanner(theFile)
-
-	scanner.Scan() // skip the header row
+	defer history.Close()
 
 	count := 0
-	for scanner.Scan() {
-		line := scanner.Text()
-		pieces := strings.Split(line, "\t")
+	for {
+		pieces, err := history.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Printf("Error reading voting history database: %s\n", err)
+			os.Exit(1)
+		}
 
 		// see if we know about this election already
-		elec := strings.Trim(pieces[electionDesc], "\"")
+		elec := pieces[electionDesc]
 		if !electRegex.MatchString(elec) {
 			continue
 		}
@@ -392,21 +236,33 @@ var agebuckets = map[string][]int{
 	"_100":  {Popage100, 100, 1000},
 }
 
+// popConfig reads the NC OSBM county population projections CSV
+var popConfig = hcip2.HciConfig{
+	Header:    hcip2.HeaderSkip,
+	Delimiter: ",",
+	Quoting:   hcip2.QuoteCSV,
+	Encoding:  hcip2.UTF8,
+}
+
 func processPopulationData() {
-	theFile, err := os.Open("NCprojectionsbyagegrp2019.csv")
+	popdata, err := hcip2.OpenRecords("NCprojectionsbyagegrp2019.csv", popConfig)
 	if err != nil {
-		fmt.Printf("Error reading voting history database: %s\n", err)
+		fmt.Printf("Error reading population projections: %s\n", err)
 		os.Exit(1)
 	}
-
-	scanner := bufio.NewScanner(theFile)
-
-	scanner.Scan() // skip the header row
+	defer popdata.Close()
 
 	count := 0
-	for scanner.Scan() {
-		line := scanner.Text()
-		pieces := strings.Split(line, ",")
+	for {
+		pieces, err := popdata.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Printf("Error reading population projections: %s\n", err)
+			os.Exit(1)
+		}
+		line := popdata.Raw
 
 		if pieces[PopSex] == "Total" {
 			continue // this is redundant data
@@ -472,7 +328,7 @@ func processRegisteredVoters() {
 		for election := range elections {
 			bucket := fmt.Sprintf("%s_%s_%s_%c%s",
 				election,
-				counties[voter.County_id],
+				countyFIPS(hcip2.CountyByID("NC", voter.County_id)),
 				voter.Race_desc,
 				voter.Sex_code,
 				ageBucket)
@@ -494,6 +350,8 @@ func processRegisteredVoters() {
 }
 
 func main() {
+	flag.Parse()
+
 	outFile, err := os.OpenFile("graph2.csv", os.O_CREATE+os.O_WRONLY, 0644)
 	defer outFile.Close()
 	if err != nil {
@@ -515,6 +373,12 @@ func main() {
 	for k, v := range buckets {
 		// fmt.Printf("Working on bucket %s\n", k)
 		pieces := strings.Split(k, "_")
+		if len(pieces) > 1 {
+			// the buckets are keyed by FIPS code, but the CSV has always had the county's name
+			if county, ok := hcip2.CountyByFIPS(pieces[1]); ok {
+				pieces[1] = county.ShortName()
+			}
+		}
 		writer.Write(append(pieces, strconv.Itoa(v.residents), strconv.Itoa(v.registered), strconv.Itoa(v.voted)))
 	}
 }
@@ -1,8 +1,8 @@
 package main
 
 import (
-	"bufio"
 	"encoding/csv"
+	"flag"
 	"fmt"
 	"math"
 	"math/big"
@@ -11,7 +11,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/TomOnTime/utfutil"
 	"github.com/skemper/hcip2"
 )
 
@@ -22,32 +21,7 @@ const (
 	vcLon
 )
 
-type Voter struct {
-	County_id                int       //			char  3         County identification number
-	Ncid                     string    //				char 12         North Carolina identification number (NCID) of voter
-	Status_cd                byte      //			char  1	        Status code for voter registration
-	Voter_status_desc        string    //		char 10         Satus code descriptions.
-	Reason_cd                string    //			char  2         Reason code for voter registration status
-	Voter_status_reason_desc string    //	char 60         Reason code description
-	Race_code                string    // char  3         Race code
-	Race_desc                string    //			char 35         Race description
-	Ethnic_code              string    //			char  2         Ethnicity code
-	Ethnic_desc              string    //			char 30         Ethnicity description
-	Party_cd                 string    //			char  3         Party affiliation code
-	Sex_code                 byte      //			char  1         Gender code
-	Age                      int       //				char  3         Age
-	Registr_dt               time.Time //			char 10         Voter registration date
-	Precinct_abbrv           string    //			char  6         Precinct abbreviation
-	Precinct_desc            string    //			char 30         Precinct name
-	Cancellation_dt          time.Time //			char 10         Cancellation date
-	Vtd_abbrv                string    //			char  6         Voter tabuluation district abbreviation
-	Vtd_desc                 string    //			char 30         Voter tabuluation district name
-	Age_group                string    //			char 35         Age group range
-	Lat                      float64
-	Lon                      float64
-}
-
-var voters map[string]*Voter = make(map[string]*Voter)
+var voters map[string]*hcip2.Voter
 
 // precinct_coords.csv
 const (
@@ -74,63 +48,27 @@ type Precinct struct {
 
 var precincts map[string]*Precinct = make(map[string]*Precinct)
 
+// district is the level -district averages over, if it's set, and districtDistances are the
+// distances measured in each district, by its label
+var district hcip2.DistrictType
+var districtDistances map[string][]float64 = make(map[string][]float64)
+
+// state is the postal code of the voter file's layout, for naming its counties
+var state string
+
+var voterFlags = hcip2.NewVoterFlags(flag.CommandLine)
+var districtName = flag.String("district", "", "average the distances over a district level, e.g. state_house, rather than by precinct")
+
 func loadVoterDatabase() {
 	start := time.Now()
-	vrdb, err := utfutil.OpenFile("VR_Snapshot_20201103.txt", utfutil.WINDOWS)
-	defer vrdb.Close()
+	var err error
+	var config hcip2.HciConfig
+	voters, config, err = hcip2.LoadVotersFromFlags(voterFlags)
 	if err != nil {
-		fmt.Printf("Error opening VRDB: %s\n", err)
+		fmt.Printf("Error loading VRDB: %s\n", err)
 		os.Exit(1)
 	}
-	scanner := bufio.NewScanner(vrdb)
-	scanner.Scan() // skip the header line
-	for scanner.Scan() {
-		line := scanner.Text()
-		pieces := strings.Split(line, "\t")
-		countyID, err := strconv.Atoi(strings.TrimSpace(pieces[hcip2.County_id]))
-		if err != nil {
-			fmt.Printf("Error converting county %s to integer for %s: %s\n", pieces[hcip2.County_id], pieces[hcip2.Ncid], err)
-			os.Exit(1)
-		}
-		age, err := strconv.Atoi(strings.TrimSpace(pieces[hcip2.Age]))
-		if err != nil {
-			fmt.Printf("Error converting age %s to integer for %s: %s\n", pieces[hcip2.County_id], pieces[hcip2.Ncid], err)
-			os.Exit(1)
-		}
-		regDate, err := time.Parse("2006-01-02", pieces[hcip2.Registr_dt])
-		if err != nil {
-			fmt.Printf("Error converting regdate %s to date for %s: %s\n", pieces[hcip2.Registr_dt], pieces[hcip2.Ncid], err)
-			os.Exit(1)
-		}
-		canDate, err := time.Parse("2006-01-02", pieces[hcip2.Cancellation_dt])
-		if err != nil {
-			fmt.Printf("Error converting candate %s to date for %s: %s\n", pieces[hcip2.Cancellation_dt], pieces[hcip2.Ncid], err)
-			os.Exit(1)
-		}
-		voter := &Voter{
-			County_id:                countyID,
-			Ncid:                     strings.Trim(pieces[hcip2.Ncid], "\""),
-			Status_cd:                pieces[hcip2.Status_cd][0],
-			Voter_status_desc:        strings.Trim(pieces[hcip2.Voter_status_desc], "\""),
-			Reason_cd:                strings.Trim(pieces[hcip2.Reason_cd], "\""),
-			Voter_status_reason_desc: strings.Trim(pieces[hcip2.Voter_status_reason_desc], "\""),
-			Race_code:                strings.Trim(pieces[hcip2.Race_code], "\""),
-			Race_desc:                strings.Trim(pieces[hcip2.Race_desc], "\""),
-			Ethnic_code:              strings.Trim(pieces[hcip2.Ethnic_code], "\""),
-			Ethnic_desc:              strings.Trim(pieces[hcip2.Ethnic_desc], "\""),
-			Party_cd:                 strings.Trim(pieces[hcip2.Party_cd], "\""),
-			Sex_code:                 pieces[hcip2.Sex_code][0],
-			Age:                      age,
-			Registr_dt:               regDate,
-			Precinct_abbrv:           strings.Trim(pieces[hcip2.Precinct_abbrv], "\""),
-			Precinct_desc:            strings.Trim(pieces[hcip2.Precinct_desc], "\""),
-			Cancellation_dt:          canDate,
-			Vtd_abbrv:                strings.Trim(pieces[hcip2.Vtd_abbrv], "\""),
-			Vtd_desc:                 strings.Trim(pieces[hcip2.Vtd_desc], "\""),
-			Age_group:                strings.Trim(pieces[hcip2.Age_group], "\""),
-		}
-		voters[voter.Ncid] = voter
-	}
+	state = config.StateAbbrev
 	fmt.Printf("Loaded VRDB in %s...\n", time.Now().Sub(start))
 }
 
@@ -305,6 +243,10 @@ func calculateDistances() {
 		if precinct, ok := precincts[pLabel]; ok {
 			distance := haversineDistance(voter.Lat, voter.Lon, precinct.ppLat, precinct.ppLon)
 			precinct.distances = append(precinct.distances, distance)
+			if *districtName != "" {
+				label := voter.Districts[district].Label()
+				districtDistances[label] = append(districtDistances[label], distance)
+			}
 		} else {
 			// no precinct location, skip
 			noPrecinctLoc++
@@ -344,7 +286,30 @@ func getAverageDistances() {
 	}
 }
 
+// writeDistrictAverages writes the -district version of graph3.csv: one row per district
+func writeDistrictAverages(writer *csv.Writer) {
+	writer.Write([]string{strings.ToUpper(district.String()), "VOTERS", "AVERAGE_DISTANCE"})
+	for label, distances := range districtDistances {
+		sum := big.NewFloat(0.0)
+		for _, distance := range distances {
+			sum = sum.Add(sum, big.NewFloat(distance))
+		}
+		avgDist, _ := sum.Quo(sum, big.NewFloat(float64(len(distances)))).Float64()
+		writer.Write([]string{label, strconv.Itoa(len(distances)), strconv.FormatFloat(avgDist, 'f', 4, 64)})
+	}
+}
+
 func main() {
+	flag.Parse()
+
+	if *districtName != "" {
+		var err error
+		if district, err = hcip2.ParseDistrictType(*districtName); err != nil {
+			fmt.Printf("Error in -district: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
 	outFile, err := os.OpenFile("graph3.csv", os.O_CREATE+os.O_WRONLY, 0644)
 	defer outFile.Close()
 	if err != nil {
@@ -364,6 +329,11 @@ func main() {
 
 	// dump it to CSV
 	writer := csv.NewWriter(outFile)
+	defer writer.Flush()
+	if *districtName != "" {
+		writeDistrictAverages(writer)
+		return
+	}
 	writer.Write([]string{"COUNTY", "PRECINCT", "AVERAGE_DISTANCE"})
 	for k, v := range precincts {
 		pieces := strings.Split(k, "_")
@@ -374,6 +344,10 @@ func main() {
 		} else {
 			avgDist, _ = v.avgDistance.Float64()
 		}
-		writer.Write([]string{hcip2.Counties[countyID], pieces[1], strconv.FormatFloat(avgDist, 'f', 4, 64)})
+		name := pieces[0] // the ID, for a state whose counties hcip2 doesn't know
+		if county, ok := hcip2.CountyByID(state, countyID); ok {
+			name = county.ShortName()
+		}
+		writer.Write([]string{name, pieces[1], strconv.FormatFloat(avgDist, 'f', 4, 64)})
 	}
 }
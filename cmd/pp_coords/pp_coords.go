@@ -2,16 +2,15 @@ package main
 
 import (
 	"encoding/csv"
-	"encoding/json"
+	"flag"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"os"
-	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/skemper/hcip2"
+	"github.com/skemper/hcip2/address"
 )
 
 const (
@@ -22,89 +21,104 @@ const (
 	PollingPlaceAddr
 )
 
-var addrRegexp = regexp.MustCompile(`([^,]+),([^,]+), NC (\d{5})`)
-
 const readBatchSize = 10000
 const maxLineLength = 1000
 
 var newline = []byte{'\n'}
 
-func makeCall(url *string) *[]hcip2.JSONResult {
-	// Call Nominatim to geocode their address
-	resp, err := http.Get(*url)
-	if err != nil {
-		fmt.Printf("Error calling Nominatim: %s\n", err.Error())
-		os.Exit(1)
-	}
-	if resp.StatusCode != 200 {
-		fmt.Printf("Non-OK response code from Nominatim: %d %s\n", resp.StatusCode, resp.Body)
-		fmt.Printf("Came from URL %s\n", *url)
-		os.Exit(1)
+var geocoderName = flag.String("geocoder", "nominatim", "geocoder to use: nominatim, photon, pelias or census")
+var geocoderURL = flag.String("geocoder-url", "", "where the geocoder is; the backend's usual local or public URL if empty")
+var retryFlags = hcip2.NewRetryFlags(flag.CommandLine)
+var geocacheFile = flag.String("geocache", "geocode.cache", "where to keep the geocoder's answers between runs; no cache if empty")
+var geocacheTTL = flag.Duration("geocache-ttl", 365*24*time.Hour, "how long cached answers are good for; forever if 0")
+var centroidsFile = flag.String("centroids", "", "Census Gazetteer counties file, to score the places the geocoder finds by how near they are to the polling place's county")
+var minConfidence = flag.Float64("min-confidence", 0.15, "how far ahead of the rest the best of several places has to score to be taken")
+var picksFile = flag.String("picks", "picks.csv", "where to list the polling places the best of several places was taken for, with its confidence")
+var reviewFile = flag.String("review", "review.csv", "where to list the places found for polling places too close to call, for someone to look over")
+var geocacheVersion = flag.String("geocache-version", "", "tags the cached answers, e.g. with the date of the geocoder's map data; answers with another tag aren't used")
+
+var geocoder hcip2.Geocoder
+
+// check gives up on the run if the geocoder failed for good; the retries and the circuit breaker
+// have already waited out anything passing.  Geocoders that can't do free-form searches just find
+// nothing for them.
+func check(v []hcip2.Match, err error) []hcip2.Match {
+	if err == hcip2.ErrFreeForm {
+		return nil
 	}
-
-	v := new([]hcip2.JSONResult)
-	err = json.NewDecoder(resp.Body).Decode(&v)
 	if err != nil {
-		fmt.Printf("Error decoding JSON: %s\n", err.Error())
-		fmt.Printf("From URL: %s\n", *url)
-		body, _ := ioutil.ReadAll(resp.Body)
-		fmt.Printf("Full response was: %s\n", body)
+		fmt.Printf("Error geocoding: %s\n", err)
 		os.Exit(1)
 	}
 	return v
 }
 
+// formatCoord writes a latitude or longitude for goods.csv
+func formatCoord(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
 // query1 decomposes the entire address and feeds the structed data to the API
-func query1(addrPieces []string) *[]hcip2.JSONResult {
-	urlBuilder := strings.Builder{}
-	urlBuilder.WriteString("http://localhost/nominatim/search?country=us&format=jsonv2&street=")
-	urlBuilder.WriteString(addrPieces[1])
-	urlBuilder.WriteString("&city=")
-	urlBuilder.WriteString(addrPieces[2])
-	urlBuilder.WriteString("&state=NC&postalcode=")
-	urlBuilder.WriteString(addrPieces[3])
-	url := strings.ReplaceAll(urlBuilder.String(), " ", "+")
-	fmt.Println(url)
-	return makeCall(&url)
+func query1(addr address.Address) []hcip2.Match {
+	return check(geocoder.Geocode(addr))
 }
 
 // query2 asks just the location name and the ZIP code
-func query2(name string, addrPieces []string) *[]hcip2.JSONResult {
-	urlBuilder := strings.Builder{}
-	urlBuilder.WriteString("http://localhost/nominatim/search?country=us&format=jsonv2&q=")
-	urlBuilder.WriteString(name)
-	urlBuilder.WriteString(", ")
-	urlBuilder.WriteString(addrPieces[3])
-	url := strings.ReplaceAll(urlBuilder.String(), " ", "+")
-	fmt.Println(url)
-	return makeCall(&url)
+func query2(name string, addr address.Address) []hcip2.Match {
+	return check(geocoder.Search(name + ", " + addr.Zip))
 }
 
 // query3 is like query1, but without the city
-func query3(addrPieces []string) *[]hcip2.JSONResult {
-	urlBuilder := strings.Builder{}
-	urlBuilder.WriteString("http://localhost/nominatim/search?country=us&format=jsonv2&street=")
-	urlBuilder.WriteString(addrPieces[1])
-	urlBuilder.WriteString("&state=NC&postalcode=")
-	urlBuilder.WriteString(addrPieces[3])
-	url := strings.ReplaceAll(urlBuilder.String(), " ", "+")
-	fmt.Println(url)
-	return makeCall(&url)
+func query3(addr address.Address) []hcip2.Match {
+	addr.City = ""
+	return check(geocoder.Geocode(addr))
 }
 
-// query4 looks for the name of the polling place, in North Carolina.  it's a Hail Mary, but it works in at least one case
-func query4(name string) *[]hcip2.JSONResult {
-	urlBuilder := strings.Builder{}
-	urlBuilder.WriteString("http://localhost/nominatim/search?country=us&format=jsonv2&q=")
-	urlBuilder.WriteString(name)
-	urlBuilder.WriteString(", NC, USA")
-	url := strings.ReplaceAll(urlBuilder.String(), " ", "+")
-	fmt.Println(url)
-	return makeCall(&url)
+// query4 looks for the name of the polling place, in its state.  it's a Hail Mary, but it works in at least one case
+func query4(name string, addr address.Address) []hcip2.Match {
+	return check(geocoder.Search(name + ", " + addr.State + ", USA"))
 }
 
 func main() {
+	flag.Parse()
+	var err error
+	if geocoder, err = hcip2.NewGeocoder(*geocoderName, *geocoderURL, hcip2.NewHTTPClient(1, retryFlags.Policy(*geocoderName))); err != nil {
+		fmt.Printf("Error in -geocoder: %s\n", err)
+		os.Exit(1)
+	}
+	var geocache *hcip2.GeocodeCache
+	if *geocacheFile != "" {
+		if geocache, err = hcip2.OpenGeocodeCache(*geocacheFile, *geocacheTTL); err != nil {
+			fmt.Printf("Error opening geocode cache: %s\n", err)
+			os.Exit(1)
+		}
+		defer geocache.Close()
+		geocoder = geocache.Wrap(geocoder, *geocacheVersion)
+	}
+
+	var centroids map[string]hcip2.Point
+	if *centroidsFile != "" {
+		if centroids, err = hcip2.LoadCentroids(*centroidsFile); err != nil {
+			fmt.Printf("Error loading centroids: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	scorer := hcip2.NewScorer(centroids)
+	scorer.MinConfidence = *minConfidence
+
 	_goods, _bads, _multis := hcip2.MakeFiles()
+	_picks, err := hcip2.OpenOutput(*picksFile, nil)
+	if err != nil {
+		fmt.Printf("Error opening %s: %s\n", *picksFile, err)
+		os.Exit(1)
+	}
+	defer _picks.Close()
+	_review, err := hcip2.OpenOutput(*reviewFile, nil)
+	if err != nil {
+		fmt.Printf("Error opening %s: %s\n", *reviewFile, err)
+		os.Exit(1)
+	}
+	defer _review.Close()
 
 	// we are reading just one file: 202011_VRDB_Extract.txt
 	vrdb, err := os.Open("nc_polling_places.csv")
@@ -117,6 +131,10 @@ func main() {
 	goods := csv.NewWriter(_goods)
 	bads := csv.NewWriter(_bads)
 	multis := csv.NewWriter(_multis)
+	picks := csv.NewWriter(_picks)
+	review := csv.NewWriter(_review)
+	picks.Write(hcip2.PickHeader)
+	review.Write(hcip2.ReviewHeader)
 
 	start := time.Now()
 	var lines [][]string
@@ -141,36 +159,64 @@ func main() {
 		fmt.Printf("\n")
 		fmt.Println(oneline)
 		fulladdr := line[PollingPlaceAddr]
-		addrPieces := addrRegexp.FindStringSubmatch(fulladdr)
-		if addrPieces == nil {
-			fmt.Printf("Line %s doesn't match regex\n", fulladdr)
+		addr, confidence := address.Parse(fulladdr)
+		if addr.State == "" {
+			addr.State = "NC"
 		}
 
-		v := query1(addrPieces)
-		if len(*v) == 1 {
-			goodlines[numGoods] = append(line, (*v)[0].Lat, (*v)[0].Lon)
+		id := line[CountyID] + "/" + line[PrecinctLabel]
+		fips := ""
+		if countyID, err := strconv.Atoi(line[CountyID]); err == nil {
+			if county, ok := hcip2.CountyByID("NC", countyID); ok {
+				fips = county.FIPS
+			}
+		}
+		// found takes a query's answer if it's one place, or clearly the best of several.  The
+		// first query too close to call is the one to review: it's the most specific.
+		var unsettled *hcip2.Pick
+		found := func(v []hcip2.Match) bool {
+			if len(v) > 1 {
+				pick := scorer.Pick(v, addr, fips)
+				if !pick.OK {
+					if unsettled == nil {
+						unsettled = &pick
+					}
+					return false
+				}
+				picks.Write(pick.PickRow(id))
+				v = []hcip2.Match{pick.Best()}
+			}
+			if len(v) != 1 {
+				return false
+			}
+			goodlines[numGoods] = append(line, formatCoord(v[0].Lat), formatCoord(v[0].Lon))
 			numGoods++
+			return true
+		}
+
+		if confidence == address.Low {
+			fmt.Printf("Couldn't make sense of address %s, looking up the name only\n", fulladdr)
+		} else if found(query1(addr)) {
 			continue
 		}
 
-		v = query2(line[PollingPlaceName], addrPieces)
-		if len(*v) == 1 {
-			goodlines[numGoods] = append(line, (*v)[0].Lat, (*v)[0].Lon)
-			numGoods++
+		if addr.Zip != "" && found(query2(line[PollingPlaceName], addr)) {
 			continue
 		}
 
-		v = query3(addrPieces)
-		if len(*v) == 1 {
-			goodlines[numGoods] = append(line, (*v)[0].Lat, (*v)[0].Lon)
-			numGoods++
+		if confidence != address.Low && found(query3(addr)) {
 			continue
 		}
 
-		v = query4(line[PollingPlaceName])
-		if len(*v) == 1 {
-			goodlines[numGoods] = append(line, (*v)[0].Lat, (*v)[0].Lon)
-			numGoods++
+		if found(query4(line[PollingPlaceName], addr)) {
+			continue
+		}
+
+		// too close to call beats found nothing
+		if unsettled != nil {
+			multilines[numMultis] = line
+			numMultis++
+			review.WriteAll(unsettled.ReviewRows(id, fulladdr))
 			continue
 		}
 
@@ -194,8 +240,10 @@ func main() {
 	goods.Flush()
 	bads.Flush()
 	multis.Flush()
+	picks.Flush()
+	review.Flush()
 
 	end := time.Now()
-	fmt.Printf("Finished (read: %d, wrote: %d) in %s...\n", count, numGoods+numBads+numMultis, end.Sub(start))
+	fmt.Printf("Finished (read: %d, wrote: %d) in %s%s...\n", count, numGoods+numBads+numMultis, end.Sub(start), geocache.StatsString())
 
 }
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skemper/hcip2"
+)
+
+var stateName = flag.String("state", "NC", "state abbreviation or JSON layout of the voter file")
+var history = flag.Bool("history", false, "the file is NC's ncvhis voting history rather than a snapshot")
+var keyColumns = flag.String("key", "", "comma-separated columns that together identify a record; the voter ID if empty, or ncid,election_lbl with -history")
+var dateColumns = flag.String("dates", "", "comma-separated columns to check as dates, on top of the layout's date fields; election_lbl with -history")
+var outFile = flag.String("out", "quality.json", "where to write the full report")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Printf("Usage: %s [flags] voter_file\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	start := time.Now()
+
+	var err error
+	config := hcip2.NCHistory
+	if *history {
+		if *keyColumns == "" {
+			*keyColumns = "ncid,election_lbl"
+		}
+		if *dateColumns == "" {
+			*dateColumns = "election_lbl"
+		}
+	} else {
+		config, err = hcip2.GetConfig(*stateName)
+		if err != nil {
+			fmt.Printf("Error loading config: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	config.OnHeaderDrift = hcip2.WarnHeaderDrift
+
+	var opts hcip2.QualityOptions
+	if opts.Key, err = columns(&config, *keyColumns); err == nil {
+		opts.DateColumns, err = columns(&config, *dateColumns)
+	}
+	if err != nil {
+		fmt.Printf("Error in flags: %s\n", err)
+		os.Exit(1)
+	}
+
+	report, err := hcip2.ProfileFile(flag.Arg(0), config, opts)
+	if err != nil {
+		fmt.Printf("Error profiling %s: %s\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+
+	out, err := os.Create(*outFile)
+	if err != nil {
+		fmt.Printf("Error opening %s: %s\n", *outFile, err)
+		os.Exit(1)
+	}
+	defer out.Close()
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(report); err != nil {
+		fmt.Printf("Error writing %s: %s\n", *outFile, err)
+		os.Exit(1)
+	}
+
+	printSummary(report, config.MaxLineLength)
+	fmt.Printf("Profiled %d rows in %s...\n", report.Rows, time.Now().Sub(start))
+}
+
+// columns resolves a comma-separated list of column names
+func columns(config *hcip2.HciConfig, names string) ([]int, error) {
+	var cols []int
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		col, ok := config.ColumnIndex(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %s", name)
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// printSummary writes the readable version of the report: the whole-file counts, the columns
+// that are always empty, then every other column that has nulls or bad values, worst first
+func printSummary(report *hcip2.QualityReport, maxLineLength int) {
+	fmt.Printf("%s: %d rows\n", report.File, report.Rows)
+	fmt.Printf("  %d short rows, %d long rows, %d lines over %d characters\n", report.ShortRows, report.LongRows, report.OverlongLines, maxLineLength)
+	fmt.Printf("  %d duplicate keys", report.DuplicateKeys)
+	if len(report.DuplicateExamples) > 0 {
+		fmt.Printf(" (%s)", strings.Join(report.DuplicateExamples, ", "))
+	}
+	fmt.Println()
+	fmt.Printf("  %d addresses without a house number\n", report.MissingHouseNumbers)
+
+	var empty []string
+	cols := make([]*hcip2.ColumnProfile, 0, len(report.Columns))
+	for i := range report.Columns {
+		col := &report.Columns[i]
+		switch {
+		case report.Rows > 0 && col.Nulls == report.Rows:
+			empty = append(empty, col.Name)
+		case col.Nulls > 0 || col.Problems() > 0:
+			cols = append(cols, col)
+		}
+	}
+	if len(empty) > 0 {
+		fmt.Printf("  %d columns always empty: %s\n", len(empty), strings.Join(empty, ", "))
+	}
+	sort.SliceStable(cols, func(i, j int) bool {
+		if cols[i].Problems() != cols[j].Problems() {
+			return cols[i].Problems() > cols[j].Problems()
+		}
+		return cols[i].NullRate > cols[j].NullRate
+	})
+
+	fmt.Printf("\n%-28s %7s %9s %9s %9s %9s %9s\n", "column", "null%", "distinct", "bad date", "bad num", "bad age", "bad code")
+	for _, col := range cols {
+		distinct := fmt.Sprint(col.Distinct)
+		if col.DistinctCapped {
+			distinct += "+"
+		}
+		unknown := 0
+		for _, n := range col.UnknownCodes {
+			unknown += n
+		}
+		fmt.Printf("%-28s %6.2f%% %9s %9d %9d %9d %9d\n", col.Name, 100*col.NullRate, distinct, col.InvalidDates, col.InvalidNumbers, col.AgeOutOfRange, unknown)
+		for _, example := range col.Examples {
+			fmt.Printf("    %s\n", example)
+		}
+	}
+}
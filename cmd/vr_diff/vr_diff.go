@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/skemper/hcip2"
+)
+
+var stateName = flag.String("state", "NC", "state abbreviation or JSON layout of both snapshots")
+var filterExpr = flag.String("filter", "", "only compare the voters this filter expression is true for, e.g. 'County_id == 92'")
+var format = flag.String("format", "csv", "format of the change events: csv or ndjson")
+var outFile = flag.String("out", "changes.csv", "where to write the change events")
+var summaryFile = flag.String("summary", "change_summary.csv", "where to write the per-county counts of each kind of change")
+var private = flag.Bool("private", false, "pseudonymize voter IDs and leave names and addresses out of the change events")
+var keyFile = flag.String("key", "", "file holding the pseudonymization key for -private; $"+hcip2.PrivacyKeyEnv+" if not given")
+var statuses = flag.String("status", "active,inactive", "registration statuses to report changes for: any of active, inactive, removed and denied, or all")
+var confidential = flag.Bool("confidential", false, "compare confidential and exempt voters too")
+var rejectsFile = flag.String("rejects", "rejects.csv", "where to put the rows that can't be parsed")
+var maxRejects = flag.String("max-rejects", "1%", "how many rows can be rejected before giving up: a count, a percentage of the rows, or none")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Printf("Usage: %s [flags] old_snapshot new_snapshot\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	start := time.Now()
+
+	config, err := hcip2.GetConfig(*stateName)
+	if err != nil {
+		fmt.Printf("Error loading config: %s\n", err)
+		os.Exit(1)
+	}
+	config.OnHeaderDrift = hcip2.WarnHeaderDrift
+	if config.Policy, err = hcip2.ParseStatusPolicy(*statuses, *confidential); err != nil {
+		fmt.Printf("Error in -status: %s\n", err)
+		os.Exit(1)
+	}
+	if *filterExpr != "" {
+		if err = config.AddFilter(*filterExpr); err != nil {
+			fmt.Printf("Error in filter: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	budget, err := hcip2.ParseBudget(*maxRejects)
+	if err == nil {
+		config.Rejects, err = hcip2.NewRejects(*rejectsFile, budget)
+	}
+	if err != nil {
+		fmt.Printf("Error setting up rejects: %s\n", err)
+		os.Exit(1)
+	}
+	defer config.Rejects.Close()
+
+	var redactor *hcip2.Redactor
+	if *private {
+		key, err := hcip2.LoadPrivacyKey(*keyFile)
+		if err == nil {
+			redactor, err = hcip2.NewRedactor(config, key)
+		}
+		if err != nil {
+			fmt.Printf("Error starting privacy mode: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	out, err := os.Create(*outFile)
+	if err != nil {
+		fmt.Printf("Error opening %s: %s\n", *outFile, err)
+		os.Exit(1)
+	}
+	defer out.Close()
+
+	var write func(hcip2.Change) error
+	var flush func() error
+	switch *format {
+	case "csv":
+		w := csv.NewWriter(out)
+		w.Write([]string{"kind", "state_voter_id", "county", "old", "new", "reason"})
+		write = func(c hcip2.Change) error {
+			return w.Write([]string{string(c.Kind), c.StateVoterID, c.County, c.Old, c.New, c.Reason})
+		}
+		flush = func() error {
+			w.Flush()
+			return w.Error()
+		}
+	case "ndjson":
+		enc := json.NewEncoder(out)
+		write = func(c hcip2.Change) error { return enc.Encode(c) }
+		flush = func() error { return nil }
+	default:
+		fmt.Printf("Unknown format %s; use csv or ndjson\n", *format)
+		os.Exit(1)
+	}
+
+	counts := make(map[string]map[hcip2.ChangeKind]int)
+	total := 0
+	err = hcip2.DiffSnapshots(flag.Arg(0), flag.Arg(1), config, func(c hcip2.Change) error {
+		if counts[c.County] == nil {
+			counts[c.County] = make(map[hcip2.ChangeKind]int)
+		}
+		counts[c.County][c.Kind]++
+		total++
+		if redactor != nil {
+			c = redact(redactor, c)
+		}
+		return write(c)
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		fmt.Printf("Error comparing snapshots: %s\n", err)
+		os.Exit(1)
+	}
+
+	if err = writeSummary(counts); err != nil {
+		fmt.Printf("Error writing %s: %s\n", *summaryFile, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Found %d changes in %s...\n", total, time.Now().Sub(start))
+}
+
+// redact pseudonymizes a change's voter ID and blanks the names and addresses it carries
+func redact(redactor *hcip2.Redactor, c hcip2.Change) hcip2.Change {
+	c.StateVoterID = redactor.Pseudonym(c.StateVoterID)
+	switch c.Kind {
+	case hcip2.NewRegistration, hcip2.Move, hcip2.NameChange:
+		c.Old, c.New = "", ""
+	}
+	return c
+}
+
+// writeSummary writes one row per county, with a column for each kind of change
+func writeSummary(counts map[string]map[hcip2.ChangeKind]int) error {
+	file, err := os.Create(*summaryFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	counties := make([]string, 0, len(counts))
+	for county := range counts {
+		counties = append(counties, county)
+	}
+	sort.Strings(counties)
+
+	w := csv.NewWriter(file)
+	header := []string{"county"}
+	for _, kind := range hcip2.ChangeKinds {
+		header = append(header, string(kind))
+	}
+	w.Write(header)
+	for _, county := range counties {
+		row := []string{county}
+		for _, kind := range hcip2.ChangeKinds {
+			row = append(row, strconv.Itoa(counts[county][kind]))
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,303 @@
+package hcip2
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CountyInfo is one county of a state, with the keys each data source knows it by.  Join on ID or
+// FIPS rather than on names: the sources don't agree on how to spell them.
+type CountyInfo struct {
+	State  string // two-letter postal code
+	ID     int    // the state's county number: NCSBE's county_id, or WA's alphabetical county number
+	Code   string // the county code in the voter file, where it's not the ID (WA's CountyCode)
+	Name   string // upper case, spaces and all
+	FIPS   string // five-digit state and county FIPS code
+	Region string // NC's Prosperity Zone
+	COG    string // NC's council of governments region
+	MSA    string // the metropolitan statistical area the county is in, if any
+}
+
+// NCCounties lists NC's counties in county_id order
+var NCCounties = []CountyInfo{
+	{State: "NC", ID: 1, Name: "ALAMANCE", FIPS: "37001", Region: "Piedmont-Triad", COG: "Piedmont Triad", MSA: "Burlington"},
+	{State: "NC", ID: 2, Name: "ALEXANDER", FIPS: "37003", Region: "Northwest", COG: "Western Piedmont", MSA: "Hickory-Lenoir-Morganton"},
+	{State: "NC", ID: 3, Name: "ALLEGHANY", FIPS: "37005", Region: "Northwest", COG: "High Country"},
+	{State: "NC", ID: 4, Name: "ANSON", FIPS: "37007", Region: "Southwest", COG: "Centralina", MSA: "Charlotte-Concord-Gastonia"},
+	{State: "NC", ID: 5, Name: "ASHE", FIPS: "37009", Region: "Northwest", COG: "High Country"},
+	{State: "NC", ID: 6, Name: "AVERY", FIPS: "37011", Region: "Western", COG: "High Country"},
+	{State: "NC", ID: 7, Name: "BEAUFORT", FIPS: "37013", Region: "Northeast", COG: "Mid-East"},
+	{State: "NC", ID: 8, Name: "BERTIE", FIPS: "37015", Region: "Northeast", COG: "Mid-East"},
+	{State: "NC", ID: 9, Name: "BLADEN", FIPS: "37017", Region: "Sandhills", COG: "Lumber River"},
+	{State: "NC", ID: 10, Name: "BRUNSWICK", FIPS: "37019", Region: "Southeast", COG: "Cape Fear", MSA: "Myrtle Beach-Conway-North Myrtle Beach"},
+	{State: "NC", ID: 11, Name: "BUNCOMBE", FIPS: "37021", Region: "Western", COG: "Land of Sky", MSA: "Asheville"},
+	{State: "NC", ID: 12, Name: "BURKE", FIPS: "37023", Region: "Western", COG: "Western Piedmont", MSA: "Hickory-Lenoir-Morganton"},
+	{State: "NC", ID: 13, Name: "CABARRUS", FIPS: "37025", Region: "Southwest", COG: "Centralina", MSA: "Charlotte-Concord-Gastonia"},
+	{State: "NC", ID: 14, Name: "CALDWELL", FIPS: "37027", Region: "Northwest", COG: "Western Piedmont", MSA: "Hickory-Lenoir-Morganton"},
+	{State: "NC", ID: 15, Name: "CAMDEN", FIPS: "37029", Region: "Northeast", COG: "Albemarle", MSA: "Virginia Beach-Norfolk-Newport News"},
+	{State: "NC", ID: 16, Name: "CARTERET", FIPS: "37031", Region: "Southeast", COG: "Eastern Carolina"},
+	{State: "NC", ID: 17, Name: "CASWELL", FIPS: "37033", Region: "Piedmont-Triad", COG: "Piedmont Triad"},
+	{State: "NC", ID: 18, Name: "CATAWBA", FIPS: "37035", Region: "Northwest", COG: "Western Piedmont", MSA: "Hickory-Lenoir-Morganton"},
+	{State: "NC", ID: 19, Name: "CHATHAM", FIPS: "37037", Region: "North Central", COG: "Triangle J", MSA: "Durham-Chapel Hill"},
+	{State: "NC", ID: 20, Name: "CHEROKEE", FIPS: "37039", Region: "Western", COG: "Southwestern Commission"},
+	{State: "NC", ID: 21, Name: "CHOWAN", FIPS: "37041", Region: "Northeast", COG: "Albemarle"},
+	{State: "NC", ID: 22, Name: "CLAY", FIPS: "37043", Region: "Western", COG: "Southwestern Commission"},
+	{State: "NC", ID: 23, Name: "CLEVELAND", FIPS: "37045", Region: "Southwest", COG: "Isothermal"},
+	{State: "NC", ID: 24, Name: "COLUMBUS", FIPS: "37047", Region: "Sandhills", COG: "Cape Fear"},
+	{State: "NC", ID: 25, Name: "CRAVEN", FIPS: "37049", Region: "Southeast", COG: "Eastern Carolina", MSA: "New Bern"},
+	{State: "NC", ID: 26, Name: "CUMBERLAND", FIPS: "37051", Region: "Sandhills", COG: "Mid-Carolina", MSA: "Fayetteville"},
+	{State: "NC", ID: 27, Name: "CURRITUCK", FIPS: "37053", Region: "Northeast", COG: "Albemarle", MSA: "Virginia Beach-Norfolk-Newport News"},
+	{State: "NC", ID: 28, Name: "DARE", FIPS: "37055", Region: "Northeast", COG: "Albemarle"},
+	{State: "NC", ID: 29, Name: "DAVIDSON", FIPS: "37057", Region: "Piedmont-Triad", COG: "Piedmont Triad", MSA: "Winston-Salem"},
+	{State: "NC", ID: 30, Name: "DAVIE", FIPS: "37059", Region: "Piedmont-Triad", COG: "Piedmont Triad", MSA: "Winston-Salem"},
+	{State: "NC", ID: 31, Name: "DUPLIN", FIPS: "37061", Region: "Southeast", COG: "Eastern Carolina"},
+	{State: "NC", ID: 32, Name: "DURHAM", FIPS: "37063", Region: "North Central", COG: "Triangle J", MSA: "Durham-Chapel Hill"},
+	{State: "NC", ID: 33, Name: "EDGECOMBE", FIPS: "37065", Region: "North Central", COG: "Upper Coastal Plain", MSA: "Rocky Mount"},
+	{State: "NC", ID: 34, Name: "FORSYTH", FIPS: "37067", Region: "Piedmont-Triad", COG: "Piedmont Triad", MSA: "Winston-Salem"},
+	{State: "NC", ID: 35, Name: "FRANKLIN", FIPS: "37069", Region: "North Central", COG: "Kerr-Tar", MSA: "Raleigh-Cary"},
+	{State: "NC", ID: 36, Name: "GASTON", FIPS: "37071", Region: "Southwest", COG: "Centralina", MSA: "Charlotte-Concord-Gastonia"},
+	{State: "NC", ID: 37, Name: "GATES", FIPS: "37073", Region: "Northeast", COG: "Albemarle", MSA: "Virginia Beach-Norfolk-Newport News"},
+	{State: "NC", ID: 38, Name: "GRAHAM", FIPS: "37075", Region: "Western", COG: "Southwestern Commission"},
+	{State: "NC", ID: 39, Name: "GRANVILLE", FIPS: "37077", Region: "North Central", COG: "Kerr-Tar", MSA: "Durham-Chapel Hill"},
+	{State: "NC", ID: 40, Name: "GREENE", FIPS: "37079", Region: "Southeast", COG: "Eastern Carolina"},
+	{State: "NC", ID: 41, Name: "GUILFORD", FIPS: "37081", Region: "Piedmont-Triad", COG: "Piedmont Triad", MSA: "Greensboro-High Point"},
+	{State: "NC", ID: 42, Name: "HALIFAX", FIPS: "37083", Region: "Northeast", COG: "Upper Coastal Plain"},
+	{State: "NC", ID: 43, Name: "HARNETT", FIPS: "37085", Region: "North Central", COG: "Mid-Carolina", MSA: "Fayetteville"},
+	{State: "NC", ID: 44, Name: "HAYWOOD", FIPS: "37087", Region: "Western", COG: "Southwestern Commission", MSA: "Asheville"},
+	{State: "NC", ID: 45, Name: "HENDERSON", FIPS: "37089", Region: "Western", COG: "Land of Sky", MSA: "Asheville"},
+	{State: "NC", ID: 46, Name: "HERTFORD", FIPS: "37091", Region: "Northeast", COG: "Mid-East"},
+	{State: "NC", ID: 47, Name: "HOKE", FIPS: "37093", Region: "Sandhills", COG: "Lumber River", MSA: "Fayetteville"},
+	{State: "NC", ID: 48, Name: "HYDE", FIPS: "37095", Region: "Northeast", COG: "Albemarle"},
+	{State: "NC", ID: 49, Name: "IREDELL", FIPS: "37097", Region: "Southwest", COG: "Centralina", MSA: "Charlotte-Concord-Gastonia"},
+	{State: "NC", ID: 50, Name: "JACKSON", FIPS: "37099", Region: "Western", COG: "Southwestern Commission"},
+	{State: "NC", ID: 51, Name: "JOHNSTON", FIPS: "37101", Region: "North Central", COG: "Triangle J", MSA: "Raleigh-Cary"},
+	{State: "NC", ID: 52, Name: "JONES", FIPS: "37103", Region: "Southeast", COG: "Eastern Carolina", MSA: "New Bern"},
+	{State: "NC", ID: 53, Name: "LEE", FIPS: "37105", Region: "North Central", COG: "Triangle J"},
+	{State: "NC", ID: 54, Name: "LENOIR", FIPS: "37107", Region: "Southeast", COG: "Eastern Carolina"},
+	{State: "NC", ID: 55, Name: "LINCOLN", FIPS: "37109", Region: "Southwest", COG: "Centralina", MSA: "Charlotte-Concord-Gastonia"},
+	{State: "NC", ID: 56, Name: "MACON", FIPS: "37111", Region: "Western", COG: "Southwestern Commission"},
+	{State: "NC", ID: 57, Name: "MADISON", FIPS: "37113", Region: "Western", COG: "Land of Sky", MSA: "Asheville"},
+	{State: "NC", ID: 58, Name: "MARTIN", FIPS: "37115", Region: "Northeast", COG: "Mid-East"},
+	{State: "NC", ID: 59, Name: "MCDOWELL", FIPS: "37117", Region: "Western", COG: "Isothermal"},
+	{State: "NC", ID: 60, Name: "MECKLENBURG", FIPS: "37119", Region: "Southwest", COG: "Centralina", MSA: "Charlotte-Concord-Gastonia"},
+	{State: "NC", ID: 61, Name: "MITCHELL", FIPS: "37121", Region: "Western", COG: "High Country"},
+	{State: "NC", ID: 62, Name: "MONTGOMERY", FIPS: "37123", Region: "Piedmont-Triad", COG: "Piedmont Triad"},
+	{State: "NC", ID: 63, Name: "MOORE", FIPS: "37125", Region: "Sandhills", COG: "Triangle J"},
+	{State: "NC", ID: 64, Name: "NASH", FIPS: "37127", Region: "North Central", COG: "Upper Coastal Plain", MSA: "Rocky Mount"},
+	{State: "NC", ID: 65, Name: "NEW HANOVER", FIPS: "37129", Region: "Southeast", COG: "Cape Fear", MSA: "Wilmington"},
+	{State: "NC", ID: 66, Name: "NORTHAMPTON", FIPS: "37131", Region: "Northeast", COG: "Upper Coastal Plain"},
+	{State: "NC", ID: 67, Name: "ONSLOW", FIPS: "37133", Region: "Southeast", COG: "Eastern Carolina", MSA: "Jacksonville"},
+	{State: "NC", ID: 68, Name: "ORANGE", FIPS: "37135", Region: "North Central", COG: "Triangle J", MSA: "Durham-Chapel Hill"},
+	{State: "NC", ID: 69, Name: "PAMLICO", FIPS: "37137", Region: "Southeast", COG: "Eastern Carolina", MSA: "New Bern"},
+	{State: "NC", ID: 70, Name: "PASQUOTANK", FIPS: "37139", Region: "Northeast", COG: "Albemarle"},
+	{State: "NC", ID: 71, Name: "PENDER", FIPS: "37141", Region: "Southeast", COG: "Cape Fear", MSA: "Wilmington"},
+	{State: "NC", ID: 72, Name: "PERQUIMANS", FIPS: "37143", Region: "Northeast", COG: "Albemarle"},
+	{State: "NC", ID: 73, Name: "PERSON", FIPS: "37145", Region: "North Central", COG: "Kerr-Tar", MSA: "Durham-Chapel Hill"},
+	{State: "NC", ID: 74, Name: "PITT", FIPS: "37147", Region: "Northeast", COG: "Mid-East", MSA: "Greenville"},
+	{State: "NC", ID: 75, Name: "POLK", FIPS: "37149", Region: "Western", COG: "Isothermal"},
+	{State: "NC", ID: 76, Name: "RANDOLPH", FIPS: "37151", Region: "Piedmont-Triad", COG: "Piedmont Triad", MSA: "Greensboro-High Point"},
+	{State: "NC", ID: 77, Name: "RICHMOND", FIPS: "37153", Region: "Sandhills", COG: "Lumber River"},
+	{State: "NC", ID: 78, Name: "ROBESON", FIPS: "37155", Region: "Sandhills", COG: "Lumber River"},
+	{State: "NC", ID: 79, Name: "ROCKINGHAM", FIPS: "37157", Region: "Piedmont-Triad", COG: "Piedmont Triad", MSA: "Greensboro-High Point"},
+	{State: "NC", ID: 80, Name: "ROWAN", FIPS: "37159", Region: "Southwest", COG: "Centralina", MSA: "Charlotte-Concord-Gastonia"},
+	{State: "NC", ID: 81, Name: "RUTHERFORD", FIPS: "37161", Region: "Western", COG: "Isothermal"},
+	{State: "NC", ID: 82, Name: "SAMPSON", FIPS: "37163", Region: "Sandhills", COG: "Mid-Carolina"},
+	{State: "NC", ID: 83, Name: "SCOTLAND", FIPS: "37165", Region: "Sandhills", COG: "Lumber River"},
+	{State: "NC", ID: 84, Name: "STANLY", FIPS: "37167", Region: "Southwest", COG: "Centralina"},
+	{State: "NC", ID: 85, Name: "STOKES", FIPS: "37169", Region: "Piedmont-Triad", COG: "Piedmont Triad", MSA: "Winston-Salem"},
+	{State: "NC", ID: 86, Name: "SURRY", FIPS: "37171", Region: "Piedmont-Triad", COG: "Piedmont Triad"},
+	{State: "NC", ID: 87, Name: "SWAIN", FIPS: "37173", Region: "Western", COG: "Southwestern Commission"},
+	{State: "NC", ID: 88, Name: "TRANSYLVANIA", FIPS: "37175", Region: "Western", COG: "Land of Sky"},
+	{State: "NC", ID: 89, Name: "TYRRELL", FIPS: "37177", Region: "Northeast", COG: "Albemarle"},
+	{State: "NC", ID: 90, Name: "UNION", FIPS: "37179", Region: "Southwest", COG: "Centralina", MSA: "Charlotte-Concord-Gastonia"},
+	{State: "NC", ID: 91, Name: "VANCE", FIPS: "37181", Region: "North Central", COG: "Kerr-Tar"},
+	{State: "NC", ID: 92, Name: "WAKE", FIPS: "37183", Region: "North Central", COG: "Triangle J", MSA: "Raleigh-Cary"},
+	{State: "NC", ID: 93, Name: "WARREN", FIPS: "37185", Region: "North Central", COG: "Kerr-Tar"},
+	{State: "NC", ID: 94, Name: "WASHINGTON", FIPS: "37187", Region: "Northeast", COG: "Albemarle"},
+	{State: "NC", ID: 95, Name: "WATAUGA", FIPS: "37189", Region: "Northwest", COG: "High Country"},
+	{State: "NC", ID: 96, Name: "WAYNE", FIPS: "37191", Region: "Southeast", COG: "Eastern Carolina", MSA: "Goldsboro"},
+	{State: "NC", ID: 97, Name: "WILKES", FIPS: "37193", Region: "Northwest", COG: "High Country"},
+	{State: "NC", ID: 98, Name: "WILSON", FIPS: "37195", Region: "North Central", COG: "Upper Coastal Plain"},
+	{State: "NC", ID: 99, Name: "YADKIN", FIPS: "37197", Region: "Piedmont-Triad", COG: "Piedmont Triad", MSA: "Winston-Salem"},
+	{State: "NC", ID: 100, Name: "YANCEY", FIPS: "37199", Region: "Western", COG: "High Country"},
+}
+
+// WACounties lists WA's counties in county number order
+var WACounties = []CountyInfo{
+	{State: "WA", ID: 1, Code: "AD", Name: "ADAMS", FIPS: "53001"},
+	{State: "WA", ID: 2, Code: "AS", Name: "ASOTIN", FIPS: "53003"},
+	{State: "WA", ID: 3, Code: "BE", Name: "BENTON", FIPS: "53005"},
+	{State: "WA", ID: 4, Code: "CH", Name: "CHELAN", FIPS: "53007"},
+	{State: "WA", ID: 5, Code: "CM", Name: "CLALLAM", FIPS: "53009"},
+	{State: "WA", ID: 6, Code: "CR", Name: "CLARK", FIPS: "53011"},
+	{State: "WA", ID: 7, Code: "CU", Name: "COLUMBIA", FIPS: "53013"},
+	{State: "WA", ID: 8, Code: "CZ", Name: "COWLITZ", FIPS: "53015"},
+	{State: "WA", ID: 9, Code: "DG", Name: "DOUGLAS", FIPS: "53017"},
+	{State: "WA", ID: 10, Code: "FE", Name: "FERRY", FIPS: "53019"},
+	{State: "WA", ID: 11, Code: "FR", Name: "FRANKLIN", FIPS: "53021"},
+	{State: "WA", ID: 12, Code: "GA", Name: "GARFIELD", FIPS: "53023"},
+	{State: "WA", ID: 13, Code: "GR", Name: "GRANT", FIPS: "53025"},
+	{State: "WA", ID: 14, Code: "GY", Name: "GRAYS HARBOR", FIPS: "53027"},
+	{State: "WA", ID: 15, Code: "IS", Name: "ISLAND", FIPS: "53029"},
+	{State: "WA", ID: 16, Code: "JE", Name: "JEFFERSON", FIPS: "53031"},
+	{State: "WA", ID: 17, Code: "KI", Name: "KING", FIPS: "53033"},
+	{State: "WA", ID: 18, Code: "KP", Name: "KITSAP", FIPS: "53035"},
+	{State: "WA", ID: 19, Code: "KS", Name: "KITTITAS", FIPS: "53037"},
+	{State: "WA", ID: 20, Code: "KT", Name: "KLICKITAT", FIPS: "53039"},
+	{State: "WA", ID: 21, Code: "LE", Name: "LEWIS", FIPS: "53041"},
+	{State: "WA", ID: 22, Code: "LI", Name: "LINCOLN", FIPS: "53043"},
+	{State: "WA", ID: 23, Code: "MA", Name: "MASON", FIPS: "53045"},
+	{State: "WA", ID: 24, Code: "OK", Name: "OKANOGAN", FIPS: "53047"},
+	{State: "WA", ID: 25, Code: "PA", Name: "PACIFIC", FIPS: "53049"},
+	{State: "WA", ID: 26, Code: "PE", Name: "PEND OREILLE", FIPS: "53051"},
+	{State: "WA", ID: 27, Code: "PI", Name: "PIERCE", FIPS: "53053"},
+	{State: "WA", ID: 28, Code: "SJ", Name: "SAN JUAN", FIPS: "53055"},
+	{State: "WA", ID: 29, Code: "SK", Name: "SKAGIT", FIPS: "53057"},
+	{State: "WA", ID: 30, Code: "SM", Name: "SKAMANIA", FIPS: "53059"},
+	{State: "WA", ID: 31, Code: "SN", Name: "SNOHOMISH", FIPS: "53061"},
+	{State: "WA", ID: 32, Code: "SP", Name: "SPOKANE", FIPS: "53063"},
+	{State: "WA", ID: 33, Code: "ST", Name: "STEVENS", FIPS: "53065"},
+	{State: "WA", ID: 34, Code: "TH", Name: "THURSTON", FIPS: "53067"},
+	{State: "WA", ID: 35, Code: "WK", Name: "WAHKIAKUM", FIPS: "53069"},
+	{State: "WA", ID: 36, Code: "WL", Name: "WALLA WALLA", FIPS: "53071"},
+	{State: "WA", ID: 37, Code: "WM", Name: "WHATCOM", FIPS: "53073"},
+	{State: "WA", ID: 38, Code: "WT", Name: "WHITMAN", FIPS: "53075"},
+	{State: "WA", ID: 39, Code: "YA", Name: "YAKIMA", FIPS: "53077"},
+}
+
+// stateCounties maps a postal code onto its reference table
+var stateCounties = map[string][]CountyInfo{
+	"NC": NCCounties,
+	"WA": WACounties,
+}
+
+// countyKey folds a county name or code for matching: case, spaces and punctuation don't
+// count, so NEWHANOVER, New Hanover and NEW HANOVER are the same county
+func countyKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, name)
+}
+
+// ShortName is the county's name run together, as Counties and the older outputs spell it:
+// NEWHANOVER
+func (county CountyInfo) ShortName() string {
+	return strings.Replace(county.Name, " ", "", -1)
+}
+
+// countyNames lists the short names of counties by ID, with nothing at ID 0
+func countyNames(counties []CountyInfo) []string {
+	names := make([]string, len(counties)+1)
+	for _, county := range counties {
+		names[county.ID] = county.ShortName()
+	}
+	return names
+}
+
+// CountyByID finds a county by the state's county number
+func CountyByID(state string, id int) (CountyInfo, bool) {
+	counties := stateCounties[strings.ToUpper(state)]
+	if id < 1 || id > len(counties) {
+		return CountyInfo{}, false
+	}
+	return counties[id-1], true
+}
+
+// CountyByName finds a county by name; see countyKey for what counts as the same name
+func CountyByName(state string, name string) (CountyInfo, bool) {
+	key := countyKey(name)
+	for _, county := range stateCounties[strings.ToUpper(state)] {
+		if countyKey(county.Name) == key {
+			return county, true
+		}
+	}
+	return CountyInfo{}, false
+}
+
+// CountyByFIPS finds a county by its five-digit FIPS code; leading zeros may be left off
+func CountyByFIPS(fips string) (CountyInfo, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(fips))
+	if err != nil {
+		return CountyInfo{}, false
+	}
+	for _, counties := range stateCounties {
+		for _, county := range counties {
+			if c, _ := strconv.Atoi(county.FIPS); c == n {
+				return county, true
+			}
+		}
+	}
+	return CountyInfo{}, false
+}
+
+// LookupCounty finds a county by whatever a voter file keeps in its county column: the ID,
+// the county code, the FIPS code or the name
+func LookupCounty(state string, val string) (CountyInfo, bool) {
+	val = strings.TrimSpace(val)
+	if id, err := strconv.Atoi(val); err == nil {
+		if len(val) == 5 {
+			return CountyByFIPS(val)
+		}
+		return CountyByID(state, id)
+	}
+	key := countyKey(val)
+	for _, county := range stateCounties[strings.ToUpper(state)] {
+		if county.Code != "" && county.Code == key {
+			return county, true
+		}
+	}
+	return CountyByName(state, val)
+}
+
+// County finds the county of a split record, by the layout's County_id column or else its
+// County_desc, in the reference tables
+func (config *HciConfig) County(pieces []string) (CountyInfo, bool) {
+	if col, ok := config.Fields["County_id"]; ok {
+		if val := strings.TrimSpace(column(pieces, col)); val != "" {
+			return LookupCounty(config.StateAbbrev, val)
+		}
+	}
+	if col, ok := config.Fields["County_desc"]; ok {
+		return LookupCounty(config.StateAbbrev, column(pieces, col))
+	}
+	return CountyInfo{}, false
+}
+
+// LoadCentroids reads the centroids of counties, keyed by FIPS code, from a Census Gazetteer
+// counties file: tab-separated, with GEOID, INTPTLAT and INTPTLONG columns
+func LoadCentroids(path string) (map[string]Point, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	cols := map[string]int{}
+	for i, name := range strings.Split(lines[0], "\t") {
+		cols[strings.TrimSpace(name)] = i
+	}
+	geoid, okID := cols["GEOID"]
+	lat, okLat := cols["INTPTLAT"]
+	lon, okLon := cols["INTPTLONG"]
+	if !okID || !okLat || !okLon {
+		return nil, fmt.Errorf("%s isn't a Gazetteer counties file: it needs GEOID, INTPTLAT and INTPTLONG columns", path)
+	}
+	centroids := make(map[string]Point, len(lines)-1)
+	for n, line := range lines[1:] {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		var p Point
+		var latErr, lonErr error
+		p.Lat, latErr = strconv.ParseFloat(strings.TrimSpace(column(fields, lat)), 64)
+		p.Lon, lonErr = strconv.ParseFloat(strings.TrimSpace(column(fields, lon)), 64)
+		if latErr != nil || lonErr != nil {
+			return nil, fmt.Errorf("%s line %d: bad coordinates", path, n+2)
+		}
+		centroids[strings.TrimSpace(column(fields, geoid))] = p
+	}
+	return centroids, nil
+}
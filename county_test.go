@@ -0,0 +1,105 @@
+package hcip2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCountyTables(t *testing.T) {
+	for state, counties := range stateCounties {
+		fips := make(map[string]bool)
+		for i, county := range counties {
+			if county.ID != i+1 || county.State != state {
+				t.Errorf("%s county %d is %+v", state, i+1, county)
+			}
+			if len(county.FIPS) != 5 || fips[county.FIPS] {
+				t.Errorf("%s has a bad or repeated FIPS code %q", county.Name, county.FIPS)
+			}
+			fips[county.FIPS] = true
+		}
+	}
+	if len(NCCounties) != 100 || len(WACounties) != 39 {
+		t.Errorf("%d NC counties and %d WA counties", len(NCCounties), len(WACounties))
+	}
+}
+
+func TestCounties(t *testing.T) {
+	if len(Counties) != 101 || Counties[0] != "" || Counties[1] != "ALAMANCE" || Counties[65] != "NEWHANOVER" || Counties[100] != "YANCEY" {
+		t.Errorf("Counties has %d names: %q", len(Counties), Counties)
+	}
+}
+
+func TestLookupCounty(t *testing.T) {
+	tests := []struct {
+		state string
+		val   string
+		want  string // the FIPS code of the county found, or "" for none
+	}{
+		{"NC", "65", "37129"},
+		{"nc", " 92 ", "37183"},
+		{"NC", "0", ""},
+		{"NC", "101", ""},
+		{"NC", "NEWHANOVER", "37129"},
+		{"NC", "New Hanover", "37129"},
+		{"NC", "NEW HANOVER", "37129"},
+		{"NC", "Mc Dowell", "37117"},
+		{"NC", "37183", "37183"},
+		{"NC", "53033", "53033"}, // a FIPS code says which state it's in
+		{"NC", "NOWHERE", ""},
+		{"WA", "KI", "53033"},
+		{"WA", "ki", "53033"},
+		{"WA", "17", "53033"},
+		{"WA", "Walla Walla", "53071"},
+		{"WA", "WAKE", ""},
+		{"OH", "1", ""},
+	}
+	for _, test := range tests {
+		county, ok := LookupCounty(test.state, test.val)
+		if ok != (test.want != "") || county.FIPS != test.want {
+			t.Errorf("LookupCounty(%q, %q) = %+v, %v, want %s", test.state, test.val, county, ok, test.want)
+		}
+	}
+	if county, ok := CountyByFIPS("053033"); !ok || county.Name != "KING" {
+		t.Errorf("CountyByFIPS(053033) = %+v, %v", county, ok)
+	}
+	if county, _ := CountyByID("NC", 65); county.ShortName() != "NEWHANOVER" || county.Region != "Southeast" {
+		t.Errorf("CountyByID(NC, 65) = %+v", county)
+	}
+}
+
+func TestConfigCounty(t *testing.T) {
+	pieces := make([]string, len(WAColumns))
+	pieces[County] = "KI"
+	if county, ok := WA.County(pieces); !ok || county.ID != 17 {
+		t.Errorf("WA.County = %+v, %v", county, ok)
+	}
+
+	pieces = make([]string, len(NCColumns))
+	pieces[County_id] = "92"
+	pieces[County_desc] = "DURHAM" // the ID wins
+	if county, ok := NC.County(pieces); !ok || county.Name != "WAKE" {
+		t.Errorf("NC.County = %+v, %v", county, ok)
+	}
+	pieces[County_id] = ""
+	if county, ok := NC.County(pieces); !ok || county.Name != "DURHAM" {
+		t.Errorf("NC.County without an ID = %+v, %v", county, ok)
+	}
+}
+
+func TestLoadCentroids(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "counties.txt", "USPS\tGEOID\tNAME\tINTPTLAT\tINTPTLONG \r\nNC\t37183\tWake County\t35.789\t-78.650\r\n")
+
+	centroids, err := LoadCentroids(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]Point{"37183": {Lat: 35.789, Lon: -78.65}}; !reflect.DeepEqual(centroids, want) {
+		t.Errorf("LoadCentroids = %v, want %v", centroids, want)
+	}
+
+	writeFile(t, dir, "counties.txt", "GEOID\tLAT\tLON\n37183\t35.789\t-78.650\n")
+	if _, err := LoadCentroids(path); err == nil {
+		t.Error("loaded a file without the Gazetteer columns")
+	}
+}
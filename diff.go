@@ -0,0 +1,152 @@
+package hcip2
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is the kind of change DiffSnapshots found for a voter
+type ChangeKind string
+
+const (
+	NewRegistration ChangeKind = "new"      // in the new snapshot only
+	Removal         ChangeKind = "removed"  // gone from the new snapshot, or newly given a removed status
+	Move            ChangeKind = "moved"    // residential address changed
+	PartyChange     ChangeKind = "party"    // party affiliation changed
+	NameChange      ChangeKind = "name"     // last, first, middle name or suffix changed
+	PrecinctChange  ChangeKind = "precinct" // precinct reassigned, with or without a move
+)
+
+// ChangeKinds lists every ChangeKind, in the order summaries report them
+var ChangeKinds = []ChangeKind{NewRegistration, Removal, Move, PartyChange, NameChange, PrecinctChange}
+
+// Change is one difference between two snapshots for one voter
+type Change struct {
+	Kind         ChangeKind `json:"kind"`
+	StateVoterID string     `json:"state_voter_id"`
+	County       string     `json:"county"` // the voter's county in the new snapshot, or the old one for removals
+	Old          string     `json:"old,omitempty"`
+	New          string     `json:"new,omitempty"`
+	Reason       string     `json:"reason,omitempty"` // Reason_cd, for removals
+}
+
+// snapshotEntry is what DiffSnapshots keeps of each voter in the old snapshot; a whole Voter
+// for every voter in the state takes too much memory
+type snapshotEntry struct {
+	allowed  bool // by the status policy
+	removed  bool
+	status   string
+	reason   string
+	county   string
+	party    string
+	name     string
+	address  string
+	precinct string
+}
+
+func newSnapshotEntry(voter *Voter, policy StatusPolicy) *snapshotEntry {
+	county := voter.County_desc
+	if county == "" && voter.County_id != 0 {
+		county = strconv.Itoa(voter.County_id)
+	}
+	status := ""
+	if voter.Status_cd != 0 {
+		status = string(voter.Status_cd)
+	}
+	return &snapshotEntry{
+		allowed:  policy.Allows(voter.Status, voter.Confidential),
+		removed:  voter.Status == StatusRemoved,
+		status:   status,
+		reason:   voter.Reason_cd,
+		county:   county,
+		party:    voter.Party_cd,
+		name:     strings.Join(strings.Fields(strings.Join([]string{voter.First_name, voter.Midl_name, voter.Last_name, voter.Name_sufx_cd}, " ")), " "),
+		address:  voter.Address.String(),
+		precinct: strings.TrimSpace(county + " " + voter.Precinct_abbrv),
+	}
+}
+
+// DiffSnapshots compares two voter snapshots in the same layout, keyed on state voter ID, and
+// passes every change through fn.  The old snapshot is held in memory and the new one streamed.
+// Both are read whatever the voters' status, so that a voter who turns inactive or confidential
+// isn't taken for a removal; the status policy then decides which changes are passed on.  Removals
+// go by the voter's old record, and the other changes need the policy to allow both.
+func DiffSnapshots(oldPath string, newPath string, config HciConfig, fn func(Change) error) error {
+	policy := config.statusPolicy()
+	config.Policy = StatusPolicy{Active: true, Inactive: true, Removed: true, Denied: true, Confidential: true}
+
+	old := make(map[string]*snapshotEntry)
+	err := ScanVoters(oldPath, config, func(voter *Voter) error {
+		old[voter.StateVoterID] = newSnapshotEntry(voter, policy)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = ScanVoters(newPath, config, func(voter *Voter) error {
+		cur := newSnapshotEntry(voter, policy)
+		prev, ok := old[voter.StateVoterID]
+		delete(old, voter.StateVoterID)
+		change := func(kind ChangeKind, from string, to string) Change {
+			return Change{Kind: kind, StateVoterID: voter.StateVoterID, County: cur.county, Old: from, New: to}
+		}
+
+		if !ok && cur.removed {
+			return nil // registered and removed again between the snapshots
+		}
+		if !ok || (prev.removed && !cur.removed) {
+			if !cur.allowed {
+				return nil
+			}
+			return fn(change(NewRegistration, "", cur.address))
+		}
+		if cur.removed {
+			if prev.removed || !prev.allowed {
+				return nil // removed a while ago, or never let through
+			}
+			removal := change(Removal, prev.status, cur.status)
+			removal.Reason = cur.reason
+			return fn(removal)
+		}
+		if !prev.allowed || !cur.allowed {
+			return nil
+		}
+
+		for _, diff := range []Change{
+			change(Move, prev.address, cur.address),
+			change(PartyChange, prev.party, cur.party),
+			change(NameChange, prev.name, cur.name),
+			change(PrecinctChange, prev.precinct, cur.precinct),
+		} {
+			if diff.Old == diff.New {
+				continue
+			}
+			if err := fn(diff); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// whoever is left has dropped out of the snapshot entirely
+	ids := make([]string, 0, len(old))
+	for id, prev := range old {
+		if prev.allowed && !prev.removed {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		prev := old[id]
+		err := fn(Change{Kind: Removal, StateVoterID: id, County: prev.county, Old: prev.status, Reason: prev.reason})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package hcip2
+
+import (
+	"reflect"
+	"testing"
+)
+
+var diffConfig = HciConfig{
+	Columns:        []string{"id", "status", "reason", "county", "party", "first", "last", "street", "city", "zip", "precinct"},
+	STATE_VOTER_ID: 0,
+	Road:           []int{7},
+	CITY:           8,
+	STATE:          -1,
+	ZIP:            9,
+	StateAbbrev:    "NC",
+	Delimiter:      "|",
+	Fields: map[string]int{"Status_cd": 1, "Reason_cd": 2, "County_desc": 3, "Party_cd": 4,
+		"First_name": 5, "Last_name": 6, "Precinct_abbrv": 10},
+	StatusCodes: map[string]Status{"A": StatusActive, "R": StatusRemoved},
+	Policy:      StatusPolicy{Active: true, Inactive: true, Removed: true},
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	header := "id|status|reason|county|party|first|last|street|city|zip|precinct\n"
+	oldPath := writeFile(t, dir, "old.txt", header+
+		"1|A||WAKE|DEM|ANN|LEE|1 MAIN ST|RALEIGH|27601|01\n"+
+		"2|A||WAKE|REP|BOB|KAY|2 OAK ST|RALEIGH|27601|01\n"+
+		"3|A||WAKE|UNA|CY|DOE|3 ELM ST|CARY|27511|02\n"+
+		"4|R|MV|WAKE|UNA|DEE|FOX|4 ASH ST|CARY|27511|02\n"+
+		"5|A||DURHAM|DEM|ED|GUY|5 FIR ST|DURHAM|27701|07\n")
+	newPath := writeFile(t, dir, "new.txt", header+
+		"1|A||WAKE|DEM|ANN|LEE|1 MAIN ST|RALEIGH|27601|01\n"+
+		"2|A||WAKE|UNA|BOB|KAY|9 Pine Street|RALEIGH|27601|03\n"+
+		"3|R|DC|WAKE|UNA|CY|DOE|3 ELM ST|CARY|27511|02\n"+
+		"4|A||WAKE|UNA|DEE|FOX|4 ASH ST|CARY|27511|02\n"+
+		"6|A||WAKE|LIB|FAY|HO|6 BAY ST|APEX|27502|04\n"+
+		"7|R|DC|WAKE|LIB|GIL|IVY|7 BAY ST|APEX|27502|04\n")
+
+	var got []Change
+	err := DiffSnapshots(oldPath, newPath, diffConfig, func(c Change) error {
+		got = append(got, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Kind: Move, StateVoterID: "2", County: "WAKE", Old: "2 OAK ST, RALEIGH NC 27601", New: "9 PINE ST, RALEIGH NC 27601"},
+		{Kind: PartyChange, StateVoterID: "2", County: "WAKE", Old: "REP", New: "UNA"},
+		{Kind: PrecinctChange, StateVoterID: "2", County: "WAKE", Old: "WAKE 01", New: "WAKE 03"},
+		{Kind: Removal, StateVoterID: "3", County: "WAKE", Old: "A", New: "R", Reason: "DC"},
+		{Kind: NewRegistration, StateVoterID: "4", County: "WAKE", New: "4 ASH ST, CARY NC 27511"},
+		{Kind: NewRegistration, StateVoterID: "6", County: "WAKE", New: "6 BAY ST, APEX NC 27502"},
+		{Kind: Removal, StateVoterID: "5", County: "DURHAM", Old: "A"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes:\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDiffSnapshotsPolicy(t *testing.T) {
+	dir := t.TempDir()
+	config := diffConfig
+	config.Columns = append(append([]string{}, diffConfig.Columns...), "confidential")
+	config.Confidential = func(pieces []string) bool { return column(pieces, 11) == "Y" }
+	config.StatusCodes = map[string]Status{"A": StatusActive, "I": StatusInactive, "R": StatusRemoved}
+	header := "id|status|reason|county|party|first|last|street|city|zip|precinct|confidential\n"
+	oldPath := writeFile(t, dir, "old.txt", header+
+		"1|A||WAKE|DEM|ANN|LEE|1 MAIN ST|RALEIGH|27601|01|N\n"+
+		"2|A||WAKE|REP|BOB|KAY|2 OAK ST|RALEIGH|27601|01|Y\n"+
+		"3|A||WAKE|UNA|CY|DOE|3 ELM ST|CARY|27511|02|Y\n"+
+		"4|A||WAKE|UNA|DEE|FOX|4 ASH ST|CARY|27511|02|N\n"+
+		"5|A||WAKE|LIB|ED|GUY|5 FIR ST|CARY|27511|02|N\n")
+	newPath := writeFile(t, dir, "new.txt", header+
+		"1|A||WAKE|DEM|ANN|LEE|8 HIDDEN LN|RALEIGH|27601|01|Y\n"+
+		"2|A||WAKE|UNA|BOB|KAY|2 OAK ST|RALEIGH|27601|01|N\n"+
+		"3|R|DC|WAKE|UNA|CY|DOE|3 ELM ST|CARY|27511|02|Y\n"+
+		"4|I||WAKE|REP|DEE|FOX|4 ASH ST|CARY|27511|02|N\n"+
+		"5|R|MV|WAKE|LIB|ED|GUY|5 FIR ST|CARY|27511|02|N\n")
+
+	diff := func(policy StatusPolicy) []Change {
+		config.Policy = policy
+		var got []Change
+		err := DiffSnapshots(oldPath, newPath, config, func(c Change) error {
+			got = append(got, c)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	// becoming confidential or inactive isn't a removal, and a voter the policy leaves out in
+	// either snapshot has nothing else to report
+	want := []Change{
+		{Kind: Removal, StateVoterID: "5", County: "WAKE", Old: "A", New: "R", Reason: "MV"},
+	}
+	if got := diff(StatusPolicy{Active: true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("active only:\n%+v\nwant\n%+v", got, want)
+	}
+
+	want = []Change{
+		{Kind: Move, StateVoterID: "1", County: "WAKE", Old: "1 MAIN ST, RALEIGH NC 27601", New: "8 HIDDEN LN, RALEIGH NC 27601"},
+		{Kind: PartyChange, StateVoterID: "2", County: "WAKE", Old: "REP", New: "UNA"},
+		{Kind: Removal, StateVoterID: "3", County: "WAKE", Old: "A", New: "R", Reason: "DC"},
+		{Kind: PartyChange, StateVoterID: "4", County: "WAKE", Old: "UNA", New: "REP"},
+		{Kind: Removal, StateVoterID: "5", County: "WAKE", Old: "A", New: "R", Reason: "MV"},
+	}
+	if got := diff(StatusPolicy{Active: true, Inactive: true, Confidential: true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("with confidential voters:\n%+v\nwant\n%+v", got, want)
+	}
+}
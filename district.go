@@ -0,0 +1,109 @@
+package hcip2
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DistrictType is a level of district a voter is assigned to, whatever the state calls it
+type DistrictType int
+
+const (
+	DistrictPrecinct DistrictType = iota
+	DistrictVTD                   // voter tabulation district
+	DistrictCongressional
+	DistrictStateSenate // WA elects both chambers from its legislative districts
+	DistrictStateHouse
+	DistrictSuperiorCourt
+	DistrictJudicial
+	DistrictProsecutorial
+	DistrictCountyCommission
+	DistrictSchool
+	DistrictMunicipality
+	DistrictWard
+	DistrictMunicipal // a municipality's own council districts
+	DistrictTownship
+	DistrictFire
+	DistrictWater
+	DistrictSewer
+	DistrictSanitation
+	DistrictRescue
+	numDistrictTypes
+)
+
+// districtNames are the names the -district flags, filters and layout files use
+var districtNames = [numDistrictTypes]string{
+	"precinct",
+	"vtd",
+	"congressional",
+	"state_senate",
+	"state_house",
+	"superior_court",
+	"judicial",
+	"prosecutorial",
+	"county_commission",
+	"school",
+	"municipality",
+	"ward",
+	"municipal_district",
+	"township",
+	"fire",
+	"water",
+	"sewer",
+	"sanitation",
+	"rescue",
+}
+
+func (t DistrictType) String() string {
+	if t < 0 || t >= numDistrictTypes {
+		return fmt.Sprintf("DistrictType(%d)", int(t))
+	}
+	return districtNames[t]
+}
+
+// ParseDistrictType looks up a district type by name; case doesn't matter, and a space or -
+// matches _
+func ParseDistrictType(name string) (DistrictType, error) {
+	norm := strings.ToLower(strings.TrimSpace(name))
+	norm = strings.NewReplacer(" ", "_", "-", "_").Replace(norm)
+	for t, n := range districtNames {
+		if n == norm {
+			return DistrictType(t), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown district type %q; use one of %s", name, strings.Join(districtNames[:], ", "))
+}
+
+// District is one of a voter's district assignments
+type District struct {
+	Code string // the abbreviation or number, e.g. "13"
+	Name string // the description, if the layout has one, e.g. "13TH CONGRESSIONAL"
+}
+
+// Label is the district's code, or its name if it has no code
+func (d District) Label() string {
+	if d.Code != "" {
+		return d.Code
+	}
+	return d.Name
+}
+
+// Districts holds a voter's assignment at every district level, indexed by DistrictType; the
+// levels a layout doesn't have are left blank
+type Districts [numDistrictTypes]District
+
+// DistrictColumns are where a layout keeps one level of district.  Name is -1 for the layouts
+// that only have codes.
+type DistrictColumns struct {
+	Code int
+	Name int
+}
+
+// districts reads every district level the layout has out of a split record
+func (config *HciConfig) districts(pieces []string) Districts {
+	var districts Districts
+	for t, cols := range config.Districts {
+		districts[t] = District{Code: column(pieces, cols.Code), Name: column(pieces, cols.Name)}
+	}
+	return districts
+}
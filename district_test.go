@@ -0,0 +1,110 @@
+package hcip2
+
+import (
+	"testing"
+)
+
+func TestParseDistrictType(t *testing.T) {
+	for i := DistrictType(0); i < numDistrictTypes; i++ {
+		if got, err := ParseDistrictType(i.String()); err != nil || got != i {
+			t.Errorf("ParseDistrictType(%q) = %v, %v", i.String(), got, err)
+		}
+	}
+	tests := []struct {
+		name string
+		want DistrictType
+	}{
+		{"State House", DistrictStateHouse},
+		{" state-senate ", DistrictStateSenate},
+		{"VTD", DistrictVTD},
+		{"municipal_district", DistrictMunicipal},
+	}
+	for _, test := range tests {
+		if got, err := ParseDistrictType(test.name); err != nil || got != test.want {
+			t.Errorf("ParseDistrictType(%q) = %v, %v, want %v", test.name, got, err, test.want)
+		}
+	}
+	if _, err := ParseDistrictType("statehouse"); err == nil {
+		t.Error("ParseDistrictType(statehouse) should have failed")
+	}
+	if s := DistrictType(-1).String(); s != "DistrictType(-1)" {
+		t.Errorf("DistrictType(-1).String() = %q", s)
+	}
+}
+
+func TestDistrictLabel(t *testing.T) {
+	tests := []struct {
+		district District
+		want     string
+	}{
+		{District{Code: "13", Name: "13TH CONGRESSIONAL"}, "13"},
+		{District{Name: "RALEIGH"}, "RALEIGH"},
+		{District{}, ""},
+	}
+	for _, test := range tests {
+		if got := test.district.Label(); got != test.want {
+			t.Errorf("%+v.Label() = %q, want %q", test.district, got, test.want)
+		}
+	}
+}
+
+func TestParseVoterDistricts(t *testing.T) {
+	pieces := make([]string, len(NCColumns))
+	pieces[Precinct_abbrv], pieces[Precinct_desc] = "01-07", "PRECINCT 01-07"
+	pieces[Cong_dist_abbrv], pieces[Cong_dist_desc] = "2", "2ND CONGRESSIONAL"
+	voter, err := ParseVoter(pieces, NC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := voter.Districts[DistrictCongressional]; d != (District{"2", "2ND CONGRESSIONAL"}) {
+		t.Errorf("NC congressional district %+v", d)
+	}
+	if d := voter.Districts[DistrictPrecinct]; d.Label() != "01-07" {
+		t.Errorf("NC precinct %+v", d)
+	}
+
+	pieces = make([]string, len(WAColumns))
+	pieces[LegDistrict], pieces[CongDistrict] = "43", "7"
+	voter, err = ParseVoter(pieces, WA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// WA elects both chambers from its legislative districts
+	senate, house := voter.Districts[DistrictStateSenate], voter.Districts[DistrictStateHouse]
+	if senate != (District{Code: "43"}) || house != senate {
+		t.Errorf("WA senate %+v, house %+v", senate, house)
+	}
+	if d := voter.Districts[DistrictSchool]; d != (District{}) {
+		t.Errorf("WA has no school districts, but got %+v", d)
+	}
+}
+
+func TestLayoutDistricts(t *testing.T) {
+	layout := Layout{
+		Columns:   []string{"id", "city", "zip", "hd", "hd_name", "cd"},
+		City:      "city",
+		Zip:       "zip",
+		VoterID:   "id",
+		Districts: map[string][]string{"state house": {"hd", "hd_name"}, "congressional": {"cd"}},
+	}
+	config, err := layout.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	districts := config.districts([]string{"1", "APEX", "27502", "37", "HOUSE 37", "2"})
+	if districts[DistrictStateHouse] != (District{"37", "HOUSE 37"}) || districts[DistrictCongressional] != (District{Code: "2"}) {
+		t.Errorf("districts %+v", districts)
+	}
+
+	for _, bad := range []map[string][]string{
+		{"assembly": {"hd"}},
+		{"state_house": {}},
+		{"state_house": {"hd", "hd_name", "cd"}},
+		{"state_house": {"nope"}},
+	} {
+		layout.Districts = bad
+		if _, err := layout.Config(); err == nil {
+			t.Errorf("layout with districts %v should have failed", bad)
+		}
+	}
+}
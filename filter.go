@@ -0,0 +1,400 @@
+package hcip2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// A filter expression keeps the records it's true for.  It compares columns, named as in the
+// layout's header, by their Voter field or by district type, against quoted strings or numbers:
+//
+//	Status_cd in ("A", "I") && County_id == 92
+//	!(Party_cd == "UNA") && Age >= 18 && Age < 30
+//	`Voter Status` not in ("D")
+//	congressional == 13 && state_house in ("036", "041")
+//
+// Comparisons are numeric when the literal is a number and the column parses as one, and
+// string comparisons otherwise.  Names with spaces can be written with _ or in backquotes, and
+// Age works even in layouts that only have a birth date.  && binds tighter than ||, and ! negates.
+
+// CompileFilter compiles a filter expression against config's columns, returning the same test
+// for both split-string and split-byte records
+func CompileFilter(expr string, config *HciConfig) (func([]string) bool, func([][]byte) bool, error) {
+	p := &filterParser{config: config}
+	if err := p.lex(expr); err != nil {
+		return nil, nil, fmt.Errorf("filter %q: %s", expr, err)
+	}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %s", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("filter %q: %s", expr, err)
+	}
+
+	filterStr := func(pieces []string) bool {
+		return root.eval(func(col int) string { return column(pieces, col) })
+	}
+	filterBytes := func(pieces [][]byte) bool {
+		return root.eval(func(col int) string {
+			if col < 0 || col >= len(pieces) {
+				return ""
+			}
+			return cleanField(string(pieces[col]))
+		})
+	}
+	return filterStr, filterBytes, nil
+}
+
+// AddFilter compiles a filter expression and narrows config's FilterStr and FilterBytes with it
+func (config *HciConfig) AddFilter(expr string) error {
+	filterStr, filterBytes, err := CompileFilter(expr, config)
+	if err != nil {
+		return err
+	}
+	if prev := config.FilterStr; prev != nil {
+		config.FilterStr = func(pieces []string) bool { return prev(pieces) && filterStr(pieces) }
+	} else {
+		config.FilterStr = filterStr
+	}
+	if prev := config.FilterBytes; prev != nil {
+		config.FilterBytes = func(pieces [][]byte) bool { return prev(pieces) && filterBytes(pieces) }
+	} else {
+		config.FilterBytes = filterBytes
+	}
+	config.FilterExprs = append(config.FilterExprs, expr)
+	return nil
+}
+
+// ColumnIndex finds a column by its header name, by the Voter field it's mapped to, or by a
+// district type (see ParseDistrictType), which means that district's code.  Case doesn't
+// matter, and _ matches a space.
+func (config *HciConfig) ColumnIndex(name string) (int, bool) {
+	norm := func(s string) string { return strings.ToLower(strings.Replace(s, " ", "_", -1)) }
+	for i, col := range config.Columns {
+		if norm(col) == norm(name) {
+			return i, true
+		}
+	}
+	for field, col := range config.Fields {
+		if norm(field) == norm(name) {
+			return col, true
+		}
+	}
+	if t, err := ParseDistrictType(name); err == nil {
+		if cols, ok := config.Districts[t]; ok {
+			return cols.Code, true
+		}
+	}
+	return -1, false
+}
+
+// filterNode is one node of a compiled filter; get returns the value of a column
+type filterNode interface {
+	eval(get func(col int) string) bool
+}
+
+type andNode struct{ left, right filterNode }
+type orNode struct{ left, right filterNode }
+type notNode struct{ node filterNode }
+
+func (n andNode) eval(get func(int) string) bool { return n.left.eval(get) && n.right.eval(get) }
+func (n orNode) eval(get func(int) string) bool  { return n.left.eval(get) || n.right.eval(get) }
+func (n notNode) eval(get func(int) string) bool { return !n.node.eval(get) }
+
+// filterValue looks up the value a comparison tests, usually just a column
+type filterValue func(get func(col int) string) string
+
+// inNode tests a value against a list
+type inNode struct {
+	value  filterValue
+	values map[string]bool
+	keep   bool // false for "not in"
+}
+
+func (n inNode) eval(get func(int) string) bool {
+	return n.values[n.value(get)] == n.keep
+}
+
+// cmpNode compares a value with a literal
+type cmpNode struct {
+	value filterValue
+	op    string
+	val   string
+	num   float64
+	isNum bool
+}
+
+func (n cmpNode) eval(get func(int) string) bool {
+	val := n.value(get)
+	cmp := strings.Compare(val, n.val)
+	if n.isNum {
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return n.op == "!=" // a blank or non-numeric value equals no number
+		}
+		switch {
+		case f < n.num:
+			cmp = -1
+		case f > n.num:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	}
+	switch n.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	}
+	return cmp >= 0
+}
+
+type filterTokenKind int
+
+const (
+	tokIdent filterTokenKind = iota
+	tokString
+	tokNumber
+	tokOp
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+}
+
+type filterParser struct {
+	config *HciConfig
+	tokens []filterToken
+	pos    int
+}
+
+// lex splits an expression into tokens
+func (p *filterParser) lex(expr string) error {
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			var val strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				val.WriteRune(runes[j])
+			}
+			if j == len(runes) {
+				return fmt.Errorf("unterminated string at %d", i)
+			}
+			p.tokens = append(p.tokens, filterToken{tokString, val.String()})
+			i = j + 1
+		case r == '`':
+			j := i + 1
+			for j < len(runes) && runes[j] != '`' {
+				j++
+			}
+			if j == len(runes) {
+				return fmt.Errorf("unterminated column name at %d", i)
+			}
+			p.tokens = append(p.tokens, filterToken{tokIdent, string(runes[i+1 : j])})
+			i = j + 1
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			p.tokens = append(p.tokens, filterToken{tokNumber, string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			p.tokens = append(p.tokens, filterToken{tokIdent, string(runes[i:j])})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", ","} {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return fmt.Errorf("unexpected %c at %d", r, i)
+			}
+			p.tokens = append(p.tokens, filterToken{tokOp, op})
+			i += len(op)
+		}
+	}
+	return nil
+}
+
+func (p *filterParser) peek() filterToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return filterToken{tokOp, "end of filter"}
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// isOp reports whether the next token is the operator or keyword op
+func (p *filterParser) isOp(op string) bool {
+	tok := p.peek()
+	return p.pos < len(p.tokens) && strings.EqualFold(tok.text, op) && (tok.kind == tokOp || tok.kind == tokIdent)
+}
+
+func (p *filterParser) expect(op string) error {
+	if !p.isOp(op) {
+		return fmt.Errorf("expected %s, found %s", op, p.peek().text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.isOp("||") {
+		p.pos++
+		var right filterNode
+		right, err = p.parseAnd()
+		left = orNode{left, right}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.isOp("&&") {
+		p.pos++
+		var right filterNode
+		right, err = p.parseUnary()
+		left = andNode{left, right}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	if p.isOp("!") {
+		p.pos++
+		node, err := p.parseUnary()
+		return notNode{node}, err
+	}
+	if p.isOp("(") {
+		p.pos++
+		node, err := p.parseOr()
+		if err == nil {
+			err = p.expect(")")
+		}
+		return node, err
+	}
+	return p.parseComparison()
+}
+
+// value resolves a column name.  Age can be asked for even when the layout only has a birth
+// date; it's then worked out the way ParseVoter does.
+func (p *filterParser) value(name string) (filterValue, error) {
+	if col, ok := p.config.ColumnIndex(name); ok {
+		return func(get func(int) string) string { return get(col) }, nil
+	}
+	birth, ok := p.config.Fields["Birthdate"]
+	if !strings.EqualFold(name, "age") || !ok {
+		return nil, fmt.Errorf("unknown column %s", name)
+	}
+	format := p.config.DateFormat
+	now := time.Now()
+	return func(get func(int) string) string {
+		val := get(birth)
+		layout := format
+		if len(val) == 4 {
+			layout = "2006"
+		}
+		t, err := time.Parse(layout, val)
+		if err != nil {
+			return ""
+		}
+		return strconv.Itoa(ageOn(t, now))
+	}, nil
+}
+
+// parseComparison parses `column op literal` or `column [not] in (literal, ...)`
+func (p *filterParser) parseComparison() (filterNode, error) {
+	tok := p.next()
+	if tok.kind != tokIdent {
+		return nil, fmt.Errorf("expected a column name, found %s", tok.text)
+	}
+	value, err := p.value(tok.text)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := true
+	if p.isOp("not") {
+		p.pos++
+		keep = false
+		if !p.isOp("in") {
+			return nil, fmt.Errorf("expected in after not, found %s", p.peek().text)
+		}
+	}
+	if p.isOp("in") {
+		p.pos++
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		node := inNode{value: value, values: make(map[string]bool), keep: keep}
+		for {
+			lit := p.next()
+			if lit.kind != tokString && lit.kind != tokNumber {
+				return nil, fmt.Errorf("expected a value in the list for %s, found %s", tok.text, lit.text)
+			}
+			node.values[lit.text] = true
+			if p.isOp(")") {
+				p.pos++
+				return node, nil
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	op := p.next()
+	switch op.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return nil, fmt.Errorf("expected a comparison after %s, found %s", tok.text, op.text)
+	}
+	lit := p.next()
+	node := cmpNode{value: value, op: op.text, val: lit.text}
+	switch lit.kind {
+	case tokNumber:
+		num, err := strconv.ParseFloat(lit.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %s", lit.text)
+		}
+		node.num, node.isNum = num, true
+	case tokString:
+	default:
+		return nil, fmt.Errorf("expected a value after %s %s, found %s", tok.text, op.text, lit.text)
+	}
+	return node, nil
+}
@@ -0,0 +1,100 @@
+package hcip2
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+var filterConfig = HciConfig{
+	Columns:    []string{"id", "status", "county", "party", "Voter Status", "birthdate", "cong"},
+	Fields:     map[string]int{"Status_cd": 1, "County_id": 2, "Party_cd": 3, "Birthdate": 5},
+	Districts:  map[DistrictType]DistrictColumns{DistrictCongressional: {6, -1}},
+	DateFormat: "01/02/2006",
+}
+
+func TestCompileFilter(t *testing.T) {
+	young := "01/01/" + strconv.Itoa(time.Now().Year()-20)
+	record := []string{"7", "A", "92", "UNA", "ACTIVE", young, "13"}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`Status_cd in ("A", "I") && County_id == 92`, true},
+		{`Status_cd in ("A", "I") && County_id == 91`, false},
+		{`status not in ("R", "D")`, true},
+		{`!(Party_cd == "UNA")`, false},
+		{`Party_cd != "UNA" || county >= 90`, true},
+		{`County_id == 92.0`, true},
+		{`County_id < 100`, true},
+		{`county < "100"`, false}, // a string comparison
+		{`Age >= 18 && Age < 30`, true},
+		{`age > 30`, false},
+		{"`Voter Status` == 'ACTIVE'", true},
+		{`voter_status == "ACTIVE"`, true},
+		{`congressional == 13`, true},
+		{`id == 7 && (party == "DEM" || party == "UNA")`, true},
+		{`id == 7 && party == "DEM" || party == "UNA"`, true},
+		{`id == 8 && (party == "DEM" || party == "UNA")`, false},
+		{`Party_cd == "U\"NA"`, false},
+	}
+	for _, tt := range tests {
+		filterStr, filterBytes, err := CompileFilter(tt.expr, &filterConfig)
+		if err != nil {
+			t.Errorf("%s: %s", tt.expr, err)
+			continue
+		}
+		bytes := make([][]byte, len(record))
+		for i, val := range record {
+			bytes[i] = []byte(`"` + val + `" `)
+		}
+		if got := filterStr(record); got != tt.want {
+			t.Errorf("%s is %v, want %v", tt.expr, got, tt.want)
+		}
+		if got := filterBytes(bytes); got != tt.want {
+			t.Errorf("%s is %v on bytes, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	// a blank isn't any number
+	filterStr, _, _ := CompileFilter(`County_id != 92`, &filterConfig)
+	if !filterStr([]string{"7", "A", ""}) {
+		t.Error("a blank county equals 92")
+	}
+}
+
+func TestCompileFilterErrors(t *testing.T) {
+	for _, expr := range []string{
+		`nope == 1`,
+		`Party_cd == `,
+		`Party_cd = "DEM"`,
+		`Party_cd in ("DEM"`,
+		`Party_cd not ("DEM")`,
+		`(Party_cd == "DEM"`,
+		`Party_cd == "DEM" extra`,
+		`Party_cd == "DEM`,
+		"`Party_cd == 1",
+		`Party_cd == 1 $`,
+	} {
+		if _, _, err := CompileFilter(expr, &filterConfig); err == nil {
+			t.Errorf("%s compiled", expr)
+		}
+	}
+}
+
+func TestAddFilterNarrows(t *testing.T) {
+	config := filterConfig
+	config.FilterStr = func(pieces []string) bool { return pieces[1] == "A" }
+	if err := config.AddFilter(`Party_cd == "DEM"`); err != nil {
+		t.Fatal(err)
+	}
+	if config.FilterStr([]string{"1", "A", "", "REP"}) || config.FilterStr([]string{"1", "I", "", "DEM"}) {
+		t.Error("AddFilter widened the filter")
+	}
+	if !config.FilterStr([]string{"1", "A", "", "DEM"}) || !config.FilterBytes([][]byte{nil, nil, nil, []byte("DEM")}) {
+		t.Error("AddFilter dropped a record both filters keep")
+	}
+	if len(config.FilterExprs) != 1 {
+		t.Errorf("FilterExprs = %q", config.FilterExprs)
+	}
+}
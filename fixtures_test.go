@@ -0,0 +1,61 @@
+package hcip2
+
+import "testing"
+
+// TestLoadFixtures loads each committed voter file in testdata, exempt and removed voters too
+func TestLoadFixtures(t *testing.T) {
+	everyone := StatusPolicy{Active: true, Inactive: true, Removed: true, Denied: true, Confidential: true}
+	tests := []struct {
+		state string
+		path  string
+		count int
+		id    string // a voter to check
+		city  string
+		zip   string
+		line  string // its standardized street address
+	}{
+		{"FL", "testdata/fl_voters.txt", 3, "100000002", "TALLAHASSEE", "32304", "1200 W TENNESSEE ST UNIT 3"},
+		{"FL", "testdata/fl_voters.txt", 3, "100000003", "", "", ""},
+		{"GA", "testdata/ga_voters.txt", 3, "01234568", "ATLANTA", "30303", "55 TRINITY AVE SW # 4B"},
+		{"MI", "testdata/mi_voters.txt", 3, "100200301", "EAST LANSING", "48823", "220 GRAND RIVER AVE E APT 7"},
+		{"OH", "testdata/oh_voters.txt", 3, "OH0012345679", "COLUMBUS", "43215", "40 W LONG ST APT 12"},
+		{"PA", "testdata/pa_voters.txt", 3, "012345679-22", "PHILADELPHIA", "19107", "1400 1/2 JOHN F KENNEDY BLVD # 210"},
+	}
+	for _, tt := range tests {
+		config := Configs[tt.state]
+		config.Policy = everyone
+		voters, err := LoadVoters(tt.path, config)
+		if err != nil {
+			t.Errorf("%s: %s", tt.path, err)
+			continue
+		}
+		if len(voters) != tt.count {
+			t.Errorf("%s: loaded %d voters, want %d", tt.path, len(voters), tt.count)
+		}
+		voter, ok := voters[tt.id]
+		if !ok {
+			t.Errorf("%s: no voter %s", tt.path, tt.id)
+			continue
+		}
+		if voter.Res_city_desc != tt.city || voter.Zip_code != tt.zip || voter.Address.Line() != tt.line {
+			t.Errorf("%s: voter %s lives at %q, %q %q; want %q, %q %q", tt.path, tt.id,
+				voter.Address.Line(), voter.Res_city_desc, voter.Zip_code, tt.line, tt.city, tt.zip)
+		}
+		if voter.Address.City != tt.city || voter.Address.Zip != tt.zip {
+			t.Errorf("%s: voter %s's address is in %q %q", tt.path, tt.id, voter.Address.City, voter.Address.Zip)
+		}
+	}
+}
+
+func TestExemptVoterIsConfidential(t *testing.T) {
+	voters, err := LoadVoters("testdata/fl_voters.txt", FL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := voters["100000003"]; ok {
+		t.Error("the default policy let an exempt FL voter through")
+	}
+	if voter := voters["100000001"]; voter == nil || voter.Road != "400 S MONROE ST" || voter.Birthdate.Year() != 1970 {
+		t.Errorf("voter 100000001 is %+v", voter)
+	}
+}
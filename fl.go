@@ -0,0 +1,150 @@
+package hcip2
+
+// Florida Division of Elections voter extract, one tab separated file per county with no header row.
+// Voters who requested a public records exemption have their address and birth date replaced with '*'.
+
+const (
+	FL_County_Code int = iota
+	FL_Voter_ID
+	FL_Name_Last
+	FL_Name_Suffix
+	FL_Name_First
+	FL_Name_Middle
+	FL_Exemption
+	FL_Residence_Address_Line_1
+	FL_Residence_Address_Line_2
+	FL_Residence_City
+	FL_Residence_State
+	FL_Residence_Zipcode
+	FL_Mailing_Address_Line_1
+	FL_Mailing_Address_Line_2
+	FL_Mailing_Address_Line_3
+	FL_Mailing_City
+	FL_Mailing_State
+	FL_Mailing_Zipcode
+	FL_Mailing_Country
+	FL_Gender
+	FL_Race
+	FL_Birth_Date
+	FL_Registration_Date
+	FL_Party_Affiliation
+	FL_Precinct
+	FL_Precinct_Group
+	FL_Precinct_Split
+	FL_Precinct_Suffix
+	FL_Voter_Status
+	FL_Congressional_District
+	FL_House_District
+	FL_Senate_District
+	FL_County_Commission_District
+	FL_School_Board_District
+	FL_Daytime_Area_Code
+	FL_Daytime_Phone_Number
+	FL_Daytime_Phone_Extension
+	FL_Email
+)
+
+// FLColumns are the column names of the FL voter file, in file order
+var FLColumns = []string{
+	"County Code",
+	"Voter ID",
+	"Name Last",
+	"Name Suffix",
+	"Name First",
+	"Name Middle",
+	"Requested public records exemption",
+	"Residence Address Line 1",
+	"Residence Address Line 2",
+	"Residence City (USPS)",
+	"Residence State",
+	"Residence Zipcode",
+	"Mailing Address Line 1",
+	"Mailing Address Line 2",
+	"Mailing Address Line 3",
+	"Mailing City",
+	"Mailing State",
+	"Mailing Zipcode",
+	"Mailing Country",
+	"Gender",
+	"Race",
+	"Birth Date",
+	"Registration Date",
+	"Party Affiliation",
+	"Precinct",
+	"Precinct Group",
+	"Precinct Split",
+	"Precinct Suffix",
+	"Voter Status",
+	"Congressional District",
+	"House District",
+	"Senate District",
+	"County Commission District",
+	"School Board District",
+	"Daytime Area Code",
+	"Daytime Phone Number",
+	"Daytime Phone Extension",
+	"Email address",
+}
+
+var FL HciConfig = HciConfig{
+	Columns:        FLColumns,
+	Header:         HeaderNone,
+	MaxLineLength:  1000,
+	CITY:           FL_Residence_City,
+	STATE:          FL_Residence_State,
+	ZIP:            FL_Residence_Zipcode,
+	StateAbbrev:    "FL",
+	STATE_VOTER_ID: FL_Voter_ID,
+	Delimiter:      "\t",
+	Encoding:       UTF8,
+	DateFormat:     "01/02/2006",
+	Road:           []int{FL_Residence_Address_Line_1, FL_Residence_Address_Line_2},
+	RoadNoUnit:     []int{FL_Residence_Address_Line_1},
+	StatusCodes: map[string]Status{
+		"ACT": StatusActive,
+		"INA": StatusInactive,
+	},
+	Confidential: func(pieces []string) bool {
+		// exempt voters have no usable address anyway
+		return column(pieces, FL_Exemption) == "Y"
+	},
+	PII: map[int]PIIAction{
+		FL_Name_Last:                PIIDrop,
+		FL_Name_Suffix:              PIIDrop,
+		FL_Name_First:               PIIDrop,
+		FL_Name_Middle:              PIIDrop,
+		FL_Residence_Address_Line_1: PIIDrop,
+		FL_Residence_Address_Line_2: PIIDrop,
+		FL_Mailing_Address_Line_1:   PIIDrop,
+		FL_Mailing_Address_Line_2:   PIIDrop,
+		FL_Mailing_Address_Line_3:   PIIDrop,
+		FL_Birth_Date:               PIIDrop,
+		FL_Daytime_Area_Code:        PIIDrop,
+		FL_Daytime_Phone_Number:     PIIDrop,
+		FL_Daytime_Phone_Extension:  PIIDrop,
+		FL_Email:                    PIIDrop,
+	},
+	Districts: map[DistrictType]DistrictColumns{
+		DistrictPrecinct:         {FL_Precinct, -1},
+		DistrictCongressional:    {FL_Congressional_District, -1},
+		DistrictStateSenate:      {FL_Senate_District, -1},
+		DistrictStateHouse:       {FL_House_District, -1},
+		DistrictCountyCommission: {FL_County_Commission_District, -1},
+		DistrictSchool:           {FL_School_Board_District, -1},
+	},
+	Fields: map[string]int{
+		"County_desc":       FL_County_Code,
+		"Status_cd":         FL_Voter_Status,
+		"Voter_status_desc": FL_Voter_Status,
+		"Last_name":         FL_Name_Last,
+		"First_name":        FL_Name_First,
+		"Midl_name":         FL_Name_Middle,
+		"Name_sufx_cd":      FL_Name_Suffix,
+		"Race_code":         FL_Race,
+		"Party_cd":          FL_Party_Affiliation,
+		"Sex_code":          FL_Gender,
+		"Birthdate":         FL_Birth_Date,
+		"Registr_dt":        FL_Registration_Date,
+		"Precinct_abbrv":    FL_Precinct,
+	},
+}
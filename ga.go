@@ -0,0 +1,201 @@
+package hcip2
+
+// Georgia Secretary of State statewide voter list, pipe separated with a header row.  There is no
+// residence state column, and BIRTHDATE is only the year of birth.
+
+const (
+	GA_COUNTY_CODE int = iota
+	GA_REGISTRATION_NUMBER
+	GA_VOTER_STATUS
+	GA_LAST_NAME
+	GA_FIRST_NAME
+	GA_MIDDLE_MAIDEN_NAME
+	GA_NAME_SUFFIX
+	GA_NAME_TITLE
+	GA_RESIDENCE_HOUSE_NUMBER
+	GA_RESIDENCE_STREET_NAME
+	GA_RESIDENCE_STREET_SUFFIX
+	GA_RESIDENCE_APT_UNIT_NBR
+	GA_RESIDENCE_CITY
+	GA_RESIDENCE_ZIPCODE
+	GA_BIRTHDATE
+	GA_REGISTRATION_DATE
+	GA_RACE
+	GA_GENDER
+	GA_LAND_DISTRICT
+	GA_LAND_LOT
+	GA_STATUS_REASON
+	GA_COUNTY_PRECINCT_ID
+	GA_CITY_PRECINCT_ID
+	GA_CONGRESSIONAL_DISTRICT
+	GA_SENATE_DISTRICT
+	GA_HOUSE_DISTRICT
+	GA_JUDICIAL_DISTRICT
+	GA_COMMISSION_DISTRICT
+	GA_SCHOOL_DISTRICT
+	GA_COUNTY_DISTRICTA_NAME
+	GA_COUNTY_DISTRICTA_VALUE
+	GA_COUNTY_DISTRICTB_NAME
+	GA_COUNTY_DISTRICTB_VALUE
+	GA_MUNICIPAL_NAME
+	GA_MUNICIPAL_CODE
+	GA_WARD_CITY_COUNCIL_NAME
+	GA_WARD_CITY_COUNCIL_CODE
+	GA_CITY_SCHOOL_DISTRICT_NAME
+	GA_CITY_SCHOOL_DISTRICT_VALUE
+	GA_CITY_DISTA_NAME
+	GA_CITY_DISTA_VALUE
+	GA_CITY_DISTB_NAME
+	GA_CITY_DISTB_VALUE
+	GA_CITY_DISTC_NAME
+	GA_CITY_DISTC_VALUE
+	GA_CITY_DISTD_NAME
+	GA_CITY_DISTD_VALUE
+	GA_DATE_LAST_VOTED
+	GA_PARTY_LAST_VOTED
+	GA_DATE_ADDED
+	GA_DATE_CHANGED
+	GA_DISTRICT_COMBO
+	GA_RACE_DESC
+	GA_LAST_CONTACT_DATE
+	GA_MAIL_HOUSE_NBR
+	GA_MAIL_STREET_NAME
+	GA_MAIL_APT_UNIT_NBR
+	GA_MAIL_CITY
+	GA_MAIL_STATE
+	GA_MAIL_ZIPCODE
+	GA_MAIL_ADDRESS_2
+	GA_MAIL_ADDRESS_3
+	GA_MAIL_COUNTRY
+)
+
+// GAColumns are the header names of the GA voter file, in file order
+var GAColumns = []string{
+	"COUNTY_CODE",
+	"REGISTRATION_NUMBER",
+	"VOTER_STATUS",
+	"LAST_NAME",
+	"FIRST_NAME",
+	"MIDDLE_MAIDEN_NAME",
+	"NAME_SUFFIX",
+	"NAME_TITLE",
+	"RESIDENCE_HOUSE_NUMBER",
+	"RESIDENCE_STREET_NAME",
+	"RESIDENCE_STREET_SUFFIX",
+	"RESIDENCE_APT_UNIT_NBR",
+	"RESIDENCE_CITY",
+	"RESIDENCE_ZIPCODE",
+	"BIRTHDATE",
+	"REGISTRATION_DATE",
+	"RACE",
+	"GENDER",
+	"LAND_DISTRICT",
+	"LAND_LOT",
+	"STATUS_REASON",
+	"COUNTY_PRECINCT_ID",
+	"CITY_PRECINCT_ID",
+	"CONGRESSIONAL_DISTRICT",
+	"SENATE_DISTRICT",
+	"HOUSE_DISTRICT",
+	"JUDICIAL_DISTRICT",
+	"COMMISSION_DISTRICT",
+	"SCHOOL_DISTRICT",
+	"COUNTY_DISTRICTA_NAME",
+	"COUNTY_DISTRICTA_VALUE",
+	"COUNTY_DISTRICTB_NAME",
+	"COUNTY_DISTRICTB_VALUE",
+	"MUNICIPAL_NAME",
+	"MUNICIPAL_CODE",
+	"WARD_CITY_COUNCIL_NAME",
+	"WARD_CITY_COUNCIL_CODE",
+	"CITY_SCHOOL_DISTRICT_NAME",
+	"CITY_SCHOOL_DISTRICT_VALUE",
+	"CITY_DISTA_NAME",
+	"CITY_DISTA_VALUE",
+	"CITY_DISTB_NAME",
+	"CITY_DISTB_VALUE",
+	"CITY_DISTC_NAME",
+	"CITY_DISTC_VALUE",
+	"CITY_DISTD_NAME",
+	"CITY_DISTD_VALUE",
+	"DATE_LAST_VOTED",
+	"PARTY_LAST_VOTED",
+	"DATE_ADDED",
+	"DATE_CHANGED",
+	"DISTRICT_COMBO",
+	"RACE_DESC",
+	"LAST_CONTACT_DATE",
+	"MAIL_HOUSE_NBR",
+	"MAIL_STREET_NAME",
+	"MAIL_APT_UNIT_NBR",
+	"MAIL_CITY",
+	"MAIL_STATE",
+	"MAIL_ZIPCODE",
+	"MAIL_ADDRESS_2",
+	"MAIL_ADDRESS_3",
+	"MAIL_COUNTRY",
+}
+
+var GA HciConfig = HciConfig{
+	Columns:        GAColumns,
+	MaxLineLength:  1500,
+	CITY:           GA_RESIDENCE_CITY,
+	STATE:          -1,
+	ZIP:            GA_RESIDENCE_ZIPCODE,
+	StateAbbrev:    "GA",
+	STATE_VOTER_ID: GA_REGISTRATION_NUMBER,
+	Delimiter:      "|",
+	Encoding:       UTF8,
+	DateFormat:     "20060102",
+	Road:           []int{GA_RESIDENCE_HOUSE_NUMBER, GA_RESIDENCE_STREET_NAME, GA_RESIDENCE_STREET_SUFFIX, GA_RESIDENCE_APT_UNIT_NBR},
+	RoadNoUnit:     []int{GA_RESIDENCE_HOUSE_NUMBER, GA_RESIDENCE_STREET_NAME, GA_RESIDENCE_STREET_SUFFIX},
+	// cancelled records shouldn't be in the list, but sometimes are
+	StatusCodes: map[string]Status{
+		"A": StatusActive,
+		"I": StatusInactive,
+	},
+	PII: map[int]PIIAction{
+		GA_LAST_NAME:              PIIDrop,
+		GA_FIRST_NAME:             PIIDrop,
+		GA_MIDDLE_MAIDEN_NAME:     PIIDrop,
+		GA_NAME_SUFFIX:            PIIDrop,
+		GA_NAME_TITLE:             PIIDrop,
+		GA_RESIDENCE_HOUSE_NUMBER: PIIDrop,
+		GA_RESIDENCE_STREET_NAME:  PIIDrop,
+		GA_RESIDENCE_APT_UNIT_NBR: PIIDrop,
+		GA_BIRTHDATE:              PIIDrop,
+		GA_MAIL_HOUSE_NBR:         PIIDrop,
+		GA_MAIL_STREET_NAME:       PIIDrop,
+		GA_MAIL_APT_UNIT_NBR:      PIIDrop,
+		GA_MAIL_ADDRESS_2:         PIIDrop,
+		GA_MAIL_ADDRESS_3:         PIIDrop,
+	},
+	Districts: map[DistrictType]DistrictColumns{
+		DistrictPrecinct:         {GA_COUNTY_PRECINCT_ID, -1},
+		DistrictCongressional:    {GA_CONGRESSIONAL_DISTRICT, -1},
+		DistrictStateSenate:      {GA_SENATE_DISTRICT, -1},
+		DistrictStateHouse:       {GA_HOUSE_DISTRICT, -1},
+		DistrictJudicial:         {GA_JUDICIAL_DISTRICT, -1},
+		DistrictCountyCommission: {GA_COMMISSION_DISTRICT, -1},
+		DistrictSchool:           {GA_SCHOOL_DISTRICT, -1},
+		DistrictMunicipality:     {GA_MUNICIPAL_CODE, GA_MUNICIPAL_NAME},
+		DistrictWard:             {GA_WARD_CITY_COUNCIL_CODE, GA_WARD_CITY_COUNCIL_NAME},
+	},
+	Fields: map[string]int{
+		"County_id":                GA_COUNTY_CODE,
+		"Status_cd":                GA_VOTER_STATUS,
+		"Reason_cd":                GA_STATUS_REASON,
+		"Voter_status_reason_desc": GA_STATUS_REASON,
+		"Last_name":                GA_LAST_NAME,
+		"First_name":               GA_FIRST_NAME,
+		"Midl_name":                GA_MIDDLE_MAIDEN_NAME,
+		"Name_sufx_cd":             GA_NAME_SUFFIX,
+		"Race_code":                GA_RACE,
+		"Race_desc":                GA_RACE_DESC,
+		"Party_cd":                 GA_PARTY_LAST_VOTED,
+		"Sex_code":                 GA_GENDER,
+		"Birthdate":                GA_BIRTHDATE,
+		"Registr_dt":               GA_REGISTRATION_DATE,
+		"Precinct_abbrv":           GA_COUNTY_PRECINCT_ID,
+	},
+}
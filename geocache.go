@@ -0,0 +1,298 @@
+package hcip2
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// GeocodeCache keeps geocoder answers on disk, so a rerun on a new snapshot only asks about the
+// addresses that changed.  Answers are keyed by the normalized query and tagged with the
+// backend and a version (say, the date of the map data), and an answer with another tag or
+// older than TTL is a miss.  No match is an answer too; geocoder failures aren't cached.
+//
+// The file is a log of JSON lines, one per answer, appended to as answers come in; the latest
+// line for a query wins.  Lines a crash cut short are skipped.
+type GeocodeCache struct {
+	Path    string
+	TTL     time.Duration // how long answers are good for; forever if 0
+	entries map[string]geocacheEntry
+	file    *os.File
+	w       *bufio.Writer
+	hits    int
+	misses  int
+	mu      sync.Mutex
+}
+
+// geocacheEntry is one line of the cache file
+type geocacheEntry struct {
+	Tag     string  `json:"tag"`
+	Query   string  `json:"query"`
+	Time    int64   `json:"time"` // Unix seconds
+	Matches []Match `json:"matches"`
+}
+
+func (e geocacheEntry) key() string {
+	return e.Tag + "\x00" + e.Query
+}
+
+// OpenGeocodeCache loads the cache at path, creating it if need be.  A file mostly made of
+// superseded or expired lines is rewritten without them.
+func OpenGeocodeCache(path string, ttl time.Duration) (*GeocodeCache, error) {
+	c := &GeocodeCache{Path: path, TTL: ttl, entries: make(map[string]geocacheEntry)}
+	lines := 0
+	if file, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			lines++
+			var e geocacheEntry
+			if json.Unmarshal(scanner.Bytes(), &e) != nil {
+				continue
+			}
+			c.entries[e.key()] = e
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Error reading geocode cache %s: %s", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	for key, e := range c.entries {
+		if c.expired(e) {
+			delete(c.entries, key)
+		}
+	}
+	if lines > 2*len(c.entries)+1000 {
+		if err := c.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0664)
+	if err != nil {
+		return nil, err
+	}
+	c.file, c.w = file, bufio.NewWriter(file)
+	return c, nil
+}
+
+func (c *GeocodeCache) expired(e geocacheEntry) bool {
+	return c.TTL > 0 && time.Since(time.Unix(e.Time, 0)) > c.TTL
+}
+
+// rewrite replaces the file with just the live entries
+func (c *GeocodeCache) rewrite() error {
+	tmp, err := ioutil.TempFile(filepath.Dir(c.Path), filepath.Base(c.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // a no-op once it's renamed
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, e := range c.entries {
+		if err = enc.Encode(e); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.Path)
+	}
+	if err != nil {
+		return fmt.Errorf("Error compacting geocode cache %s: %s", c.Path, err)
+	}
+	return nil
+}
+
+// Get looks up the answer to a query, counting the hit or miss
+func (c *GeocodeCache) Get(tag string, query string) ([]Match, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[tag+"\x00"+query]
+	if !ok || c.expired(e) {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return e.Matches, true
+}
+
+// Put stores the answer to a query
+func (c *GeocodeCache) Put(tag string, query string, matches []Match) error {
+	if matches == nil {
+		matches = []Match{}
+	}
+	e := geocacheEntry{Tag: tag, Query: query, Time: time.Now().Unix(), Matches: matches}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[e.key()] = e
+	c.w.Write(line)
+	return c.w.WriteByte('\n')
+}
+
+// Stats says how many lookups have hit and missed
+func (c *GeocodeCache) Stats() (hits int, misses int) {
+	if c == nil {
+		return 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// StatsString is Stats for the progress lines
+func (c *GeocodeCache) StatsString() string {
+	if c == nil {
+		return ""
+	}
+	hits, misses := c.Stats()
+	return fmt.Sprintf(" (geocode cache: %d hits, %d misses)", hits, misses)
+}
+
+// Flush writes out the answers still buffered
+func (c *GeocodeCache) Flush() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.w.Flush()
+}
+
+// Close writes out the answers still buffered and closes the file
+func (c *GeocodeCache) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.w.Flush(); err != nil {
+		c.file.Close()
+		return err
+	}
+	return c.file.Close()
+}
+
+// geocacheFormat is part of every answer's tag, and changes when Match gains fields, so answers
+// cached without them stop being used
+const geocacheFormat = "2"
+
+// Wrap puts the cache in front of g.  version tags the answers along with g's name; change it
+// when the geocoder's data changes, to stop using the old answers.
+func (c *GeocodeCache) Wrap(g Geocoder, version string) Geocoder {
+	tag := g.Name() + "@" + geocacheFormat
+	if version != "" {
+		tag += "/" + version
+	}
+	cg := &cachedGeocoder{Geocoder: g, cache: c, tag: tag}
+	if bg, ok := g.(BatchGeocoder); ok {
+		return &cachedBatchGeocoder{cachedGeocoder: cg, batch: bg}
+	}
+	return cg
+}
+
+// cacheable is the form of an address the cache keys and the geocoder is sent: standardized,
+// with the ZIP cut to 5 digits, since a ZIP+4 only sometimes comes with it
+func cacheable(addr address.Address) address.Address {
+	a := address.Standardize(addr)
+	if len(a.Zip) > 5 {
+		a.Zip = a.Zip[:5]
+	}
+	return a
+}
+
+// AddressQuery is the normalized form of a structured query that the cache keys it by
+func AddressQuery(addr address.Address) string {
+	a := cacheable(addr)
+	return strings.Join([]string{"geocode", a.Line(), a.City, a.State, a.Zip}, "|")
+}
+
+// textQuery is the normalized form of a free-form query
+func textQuery(text string) string {
+	return "search|" + strings.Join(strings.Fields(strings.ToUpper(text)), " ")
+}
+
+type cachedGeocoder struct {
+	Geocoder
+	cache *GeocodeCache
+	tag   string
+}
+
+// Geocode sends the geocoder the address as the cache keys it, so every address with the same
+// key gets the same answer
+func (cg *cachedGeocoder) Geocode(addr address.Address) ([]Match, error) {
+	addr = cacheable(addr)
+	return cg.lookup(AddressQuery(addr), func() ([]Match, error) { return cg.Geocoder.Geocode(addr) })
+}
+
+func (cg *cachedGeocoder) Search(text string) ([]Match, error) {
+	return cg.lookup(textQuery(text), func() ([]Match, error) { return cg.Geocoder.Search(text) })
+}
+
+func (cg *cachedGeocoder) lookup(query string, call func() ([]Match, error)) ([]Match, error) {
+	if matches, ok := cg.cache.Get(cg.tag, query); ok {
+		return matches, nil
+	}
+	matches, err := call()
+	if err != nil {
+		return nil, err
+	}
+	if err = cg.cache.Put(cg.tag, query, matches); err != nil {
+		return nil, fmt.Errorf("Error writing geocode cache: %s", err)
+	}
+	return matches, nil
+}
+
+// cachedBatchGeocoder sends only the misses on to a BatchGeocoder
+type cachedBatchGeocoder struct {
+	*cachedGeocoder
+	batch BatchGeocoder
+}
+
+func (cg *cachedBatchGeocoder) GeocodeBatch(addrs []address.Address) ([][]Match, error) {
+	matches := make([][]Match, len(addrs))
+	var missed []int
+	var missedAddrs []address.Address
+	for i, addr := range addrs {
+		var ok bool
+		if matches[i], ok = cg.cache.Get(cg.tag, AddressQuery(addr)); !ok {
+			missed = append(missed, i)
+			missedAddrs = append(missedAddrs, cacheable(addr))
+		}
+	}
+	if len(missed) == 0 {
+		return matches, nil
+	}
+	answers, err := cg.batch.GeocodeBatch(missedAddrs)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missed {
+		matches[i] = answers[j]
+		if err = cg.cache.Put(cg.tag, AddressQuery(addrs[i]), answers[j]); err != nil {
+			return nil, fmt.Errorf("Error writing geocode cache: %s", err)
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,167 @@
+package hcip2
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// recordingGeocoder answers every address with one match, and keeps what it was sent
+type recordingGeocoder struct {
+	sent    []address.Address
+	batches int
+	fail    bool
+	mu      sync.Mutex
+}
+
+func (g *recordingGeocoder) Name() string { return "recording" }
+
+func (g *recordingGeocoder) Geocode(addr address.Address) ([]Match, error) {
+	if g.fail {
+		return nil, errors.New("down")
+	}
+	g.mu.Lock()
+	g.sent = append(g.sent, addr)
+	g.mu.Unlock()
+	if addr.Number == "0" {
+		return []Match{}, nil
+	}
+	return []Match{{Lat: 35, Lon: -78, Zip: addr.Zip}}, nil
+}
+
+func (g *recordingGeocoder) Search(text string) ([]Match, error) {
+	return g.Geocode(address.Address{Name: text})
+}
+
+type recordingBatchGeocoder struct {
+	recordingGeocoder
+}
+
+func (g *recordingBatchGeocoder) GeocodeBatch(addrs []address.Address) ([][]Match, error) {
+	g.batches++
+	matches := make([][]Match, len(addrs))
+	for i, addr := range addrs {
+		matches[i], _ = g.Geocode(addr)
+	}
+	return matches, nil
+}
+
+func mainSt(zip string) address.Address {
+	return address.Address{Number: "100", PreDir: "North", Name: "Main", Suffix: "Street", City: "Apex", State: "NC", Zip: zip}
+}
+
+func TestGeocodeCacheZip(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := OpenGeocodeCache(filepath.Join(dir, "geocache.jsonl"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	g := &recordingGeocoder{}
+	cg := cache.Wrap(g, "")
+	first, err := cg.Geocode(mainSt("27502-1234"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same key, so the same answer, whatever the ZIP+4
+	second, err := cg.Geocode(mainSt("27502-9999"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.sent) != 1 || g.sent[0].Zip != "27502" || g.sent[0].Line() != "100 N MAIN ST" {
+		t.Errorf("the geocoder was sent %+v", g.sent)
+	}
+	if !reflect.DeepEqual(first, second) || first[0].Zip != "27502" {
+		t.Errorf("answers %+v and %+v", first, second)
+	}
+	if hits, misses := cache.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("%d hits, %d misses", hits, misses)
+	}
+
+	bg := &recordingBatchGeocoder{}
+	cbg := cache.Wrap(bg, "batch").(BatchGeocoder)
+	addrs := []address.Address{mainSt("27502-1234"), mainSt(""), mainSt("27502-5555")}
+	if _, err := cbg.GeocodeBatch(addrs); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cbg.GeocodeBatch(addrs); err != nil {
+		t.Fatal(err)
+	}
+	if bg.batches != 1 || len(bg.sent) != 3 || bg.sent[0].Zip != "27502" || bg.sent[1].Zip != "" || bg.sent[2].Zip != "27502" {
+		t.Errorf("%d batches sent %+v", bg.batches, bg.sent)
+	}
+}
+
+func TestGeocodeCacheReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "geocache.jsonl")
+	cache, err := OpenGeocodeCache(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &recordingGeocoder{}
+	cg := cache.Wrap(g, "2024-01")
+	cg.Geocode(mainSt("27502"))
+	nowhere := mainSt("27502")
+	nowhere.Number = "0"
+	cg.Geocode(nowhere) // no match is an answer too
+	cg.Search("Apex Community Center")
+	g.fail = true
+	if _, err := cg.Search("Town Hall"); err == nil {
+		t.Error("the geocoder failing should be an error")
+	}
+	cache.Close()
+
+	// a crash cut the last line short
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"tag":"recording@2/2024-01","query":"geocode|`)
+	f.Close()
+
+	cache, err = OpenGeocodeCache(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g = &recordingGeocoder{fail: true}
+	cg = cache.Wrap(g, "2024-01")
+	if m, err := cg.Geocode(mainSt("27502")); err != nil || len(m) != 1 {
+		t.Errorf("reopened cache: %v, %v", m, err)
+	}
+	if m, err := cg.Geocode(nowhere); err != nil || m == nil || len(m) != 0 {
+		t.Errorf("no match came back as %v, %v", m, err)
+	}
+	if _, err := cg.Search("  apex community   center "); err != nil {
+		t.Errorf("free-form search wasn't cached: %s", err)
+	}
+	if _, err := cg.Search("Town Hall"); err == nil {
+		t.Error("a failure was cached")
+	}
+	if _, err := cache.Wrap(g, "2025-01").Geocode(mainSt("27502")); err == nil {
+		t.Error("a new version used the old answers")
+	}
+	cache.Close()
+
+	cache, err = OpenGeocodeCache(path, time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+	// answers are timed to the second, so they're already older than that
+	if _, ok := cache.Get("recording@2/2024-01", AddressQuery(mainSt("27502"))); ok {
+		t.Error("an expired answer was used")
+	}
+}
+
+func TestAddressQuery(t *testing.T) {
+	a := AddressQuery(mainSt("27502-1234"))
+	b := AddressQuery(address.Address{Number: "100", PreDir: "N", Name: "MAIN", Suffix: "ST", City: "APEX", State: "NC", Zip: "27502"})
+	if a != b || a != "geocode|100 N MAIN ST|APEX|NC|27502" {
+		t.Errorf("AddressQuery = %q and %q", a, b)
+	}
+}
@@ -0,0 +1,167 @@
+package hcip2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// Match is one place a geocoder found for an address
+type Match struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Label    string  `json:"label"`              // how the geocoder describes the place
+	Kind     string  `json:"kind"`               // what kind of place it is, in the geocoder's terms: house, street, address...
+	Category string  `json:"category,omitempty"` // the broader class Kind is in, if the geocoder has one: Nominatim's category, Photon's osm_key
+	Rank     int     `json:"rank,omitempty"`     // Nominatim's place_rank, 30 for a house down to 4 for a country; 0 from the others
+	Zip      string  `json:"zip,omitempty"`      // the ZIP the geocoder puts the place in, if it says
+	Score    float64 `json:"score"`              // the geocoder's own confidence or importance, from 0 to 1; 0 if it gives none
+}
+
+// Geocoder turns addresses into coordinates.  No match is an empty slice, not an error; errors
+// mean the geocoder itself failed, and say nothing about the address.
+type Geocoder interface {
+	Name() string
+	Geocode(addr address.Address) ([]Match, error) // a structured search, unit and all; blank parts are left out
+	Search(text string) ([]Match, error)           // a free-form search, e.g. a place name and a ZIP
+}
+
+// BatchGeocoder is a Geocoder that can take many addresses in one request.  The matches come
+// back in the same order as addrs.
+type BatchGeocoder interface {
+	Geocoder
+	GeocodeBatch(addrs []address.Address) ([][]Match, error)
+}
+
+// ErrFreeForm is what Search returns from geocoders that only take structured addresses
+var ErrFreeForm = errors.New("geocoder can't search free-form text")
+
+// Geocoders are the backends NewGeocoder knows, and the URL each is found at by default
+var Geocoders = map[string]string{
+	"nominatim": "http://localhost/nominatim",
+	"photon":    "http://localhost:2322",
+	"pelias":    "http://localhost:4000",
+	"census":    "https://geocoding.geo.census.gov/geocoder",
+}
+
+// NewGeocoder makes the named backend's Geocoder, talking to baseURL, or to the backend's
+// default URL (see Geocoders) if it's empty.  A nil client means http.DefaultClient.
+func NewGeocoder(name string, baseURL string, client *http.Client) (Geocoder, error) {
+	name = strings.ToLower(name)
+	defaultURL, ok := Geocoders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown geocoder %s; use nominatim, photon, pelias or census", name)
+	}
+	if baseURL == "" {
+		baseURL = defaultURL
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	switch name {
+	case "photon":
+		return &Photon{BaseURL: baseURL, Client: client}, nil
+	case "pelias":
+		return &Pelias{BaseURL: baseURL, Client: client}, nil
+	case "census":
+		return &Census{BaseURL: baseURL, Client: client}, nil
+	}
+	return &Nominatim{BaseURL: baseURL, Client: client}, nil
+}
+
+// GeocodeAll geocodes every address, in one request if g can take batches
+func GeocodeAll(g Geocoder, addrs []address.Address) ([][]Match, error) {
+	if bg, ok := g.(BatchGeocoder); ok {
+		return bg.GeocodeBatch(addrs)
+	}
+	matches := make([][]Match, len(addrs))
+	for i, addr := range addrs {
+		var err error
+		if matches[i], err = g.Geocode(addr); err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+// httpClient is the client a geocoder uses when it isn't given one
+func httpClient(client *http.Client) *http.Client {
+	if client == nil {
+		return http.DefaultClient
+	}
+	return client
+}
+
+// closeBody reads what's left of a response before closing it, so its connection can be reused
+func closeBody(resp *http.Response) {
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// secretParams are the query parameters that carry credentials, which are masked out of errors
+var secretParams = []string{"api_key", "apikey", "key", "token"}
+
+// redactURL masks the credentials in a URL's query, so it can go in an error message
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return strings.SplitN(rawURL, "?", 2)[0]
+	}
+	query := u.Query()
+	masked := false
+	for _, name := range secretParams {
+		if query.Get(name) != "" {
+			query.Set(name, "REDACTED")
+			masked = true
+		}
+	}
+	if !masked {
+		return rawURL
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// checkResponse turns a non-200 response into an error, with the start of its body
+func checkResponse(resp *http.Response, u string) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+	return fmt.Errorf("Non-OK response code from %s: %s %s", redactURL(u), resp.Status, body)
+}
+
+// getJSON fetches u and decodes its JSON body into v
+func getJSON(client *http.Client, u string, v interface{}) error {
+	resp, err := httpClient(client).Get(u)
+	if err != nil {
+		if uerr, ok := err.(*url.Error); ok {
+			uerr.URL = redactURL(uerr.URL) // it says which URL failed, key and all
+		}
+		return err
+	}
+	defer closeBody(resp)
+	if err = checkResponse(resp, u); err != nil {
+		return err
+	}
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("Error decoding JSON from %s: %s", redactURL(u), err)
+	}
+	return nil
+}
+
+// geoJSON is the GeoJSON FeatureCollection Photon and Pelias answer with; each backend reads
+// its own properties out of it
+type geoJSON struct {
+	Features []struct {
+		Geometry struct {
+			Coordinates [2]float64 `json:"coordinates"` // longitude first
+		} `json:"geometry"`
+		Properties json.RawMessage `json:"properties"`
+	} `json:"features"`
+}
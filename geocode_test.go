@@ -0,0 +1,105 @@
+package hcip2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skemper/hcip2/address"
+	"github.com/skemper/hcip2/geofake"
+)
+
+var fakePlaces = []geofake.Place{
+	{Street: "100 N MAIN ST", City: "APEX", State: "NC", Zip: "27502", Lat: 35.7327, Lon: -78.8503},
+	{Name: "APEX COMMUNITY CENTER", Street: "53 HUNTER ST", City: "APEX", State: "NC", Zip: "27502", Lat: 35.7301, Lon: -78.8532},
+}
+
+// fakeAddress is a structured address for the geocoders, with the city, state and ZIP filled in
+func fakeAddress(street string) address.Address {
+	addr := address.ParseStreet(street)
+	addr.City, addr.State, addr.Zip = "APEX", "NC", "27502"
+	return addr
+}
+
+func TestGeocoders(t *testing.T) {
+	for _, backend := range geofake.Backends {
+		h, err := geofake.Handler(backend, fakePlaces)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := httptest.NewServer(h)
+		g, err := NewGeocoder(backend, srv.URL+"/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Name() != backend {
+			t.Errorf("NewGeocoder(%s) made a %s geocoder", backend, g.Name())
+		}
+
+		matches, err := GeocodeAll(g, []address.Address{fakeAddress("100 N MAIN ST"), fakeAddress("9 NOWHERE LN"), fakeAddress("53 HUNTER ST")})
+		if err != nil {
+			t.Errorf("%s: %s", backend, err)
+		} else if len(matches) != 3 || len(matches[0]) != 1 || len(matches[1]) != 0 || len(matches[2]) != 1 {
+			t.Errorf("%s: matches %+v", backend, matches)
+		} else {
+			m := matches[0][0]
+			if m.Lat < 35.73269 || m.Lat > 35.73271 || m.Lon < -78.85031 || m.Lon > -78.85029 {
+				t.Errorf("%s: 100 N MAIN ST at %f,%f", backend, m.Lat, m.Lon)
+			}
+			if backend != "census" && m.Zip != "27502" {
+				t.Errorf("%s: 100 N MAIN ST in ZIP %q", backend, m.Zip)
+			}
+			if matches[2][0].Lat != 35.7301 && backend != "census" {
+				t.Errorf("%s: 53 HUNTER ST came back as %+v", backend, matches[2][0])
+			}
+		}
+
+		matches1, err := g.Search("Apex Community Center 27502")
+		switch {
+		case backend == "census":
+			if err != ErrFreeForm {
+				t.Errorf("census: Search returned %v, %v", matches1, err)
+			}
+		case err != nil:
+			t.Errorf("%s: %s", backend, err)
+		case len(matches1) != 1 || matches1[0].Lon != -78.8532:
+			t.Errorf("%s: Search found %+v", backend, matches1)
+		}
+		srv.Close()
+	}
+}
+
+func TestGeocoderErrorsHideAPIKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "over quota", http.StatusForbidden)
+	}))
+	p := &Pelias{BaseURL: srv.URL, APIKey: "sekrit-key"}
+	_, err := p.Geocode(fakeAddress("100 N MAIN ST"))
+	if err == nil || strings.Contains(err.Error(), "sekrit") || !strings.Contains(err.Error(), "api_key=REDACTED") {
+		t.Errorf("from a 403: %v", err)
+	}
+
+	srv.Close() // so the next request can't connect
+	_, err = p.Search("APEX")
+	if err == nil || strings.Contains(err.Error(), "sekrit") {
+		t.Errorf("from a refused connection: %v", err)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"http://localhost:4000/v1/search?text=APEX", "http://localhost:4000/v1/search?text=APEX"},
+		{"https://api.geocode.earth/v1/search?api_key=abc&text=APEX", "https://api.geocode.earth/v1/search?api_key=REDACTED&text=APEX"},
+		{"http://x/search?key=abc&token=def", "http://x/search?key=REDACTED&token=REDACTED"},
+		{"http://x/%zz?api_key=abc", "http://x/%zz"},
+	}
+	for _, test := range tests {
+		if got := redactURL(test.in); got != test.want {
+			t.Errorf("redactURL(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// Package geofake serves canned answers in the wire format of each geocoder hcip2 talks to, so
+// the geocoders, and the commands that use them, can be run without a real geocoding server.
+package geofake
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Place is somewhere the fakes can find
+type Place struct {
+	Name   string // a landmark or polling place name, for free-form searches
+	Street string // e.g. 123 N MAIN ST
+	City   string
+	State  string
+	Zip    string
+	Lat    float64
+	Lon    float64
+	Kind   string // house, street or postcode; house if blank
+}
+
+// Backends are the geocoders there are fakes of
+var Backends = []string{"nominatim", "photon", "pelias", "census"}
+
+// Handler answers like the named backend would, from places
+func Handler(backend string, places []Place) (http.Handler, error) {
+	f := &fake{places: places}
+	mux := http.NewServeMux()
+	switch strings.ToLower(backend) {
+	case "nominatim":
+		mux.HandleFunc("/search", f.nominatim)
+	case "photon":
+		mux.HandleFunc("/api", f.photon)
+	case "pelias":
+		mux.HandleFunc("/v1/search/structured", f.pelias)
+		mux.HandleFunc("/v1/search", f.pelias)
+	case "census":
+		mux.HandleFunc("/locations/addressbatch", f.census)
+	default:
+		return nil, fmt.Errorf("no fake of geocoder %s; use one of %s", backend, strings.Join(Backends, ", "))
+	}
+	return mux, nil
+}
+
+// NewServer starts a fake of the named backend on a local port; its URL is what to give
+// hcip2.NewGeocoder.  Close it when done.
+func NewServer(backend string, places []Place) (*httptest.Server, error) {
+	h, err := Handler(backend, places)
+	if err != nil {
+		return nil, err
+	}
+	return httptest.NewServer(h), nil
+}
+
+// LoadPlaces reads places from a CSV file with a header row and the columns name, street,
+// city, state, zip, lat and lon, and optionally kind
+func LoadPlaces(path string) ([]Place, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %s", path, err)
+	}
+	var places []Place
+	for i, row := range rows {
+		if i == 0 {
+			continue
+		}
+		if len(row) != 7 && len(row) != 8 {
+			return nil, fmt.Errorf("%s line %d: want name,street,city,state,zip,lat,lon[,kind]", path, i+1)
+		}
+		p := Place{Name: row[0], Street: row[1], City: row[2], State: row[3], Zip: row[4]}
+		lat, latErr := strconv.ParseFloat(row[5], 64)
+		lon, lonErr := strconv.ParseFloat(row[6], 64)
+		if latErr != nil || lonErr != nil {
+			return nil, fmt.Errorf("%s line %d: bad coordinates %s,%s", path, i+1, row[5], row[6])
+		}
+		p.Lat, p.Lon = lat, lon
+		if len(row) == 8 {
+			p.Kind = row[7]
+		}
+		places = append(places, p)
+	}
+	return places, nil
+}
+
+type fake struct {
+	places []Place
+}
+
+// norm makes values compare the way a forgiving geocoder would
+func norm(s string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(s)), " ")
+}
+
+// find answers a structured search: every part that's given has to match
+func (f *fake) find(street, city, state, zip string) []Place {
+	var found []Place
+	for _, p := range f.places {
+		if street != "" && !p.onStreet(street) ||
+			city != "" && norm(city) != norm(p.City) ||
+			state != "" && norm(state) != norm(p.State) ||
+			zip != "" && !sameZip(zip, p.Zip) {
+			continue
+		}
+		if street == "" && (p.Kind != "postcode" || zip == "") {
+			continue // only a postcode is found without a street, and only by its ZIP
+		}
+		found = append(found, p)
+	}
+	return found
+}
+
+// search answers a free-form search: the text has to name the place or its street
+func (f *fake) search(text string) []Place {
+	text = norm(text)
+	var found []Place
+	for _, p := range f.places {
+		if p.Name != "" && strings.Contains(text, norm(p.Name)) || p.Street != "" && strings.Contains(text, norm(p.Street)) {
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+// onStreet is whether a place answers for a street address: a house has to be at it, a street
+// has to be its street, and a postcode is only found without one
+func (p Place) onStreet(street string) bool {
+	switch p.Kind {
+	case "street":
+		fields := strings.Fields(norm(street))
+		return len(fields) > 1 && strings.Join(fields[1:], " ") == norm(p.Street)
+	case "postcode":
+		return false
+	}
+	return norm(street) == norm(p.Street)
+}
+
+// kind is the place's kind in each backend's terms: Nominatim's category, type and place_rank,
+// Photon's osm_key and type, and Pelias's layer
+func (p Place) kind() (category string, kind string, rank int, layer string) {
+	switch p.Kind {
+	case "street":
+		return "highway", "residential", 26, "street"
+	case "postcode":
+		return "place", "postcode", 21, "postalcode"
+	}
+	return "place", "house", 30, "address"
+}
+
+func sameZip(a, b string) bool {
+	if len(a) > 5 {
+		a = a[:5]
+	}
+	if len(b) > 5 {
+		b = b[:5]
+	}
+	return a == b
+}
+
+func (p Place) label() string {
+	var parts []string
+	for _, part := range []string{p.Name, p.Street, p.City, p.State, p.Zip} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// number splits the house number off the street
+func (p Place) number() (string, string) {
+	fields := strings.Fields(p.Street)
+	if len(fields) > 1 && fields[0][0] >= '0' && fields[0][0] <= '9' {
+		return fields[0], strings.Join(fields[1:], " ")
+	}
+	return "", p.Street
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// nominatim answers /search with jsonv2 results
+func (f *fake) nominatim(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var found []Place
+	if text := q.Get("q"); text != "" {
+		found = f.search(text)
+	} else {
+		found = f.find(q.Get("street"), q.Get("city"), q.Get("state"), q.Get("postalcode"))
+	}
+	results := make([]map[string]interface{}, len(found))
+	for i, p := range found {
+		category, kind, rank, _ := p.kind()
+		results[i] = map[string]interface{}{
+			"place_id":     i + 1,
+			"lat":          strconv.FormatFloat(p.Lat, 'f', -1, 64),
+			"lon":          strconv.FormatFloat(p.Lon, 'f', -1, 64),
+			"display_name": p.label(),
+			"place_rank":   rank,
+			"category":     category,
+			"type":         kind,
+			"importance":   0.5,
+			"address":      map[string]string{"postcode": p.Zip},
+		}
+	}
+	writeJSON(w, results)
+}
+
+// geoJSON writes found as a GeoJSON FeatureCollection with each feature's properties
+func geoJSON(w http.ResponseWriter, found []Place, props func(Place) map[string]interface{}) {
+	features := make([]map[string]interface{}, len(found))
+	for i, p := range found {
+		features[i] = map[string]interface{}{
+			"type":       "Feature",
+			"geometry":   map[string]interface{}{"type": "Point", "coordinates": []float64{p.Lon, p.Lat}},
+			"properties": props(p),
+		}
+	}
+	writeJSON(w, map[string]interface{}{"type": "FeatureCollection", "features": features})
+}
+
+// photon answers /api; it only has free-form search
+func (f *fake) photon(w http.ResponseWriter, r *http.Request) {
+	found := f.search(r.URL.Query().Get("q"))
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit < len(found) {
+		found = found[:limit]
+	}
+	geoJSON(w, found, func(p Place) map[string]interface{} {
+		number, street := p.number()
+		category, kind, _, _ := p.kind()
+		if kind == "residential" {
+			kind = "street"
+		}
+		return map[string]interface{}{
+			"name":        p.Name,
+			"housenumber": number,
+			"street":      street,
+			"city":        p.City,
+			"state":       p.State,
+			"postcode":    p.Zip,
+			"countrycode": "US",
+			"osm_key":     category,
+			"osm_value":   kind,
+			"type":        kind,
+		}
+	})
+}
+
+// pelias answers both /v1/search/structured and /v1/search
+func (f *fake) pelias(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var found []Place
+	if strings.HasSuffix(r.URL.Path, "/structured") {
+		found = f.find(q.Get("address"), q.Get("locality"), q.Get("region"), q.Get("postalcode"))
+	} else {
+		found = f.search(q.Get("text"))
+	}
+	geoJSON(w, found, func(p Place) map[string]interface{} {
+		_, _, _, layer := p.kind()
+		return map[string]interface{}{
+			"label":      p.label() + ", USA",
+			"layer":      layer,
+			"postalcode": p.Zip,
+			"confidence": 1.0,
+			"match_type": "exact",
+		}
+	})
+}
+
+// census answers a batch: a multipart upload of addressFile, a CSV of ID, street, city, state
+// and ZIP, with a CSV of the same IDs
+func (f *fake) census(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST an addressFile", http.StatusMethodNotAllowed)
+		return
+	}
+	file, _, err := r.FormFile("addressFile")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	in := csv.NewReader(file)
+	in.FieldsPerRecord = -1
+	out := csv.NewWriter(w)
+	defer out.Flush()
+	for {
+		row, err := in.Read()
+		if err == io.EOF {
+			return
+		}
+		if err != nil || len(row) < 5 {
+			http.Error(w, "bad address file", http.StatusBadRequest)
+			return
+		}
+		input := strings.Join(row[1:5], ", ")
+		found := f.find(row[1], row[2], row[3], row[4])
+		switch len(found) {
+		case 0:
+			out.Write([]string{row[0], input, "No_Match"})
+		case 1:
+			p := found[0]
+			coords := strconv.FormatFloat(p.Lon, 'f', 6, 64) + "," + strconv.FormatFloat(p.Lat, 'f', 6, 64)
+			out.Write([]string{row[0], input, "Match", "Exact", strings.Join([]string{p.Street, p.City, p.State, p.Zip}, ", "), coords, "0", "L"})
+		default:
+			out.Write([]string{row[0], input, "Tie"})
+		}
+	}
+}
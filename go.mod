@@ -3,6 +3,6 @@ module github.com/skemper/hcip2
 go 1.15
 
 require (
-	github.com/TomOnTime/utfutil v0.0.0-20200626160131-0b0178852c8f
-	golang.org/x/text v0.3.4 // indirect
+	github.com/klauspost/compress v1.11.13
+	golang.org/x/text v0.3.4
 )
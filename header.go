@@ -0,0 +1,102 @@
+package hcip2
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ColumnMap maps each expected column (by its position in the layout) onto its position in the file
+type ColumnMap []int
+
+// HeaderError lists the ways a header row has drifted from the expected columns
+type HeaderError struct {
+	Missing []string // expected columns the header doesn't have at all
+	Moved   []string // expected columns found somewhere other than their usual place
+}
+
+func (e *HeaderError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing columns: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Moved) > 0 {
+		parts = append(parts, "moved columns: "+strings.Join(e.Moved, ", "))
+	}
+	return "header doesn't match layout (" + strings.Join(parts, "; ") + ")"
+}
+
+// MatchColumns finds every expected column in a header row by name.  The returned error is
+// a *HeaderError whenever the header differs; the ColumnMap is still usable as long as nothing is
+// Missing.  A nil ColumnMap means the header matches exactly and records need no rearranging.
+func MatchColumns(header []string, expected []string) (ColumnMap, error) {
+	found := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(cleanField(strings.TrimPrefix(name, "\ufeff")))
+		if _, dup := found[name]; !dup {
+			found[name] = i
+		}
+	}
+
+	herr := new(HeaderError)
+	cols := make(ColumnMap, len(expected))
+	for i, name := range expected {
+		idx, ok := found[strings.ToLower(name)]
+		if !ok {
+			herr.Missing = append(herr.Missing, name)
+			cols[i] = -1
+			continue
+		}
+		if idx != i {
+			herr.Moved = append(herr.Moved, fmt.Sprintf("%s (expected at %d, found at %d)", name, i, idx))
+		}
+		cols[i] = idx
+	}
+
+	if len(herr.Missing) > 0 || len(herr.Moved) > 0 {
+		return cols, herr
+	}
+	return nil, nil
+}
+
+// ResolveColumns is MatchColumns, except that columns which have only moved are resolved by
+// name and listed in drift rather than being an error; anything missing is still an error.
+func ResolveColumns(header []string, expected []string) (cols ColumnMap, drift *HeaderError, err error) {
+	cols, err = MatchColumns(header, expected)
+	if herr, ok := err.(*HeaderError); ok && len(herr.Missing) == 0 {
+		return cols, herr, nil
+	}
+	return cols, nil, err
+}
+
+// ResolveHeader checks a header row against config.Columns, as ResolveColumns does, telling
+// config.OnHeaderDrift about any columns that moved
+func (config *HciConfig) ResolveHeader(header []string) (ColumnMap, error) {
+	if len(config.Columns) == 0 {
+		return nil, nil // nothing to check against
+	}
+	cols, drift, err := ResolveColumns(header, config.Columns)
+	if drift != nil && config.OnHeaderDrift != nil {
+		config.OnHeaderDrift(drift)
+	}
+	return cols, err
+}
+
+// WarnHeaderDrift is the commands' OnHeaderDrift: it tells the user which header columns have
+// moved and are read by name
+func WarnHeaderDrift(herr *HeaderError) {
+	fmt.Printf("WARNING: %s, reading them by name\n", herr)
+}
+
+// Apply rearranges a record into the expected column order; missing columns come back empty
+func (cols ColumnMap) Apply(pieces []string) []string {
+	if cols == nil {
+		return pieces
+	}
+	out := make([]string, len(cols))
+	for i, idx := range cols {
+		if idx >= 0 && idx < len(pieces) {
+			out[i] = pieces[idx]
+		}
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package hcip2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchColumns(t *testing.T) {
+	expected := []string{"id", "name", "zip"}
+
+	cols, err := MatchColumns([]string{"\ufeffID", "Name", `"zip"`}, expected)
+	if cols != nil || err != nil {
+		t.Errorf("an exact header gave %v, %v", cols, err)
+	}
+
+	cols, err = MatchColumns([]string{"zip", "id", "name"}, expected)
+	herr, ok := err.(*HeaderError)
+	if !ok || len(herr.Moved) != 3 || len(herr.Missing) != 0 {
+		t.Fatalf("a shuffled header gave %v", err)
+	}
+	if want := (ColumnMap{1, 2, 0}); !reflect.DeepEqual(cols, want) {
+		t.Errorf("cols = %v, want %v", cols, want)
+	}
+	if got := cols.Apply([]string{"27601", "7", "Ann"}); !reflect.DeepEqual(got, []string{"7", "Ann", "27601"}) {
+		t.Errorf("Apply gave %v", got)
+	}
+
+	_, err = MatchColumns([]string{"id", "name"}, expected)
+	if herr, ok := err.(*HeaderError); !ok || !reflect.DeepEqual(herr.Missing, []string{"zip"}) {
+		t.Errorf("a short header gave %v", err)
+	}
+}
+
+func TestResolveHeaderReportsDrift(t *testing.T) {
+	var drift *HeaderError
+	config := HciConfig{Columns: []string{"id", "name"}, Delimiter: "\t"}
+	config.OnHeaderDrift = func(herr *HeaderError) { drift = herr }
+
+	rr, err := NewRecordReader(strings.NewReader("name\tid\nAnn\t7\n"), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drift == nil || len(drift.Moved) != 2 {
+		t.Errorf("drift = %v, want both columns moved", drift)
+	}
+	pieces, err := rr.Read()
+	if err != nil || !reflect.DeepEqual(pieces, []string{"7", "Ann"}) {
+		t.Errorf("Read = %v, %v", pieces, err)
+	}
+
+	drift = nil
+	if _, err = NewRecordReader(strings.NewReader("name\n"), config); err == nil {
+		t.Error("a header missing a column was accepted")
+	}
+	if drift != nil {
+		t.Error("a missing column was reported as drift")
+	}
+}
@@ -0,0 +1,27 @@
+package hcip2
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile writes contents to the file name in dir, returning its path
+func writeFile(t *testing.T, dir string, name string, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// loadLayout writes a JSON layout file to dir and loads it
+func loadLayout(t *testing.T, dir string, layout string) HciConfig {
+	t.Helper()
+	config, err := LoadConfig(writeFile(t, dir, "layout.json", layout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
@@ -0,0 +1,309 @@
+package hcip2
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// Layout is the on-disk description of a state's voter file, as read by LoadConfig
+type Layout struct {
+	StateAbbrev   string              `json:"state_abbrev"`    // two-letter postal code of the state
+	Columns       []string            `json:"columns"`         // column names, in file order
+	Header        string              `json:"header"`          // resolve, skip or none; defaults to resolve
+	Delimiter     string              `json:"delimiter"`       // defaults to a tab
+	Quoting       string              `json:"quoting"`         // strip, none or csv; defaults to strip
+	Encoding      string              `json:"encoding"`        // utf-8, utf-16, utf-16le, utf-16be or windows-1252; defaults to utf-8
+	DateFormat    string              `json:"date_format"`     // time.Parse layout; defaults to 2006-01-02
+	MaxLineLength int                 `json:"max_line_length"` // defaults to 1000
+	Road          []string            `json:"road"`            // columns joined to build the street address
+	RoadNoUnit    []string            `json:"road_no_unit"`    // same as Road, minus the apartment/unit columns
+	City          string              `json:"city"`
+	State         string              `json:"state"` // may be left out if every record is in StateAbbrev
+	Zip           string              `json:"zip"`
+	VoterID       string              `json:"voter_id"`
+	Fields        map[string]string   `json:"fields"`         // Voter field name -> column name
+	AddressFields map[string]string   `json:"address_fields"` // address part (Number, PreDir, Name...) -> column name
+	Filters       []FilterRule        `json:"filters"`        // a record is kept only if it passes every rule
+	Filter        string              `json:"filter"`         // a filter expression (see CompileFilter), on top of Filters
+	PII           map[string]string   `json:"pii"`            // column name -> drop or hash, for privacy mode
+	StatusCodes   map[string]string   `json:"status_codes"`   // Status_cd code -> active, inactive, removed or denied
+	Confidential  *FilterRule         `json:"confidential"`   // which records are confidential ("in") or not ("not_in")
+	Districts     map[string][]string `json:"districts"`      // district type -> its code column, then optionally its name column
+	Codes         map[string][]string `json:"codes"`          // coded column name -> its known values, for the quality report
+}
+
+// FilterRule keeps or drops records by the value of one column
+type FilterRule struct {
+	Column string   `json:"column"`
+	Op     string   `json:"op"` // "in" keeps records whose value is listed, "not_in" drops them
+	Values []string `json:"values"`
+}
+
+// encodings, quotings and headerPolicies map the names accepted in a layout file onto their values
+var encodings = map[string]Encoding{
+	"":             UTF8,
+	"utf-8":        UTF8,
+	"utf8":         UTF8,
+	"utf-16":       UTF16LE,
+	"utf-16le":     UTF16LE,
+	"utf-16be":     UTF16BE,
+	"windows-1252": Windows1252,
+	"cp1252":       Windows1252,
+}
+
+var quotings = map[string]Quoting{
+	"":      QuoteStrip,
+	"strip": QuoteStrip,
+	"none":  QuoteNone,
+	"csv":   QuoteCSV,
+}
+
+var headerPolicies = map[string]HeaderPolicy{
+	"":        HeaderResolve,
+	"resolve": HeaderResolve,
+	"skip":    HeaderSkip,
+	"none":    HeaderNone,
+}
+
+// GetConfig returns the built-in config for a state abbreviation, or loads name as a layout file
+func GetConfig(name string) (HciConfig, error) {
+	if config, ok := Configs[strings.ToUpper(name)]; ok {
+		return config, nil
+	}
+	if strings.HasSuffix(name, ".json") {
+		return LoadConfig(name)
+	}
+	return HciConfig{}, fmt.Errorf("Unknown state %s and not a .json layout file", name)
+}
+
+// LoadConfig reads a JSON layout file and turns it into an HciConfig
+func LoadConfig(path string) (HciConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return HciConfig{}, fmt.Errorf("Error reading layout %s: %s", path, err)
+	}
+	var layout Layout
+	if err = json.Unmarshal(data, &layout); err != nil {
+		return HciConfig{}, fmt.Errorf("Error parsing layout %s: %s", path, err)
+	}
+	config, err := layout.Config()
+	if err != nil {
+		return HciConfig{}, fmt.Errorf("Bad layout %s: %s", path, err)
+	}
+	config.Source = data
+	return config, nil
+}
+
+// Config resolves the column names of a Layout into the indices an HciConfig uses
+func (layout *Layout) Config() (HciConfig, error) {
+	if len(layout.Columns) == 0 {
+		return HciConfig{}, fmt.Errorf("no columns listed")
+	}
+
+	var missing []string
+	index := func(name string) int {
+		for i, col := range layout.Columns {
+			if strings.EqualFold(col, name) {
+				return i
+			}
+		}
+		missing = append(missing, fmt.Sprintf("%q", name))
+		return -1
+	}
+	indices := func(names []string) []int {
+		cols := make([]int, len(names))
+		for i, name := range names {
+			cols[i] = index(name)
+		}
+		return cols
+	}
+
+	encoding, ok := encodings[strings.ToLower(layout.Encoding)]
+	if !ok {
+		return HciConfig{}, fmt.Errorf("unknown encoding %s", layout.Encoding)
+	}
+	quoting, ok := quotings[strings.ToLower(layout.Quoting)]
+	if !ok {
+		return HciConfig{}, fmt.Errorf("unknown quoting %s", layout.Quoting)
+	}
+	header, ok := headerPolicies[strings.ToLower(layout.Header)]
+	if !ok {
+		return HciConfig{}, fmt.Errorf("unknown header policy %s", layout.Header)
+	}
+
+	config := HciConfig{
+		Columns:        layout.Columns,
+		StateAbbrev:    strings.ToUpper(layout.StateAbbrev),
+		Header:         header,
+		Road:           indices(layout.Road),
+		RoadNoUnit:     indices(layout.RoadNoUnit),
+		MaxLineLength:  layout.MaxLineLength,
+		CITY:           index(layout.City),
+		STATE:          -1,
+		ZIP:            index(layout.Zip),
+		STATE_VOTER_ID: index(layout.VoterID),
+		Delimiter:      layout.Delimiter,
+		Quoting:        quoting,
+		Encoding:       encoding,
+		DateFormat:     layout.DateFormat,
+		Fields:         make(map[string]int),
+	}
+	if layout.State != "" {
+		config.STATE = index(layout.State)
+	}
+	if config.Delimiter == "" {
+		config.Delimiter = "\t"
+	}
+	if config.DateFormat == "" {
+		config.DateFormat = "2006-01-02"
+	}
+	if config.MaxLineLength == 0 {
+		config.MaxLineLength = 1000
+	}
+
+	voterType := reflect.TypeOf(Voter{})
+	for field, name := range layout.Fields {
+		if _, ok := voterType.FieldByName(field); !ok {
+			return HciConfig{}, fmt.Errorf("Voter has no field %s", field)
+		}
+		config.Fields[field] = index(name)
+	}
+	if len(layout.AddressFields) > 0 {
+		config.AddressFields = make(map[string]int)
+		for part, name := range layout.AddressFields {
+			if !isAddressPart(part) {
+				return HciConfig{}, fmt.Errorf("no address part %s", part)
+			}
+			config.AddressFields[part] = index(name)
+		}
+	}
+
+	if len(layout.Districts) > 0 {
+		config.Districts = make(map[DistrictType]DistrictColumns)
+		for name, cols := range layout.Districts {
+			t, err := ParseDistrictType(name)
+			if err != nil {
+				return HciConfig{}, err
+			}
+			if len(cols) < 1 || len(cols) > 2 {
+				return HciConfig{}, fmt.Errorf("district %s needs a code column and optionally a name column", name)
+			}
+			dc := DistrictColumns{Code: index(cols[0]), Name: -1}
+			if len(cols) == 2 {
+				dc.Name = index(cols[1])
+			}
+			config.Districts[t] = dc
+		}
+	}
+
+	if len(layout.PII) > 0 {
+		config.PII = make(map[int]PIIAction)
+		for name, act := range layout.PII {
+			action, ok := piiActions[strings.ToLower(act)]
+			if !ok {
+				return HciConfig{}, fmt.Errorf("unknown PII action %s on %s", act, name)
+			}
+			config.PII[index(name)] = action
+		}
+	}
+
+	if len(layout.Codes) > 0 {
+		config.Codes = make(map[int][]string)
+		for name, values := range layout.Codes {
+			config.Codes[index(name)] = values
+		}
+	}
+
+	if len(layout.StatusCodes) > 0 {
+		if _, ok := config.Fields["Status_cd"]; !ok {
+			return HciConfig{}, fmt.Errorf("status_codes needs Status_cd in fields")
+		}
+		config.StatusCodes = make(map[string]Status)
+		for code, name := range layout.StatusCodes {
+			status, ok := statusNames[strings.ToLower(name)]
+			if !ok {
+				return HciConfig{}, fmt.Errorf("unknown status %s for code %s", name, code)
+			}
+			config.StatusCodes[code] = status
+		}
+	}
+	if rule := layout.Confidential; rule != nil {
+		if rule.Op != "in" && rule.Op != "not_in" {
+			return HciConfig{}, fmt.Errorf("unknown confidential op %s on %s", rule.Op, rule.Column)
+		}
+		confidential := filterRule{column: index(rule.Column), keep: rule.Op == "in", values: rule.Values}
+		config.Confidential = func(pieces []string) bool {
+			return confidential.passes(column(pieces, confidential.column))
+		}
+	}
+
+	rules := make([]filterRule, len(layout.Filters))
+	for i, rule := range layout.Filters {
+		if rule.Op != "in" && rule.Op != "not_in" {
+			return HciConfig{}, fmt.Errorf("unknown filter op %s on %s", rule.Op, rule.Column)
+		}
+		rules[i] = filterRule{column: index(rule.Column), keep: rule.Op == "in", values: rule.Values}
+	}
+
+	if len(missing) > 0 {
+		return HciConfig{}, fmt.Errorf("unknown columns %s", strings.Join(missing, ", "))
+	}
+
+	config.FilterStr = func(pieces []string) bool {
+		for _, rule := range rules {
+			if !rule.passes(column(pieces, rule.column)) {
+				return false
+			}
+		}
+		return true
+	}
+	config.FilterBytes = func(pieces [][]byte) bool {
+		for _, rule := range rules {
+			var val string
+			if rule.column < len(pieces) {
+				val = cleanField(string(pieces[rule.column]))
+			}
+			if !rule.passes(val) {
+				return false
+			}
+		}
+		return true
+	}
+	if layout.Filter != "" {
+		if err := config.AddFilter(layout.Filter); err != nil {
+			return HciConfig{}, err
+		}
+	}
+
+	return config, nil
+}
+
+func isAddressPart(part string) bool {
+	for _, p := range address.Parts {
+		if p == part {
+			return true
+		}
+	}
+	return false
+}
+
+// filterRule is a FilterRule with its column resolved
+type filterRule struct {
+	column int
+	keep   bool
+	values []string
+}
+
+func (rule *filterRule) passes(val string) bool {
+	for _, v := range rule.values {
+		if v == val {
+			return rule.keep
+		}
+	}
+	return !rule.keep
+}
@@ -0,0 +1,65 @@
+package hcip2
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestLayoutFilesMatchBuiltins keeps layouts/nc.json and layouts/wa.json describing the same
+// files as the compiled-in NC and WA
+func TestLayoutFilesMatchBuiltins(t *testing.T) {
+	for path, builtin := range map[string]HciConfig{"layouts/nc.json": NC, "layouts/wa.json": WA} {
+		loaded, err := LoadConfig(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range []string{"Columns", "Road", "RoadNoUnit", "MaxLineLength", "CITY", "STATE", "ZIP",
+			"STATE_VOTER_ID", "StateAbbrev", "Header", "Delimiter", "Quoting", "Encoding", "DateFormat",
+			"Fields", "AddressFields", "PII", "StatusCodes", "Codes", "Districts"} {
+			want := reflect.ValueOf(builtin).FieldByName(field).Interface()
+			got := reflect.ValueOf(loaded).FieldByName(field).Interface()
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %s is %v, but the built-in has %v", path, field, got, want)
+			}
+		}
+
+		// the rules compiled into funcs are compared by what they make of records
+		for _, confidential := range []string{"Y", "N", ""} {
+			pieces := make([]string, len(builtin.Columns))
+			if builtin.StateAbbrev == "NC" {
+				pieces[Confidential_ind] = confidential
+			}
+			pieces[builtin.Fields["Status_cd"]] = "A"
+			wantConf := builtin.Confidential != nil && builtin.Confidential(pieces)
+			gotConf := loaded.Confidential != nil && loaded.Confidential(pieces)
+			if gotConf != wantConf {
+				t.Errorf("%s: confidential(%q) is %v, but the built-in says %v", path, confidential, gotConf, wantConf)
+			}
+			if got, want := loaded.Keep(pieces), builtin.Keep(pieces); got != want {
+				t.Errorf("%s: keep(%q) is %v, but the built-in says %v", path, confidential, got, want)
+			}
+		}
+	}
+}
+
+func TestLayoutCodes(t *testing.T) {
+	layout := Layout{
+		Columns: []string{"id", "city", "zip", "party"},
+		VoterID: "id",
+		City:    "city",
+		Zip:     "zip",
+		Codes:   map[string][]string{"party": {"DEM", "REP"}},
+	}
+	config, err := layout.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int][]string{3: {"DEM", "REP"}}; !reflect.DeepEqual(config.Codes, want) {
+		t.Errorf("Codes = %v, want %v", config.Codes, want)
+	}
+
+	layout.Codes = map[string][]string{"nope": {"X"}}
+	if _, err := layout.Config(); err == nil {
+		t.Error("a code list for an unknown column was accepted")
+	}
+}
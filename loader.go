@@ -0,0 +1,82 @@
+package hcip2
+
+import (
+	"flag"
+	"fmt"
+)
+
+// VoterFlags are the command-line flags of the commands that load a whole voter file into
+// memory, as LoadVotersFromFlags does
+type VoterFlags struct {
+	State        *string
+	Voters       *string
+	Cache        *bool
+	Filter       *string
+	Status       *string
+	Confidential *bool
+	Rejects      *string
+	MaxRejects   *string
+}
+
+// NewVoterFlags defines the voter file flags on fs, usually flag.CommandLine
+func NewVoterFlags(fs *flag.FlagSet) *VoterFlags {
+	return &VoterFlags{
+		State:        fs.String("state", "NC", "state abbreviation or JSON layout of the voter file"),
+		Voters:       fs.String("voters", "VR_Snapshot_20201103.txt", "voter registration snapshot to load"),
+		Cache:        fs.Bool("cache", true, "keep a parsed copy of the snapshot beside it, for faster loads next time"),
+		Filter:       fs.String("filter", "", "only load the voters this filter expression is true for, e.g. 'County_id == 92'"),
+		Status:       fs.String("status", "active,inactive", "registration statuses to load: any of active, inactive, removed and denied, or all"),
+		Confidential: fs.Bool("confidential", false, "load confidential and exempt voters too"),
+		Rejects:      fs.String("rejects", "rejects.csv", "where to put the rows that can't be parsed"),
+		MaxRejects:   fs.String("max-rejects", "1%", "how many rows can be rejected before giving up: a count, a percentage of the rows, or none"),
+	}
+}
+
+// Config is the layout the flags name, with their status policy and filter, telling the user
+// about header drift
+func (f *VoterFlags) Config() (HciConfig, error) {
+	config, err := GetConfig(*f.State)
+	if err != nil {
+		return HciConfig{}, err
+	}
+	config.OnHeaderDrift = WarnHeaderDrift
+	if config.Policy, err = ParseStatusPolicy(*f.Status, *f.Confidential); err != nil {
+		return HciConfig{}, fmt.Errorf("Error in -status: %s", err)
+	}
+	if *f.Filter != "" {
+		if err = config.AddFilter(*f.Filter); err != nil {
+			return HciConfig{}, fmt.Errorf("Error in -filter: %s", err)
+		}
+	}
+	return config, nil
+}
+
+// LoadVotersFromFlags loads the voter file the flags name, through the cache if they ask for
+// it, filing the rows that don't parse in the reject file.  It returns the config it loaded
+// the file with, too.
+func LoadVotersFromFlags(f *VoterFlags) (map[string]*Voter, HciConfig, error) {
+	config, err := f.Config()
+	if err != nil {
+		return nil, HciConfig{}, err
+	}
+	budget, err := ParseBudget(*f.MaxRejects)
+	if err == nil {
+		config.Rejects, err = NewRejects(*f.Rejects, budget)
+	}
+	if err != nil {
+		return nil, HciConfig{}, fmt.Errorf("Error setting up rejects: %s", err)
+	}
+	var voters map[string]*Voter
+	if *f.Cache {
+		voters, err = LoadVotersCached(*f.Voters, config)
+	} else {
+		voters, err = LoadVoters(*f.Voters, config)
+	}
+	if closeErr := config.Rejects.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, HciConfig{}, err
+	}
+	return voters, config, nil
+}
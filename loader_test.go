@@ -0,0 +1,52 @@
+package hcip2
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadVotersFromFlags(t *testing.T) {
+	dir := t.TempDir()
+	layout := writeFile(t, dir, "layout.json", `{
+		"state_abbrev": "XX",
+		"columns": ["id", "age", "city", "zip"],
+		"delimiter": "|",
+		"voter_id": "id",
+		"city": "city",
+		"zip": "zip",
+		"fields": {"Age": "age"}
+	}`)
+	path := writeFile(t, dir, "voters.txt", "id|age|city|zip\n1|40|APEX|27502\n2|forty|APEX|27502\n3|50|CARY|27511\n")
+	rejects := filepath.Join(dir, "rejects.csv")
+
+	load := func(args ...string) (map[string]*Voter, HciConfig, error) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags := NewVoterFlags(fs)
+		if err := fs.Parse(append([]string{"-state", layout, "-voters", path, "-cache=false", "-rejects", rejects}, args...)); err != nil {
+			t.Fatal(err)
+		}
+		return LoadVotersFromFlags(flags)
+	}
+
+	voters, config, err := load("-filter", `zip == "27502"`, "-max-rejects", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.StateAbbrev != "XX" || len(voters) != 1 || voters["1"] == nil {
+		t.Errorf("loaded %v with %s", voters, config.StateAbbrev)
+	}
+	if rows := readRejects(t, rejects); len(rows) != 2 || rows[1][0] != "3" {
+		t.Errorf("reject file %q", rows)
+	}
+
+	if _, _, err := load("-max-rejects", "0"); err == nil {
+		t.Error("a reject over -max-rejects should stop the load")
+	}
+	if _, _, err := load("-status", "bogus"); err == nil {
+		t.Error("a bad -status should be an error")
+	}
+	if _, _, err := load("-filter", "zip =="); err == nil {
+		t.Error("a bad -filter should be an error")
+	}
+}
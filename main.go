@@ -3,23 +3,100 @@ package hcip2
 import (
 	"fmt"
 	"os"
+
+	"github.com/skemper/hcip2/address"
 )
 
 var Configs map[string]HciConfig = map[string]HciConfig{
 	"NC": NC,
 	"WA": WA,
+	"OH": OH,
+	"FL": FL,
+	"GA": GA,
+	"MI": MI,
+	"PA": PA,
 }
 
 type HciConfig struct {
+	Columns        []string // column names as they appear in the header row
+	Source         []byte   // the layout file the config was loaded from, if any, for the voter cache key
 	Road           []int
 	RoadNoUnit     []int
 	MaxLineLength  int
 	CITY           int
-	STATE          int
+	STATE          int // -1 if the file has no state column; StateAbbrev is used instead
 	ZIP            int
 	STATE_VOTER_ID int
-	FilterStr      func([]string) bool // returns `true` if we should KEEP the record
-	FilterBytes    func([][]byte) bool // returns `true` if we should KEEP the record
+	StateAbbrev    string                           // the state this layout belongs to
+	Header         HeaderPolicy                     // what to do with the first line of the file
+	Delimiter      string                           // separates the columns of a record
+	Quoting        Quoting                          // how values are quoted
+	Encoding       Encoding                         // text encoding to assume when the file has no BOM
+	DateFormat     string                           // time.Parse layout of the date columns
+	Fields         map[string]int                   // maps Voter field names onto columns; fields not listed are left empty
+	AddressFields  map[string]int                   // maps address.Parts onto the Road columns; if empty, Road is parsed instead
+	PII            map[int]PIIAction                // columns holding personal information, and what privacy mode does with them
+	StatusCodes    map[string]Status                // what the Status_cd codes mean; codes not listed count as removed
+	Codes          map[int][]string                 // the known values of coded columns, for the quality report
+	Districts      map[DistrictType]DistrictColumns // where each district level the layout has is kept
+	Confidential   func([]string) bool              // returns `true` for voters whose registration is confidential or exempt
+	Policy         StatusPolicy                     // which statuses Keep lets through; DefaultStatusPolicy if zero
+	FilterStr      func([]string) bool              // returns `true` if we should KEEP the record
+	FilterBytes    func([][]byte) bool              // returns `true` if we should KEEP the record
+	FilterExprs    []string                         // the filter expressions AddFilter has narrowed the filters with
+	Rejects        *Rejects                         // where rows that fail to parse go; if nil, the first one is an error
+	OnHeaderDrift  func(*HeaderError)               // told about header columns that moved and are read by name; may be nil
+}
+
+// VoterID returns the state voter ID of a split record, or "" if the record is too short to have one
+func (config *HciConfig) VoterID(pieces []string) string {
+	return column(pieces, config.STATE_VOTER_ID)
+}
+
+// CheckWidth returns an error if a record of n columns is too short to have the voter ID and
+// all the address columns the layout reads
+func (config *HciConfig) CheckWidth(n int) error {
+	need := config.STATE_VOTER_ID
+	cols := append([]int{config.CITY, config.STATE, config.ZIP}, config.Road...)
+	for _, col := range config.AddressFields {
+		cols = append(cols, col)
+	}
+	for _, col := range cols {
+		if col > need {
+			need = col
+		}
+	}
+	if n <= need {
+		return fmt.Errorf("short row: %d columns, but the layout reads %d", n, need+1)
+	}
+	return nil
+}
+
+// ResidentialState returns the state of a split record, falling back on StateAbbrev
+func (config *HciConfig) ResidentialState(pieces []string) string {
+	if state := column(pieces, config.STATE); state != "" {
+		return state
+	}
+	return config.StateAbbrev
+}
+
+// Address returns the standardized residential address of a split record
+func (config *HciConfig) Address(pieces []string) address.Address {
+	var addr address.Address
+	if len(config.AddressFields) > 0 {
+		for part, col := range config.AddressFields {
+			addr.Set(part, column(pieces, col))
+		}
+	} else {
+		addr = address.ParseStreet(joinRoad(pieces, config.Road))
+	}
+	if addr.Line() == "*" {
+		return address.Address{} // FL redacts exempt voters' addresses with a lone *
+	}
+	addr.City = unredacted(column(pieces, config.CITY))
+	addr.State = config.ResidentialState(pieces)
+	addr.Zip = unredacted(column(pieces, config.ZIP))
+	return address.Standardize(addr)
 }
 
 func NopFilterBytes(_ [][]byte) bool {
@@ -44,58 +121,60 @@ type JSONResult struct {
 	Category          string
 	Objtype           string `json:"type"`
 	Importance        float64
+	Address           map[string]string // the place's address parts, with addressdetails=1
 	StateVoterIDBytes [25]byte
 	StateVoterIDStr   string
 }
 
-// MakeFiles sets up files for spitting out good, no, and multi-result Nominatim searches
+// MakeFiles sets up files for spitting out good, no, and multi-result geocoder searches
 func MakeFiles() (goods *os.File, bads *os.File, multis *os.File) {
-	goods, err := os.OpenFile("goods.csv", os.O_WRONLY+os.O_CREATE, 0664)
-	// defer goods.Close()
-	if err != nil {
-		fmt.Printf("Error opening goods.csv: %s\n", err.Error())
-		os.Exit(1)
-	}
-
-	bads, err = os.OpenFile("bads.csv", os.O_WRONLY+os.O_CREATE, 0664)
-	// defer bads.Close()
-	if err != nil {
-		fmt.Printf("Error opening bads.csv: %s\n", err.Error())
-		os.Exit(1)
-	}
+	return MakeFilesWithPrefix("")
+}
 
-	multis, err = os.OpenFile("multis.csv", os.O_WRONLY+os.O_CREATE, 0664)
-	// defer multis.Close()
+// MakeFilesWithPrefix sets up files for spitting out good, no, and multi-result geocoder searches
+func MakeFilesWithPrefix(prefix string) (goods *os.File, bads *os.File, multis *os.File) {
+	goods, bads, multis, err := OpenOutputs(prefix, nil)
 	if err != nil {
-		fmt.Printf("Error opening multis.csv: %s\n", err.Error())
+		fmt.Printf("Error opening output files: %s\n", err)
 		os.Exit(1)
 	}
-
 	return goods, bads, multis
 }
 
-// MakeFilesWithPrefix sets up files for spitting out good, no, and multi-result Nominatim searches
-func MakeFilesWithPrefix(prefix string) (goods *os.File, bads *os.File, multis *os.File) {
-	goods, err := os.OpenFile(prefix+"goods.csv", os.O_WRONLY+os.O_CREATE, 0664)
-	// defer goods.Close()
-	if err != nil {
-		fmt.Printf("Error opening goods.csv: %s\n", err.Error())
-		os.Exit(1)
+// OpenOutputs opens the goods, bads and multis files, named with prefix.  A fresh run empties
+// them.  Resuming from cp cuts each back to its size at the checkpoint, dropping whatever a
+// crashed batch half-wrote, and appends from there.
+func OpenOutputs(prefix string, cp *Checkpoint) (goods *os.File, bads *os.File, multis *os.File, err error) {
+	files := make([]*os.File, 3)
+	for i, name := range []string{"goods.csv", "bads.csv", "multis.csv"} {
+		if files[i], err = OpenOutput(prefix+name, cp); err != nil {
+			for _, f := range files[:i] {
+				f.Close()
+			}
+			return nil, nil, nil, err
+		}
 	}
+	return files[0], files[1], files[2], nil
+}
 
-	bads, err = os.OpenFile(prefix+"bads.csv", os.O_WRONLY+os.O_CREATE, 0664)
-	// defer bads.Close()
-	if err != nil {
-		fmt.Printf("Error opening bads.csv: %s\n", err.Error())
-		os.Exit(1)
+// OpenOutput opens one output file the way OpenOutputs does
+func OpenOutput(path string, cp *Checkpoint) (*os.File, error) {
+	if cp == nil {
+		return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0664)
 	}
-
-	multis, err = os.OpenFile(prefix+"multis.csv", os.O_WRONLY+os.O_CREATE, 0664)
-	// defer multis.Close()
+	size, ok := cp.Outputs[path]
+	if !ok {
+		return nil, fmt.Errorf("checkpoint %s doesn't know %s", cp.Path, path)
+	}
+	info, err := os.Stat(path)
 	if err != nil {
-		fmt.Printf("Error opening multis.csv: %s\n", err.Error())
-		os.Exit(1)
+		return nil, err
 	}
-
-	return goods, bads, multis
+	if info.Size() < size {
+		return nil, fmt.Errorf("%s is shorter than it was at the checkpoint", path)
+	}
+	if err := os.Truncate(path, size); err != nil {
+		return nil, fmt.Errorf("Error rolling %s back to the checkpoint: %s", path, err)
+	}
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0664)
 }
@@ -0,0 +1,160 @@
+package hcip2
+
+// Michigan Qualified Voter File (QVF) entire state extract, comma separated with a header row.
+// EXTENSION holds the apartment/unit, and only the year of birth is published.
+
+const (
+	MI_LAST_NAME int = iota
+	MI_FIRST_NAME
+	MI_MIDDLE_NAME
+	MI_NAME_SUFFIX
+	MI_YEAR_OF_BIRTH
+	MI_GENDER
+	MI_REGISTRATION_DATE
+	MI_STREET_NUMBER_PREFIX
+	MI_STREET_NUMBER
+	MI_STREET_NUMBER_SUFFIX
+	MI_DIRECTION_PREFIX
+	MI_STREET_NAME
+	MI_STREET_TYPE
+	MI_DIRECTION_SUFFIX
+	MI_EXTENSION
+	MI_CITY
+	MI_STATE
+	MI_ZIP_CODE
+	MI_MAILING_ADDRESS_LINE_ONE
+	MI_MAILING_ADDRESS_LINE_TWO
+	MI_MAILING_ADDRESS_LINE_THREE
+	MI_MAILING_ADDRESS_LINE_FOUR
+	MI_MAILING_ADDRESS_LINE_FIVE
+	MI_VOTER_IDENTIFICATION_NUMBER
+	MI_COUNTY_CODE
+	MI_COUNTY_NAME
+	MI_JURISDICTION_CODE
+	MI_JURISDICTION_NAME
+	MI_PRECINCT
+	MI_WARD
+	MI_SCHOOL_DISTRICT_CODE
+	MI_SCHOOL_DISTRICT_NAME
+	MI_STATE_HOUSE_DISTRICT_CODE
+	MI_STATE_HOUSE_DISTRICT_NAME
+	MI_STATE_SENATE_DISTRICT_CODE
+	MI_STATE_SENATE_DISTRICT_NAME
+	MI_US_CONGRESS_DISTRICT_CODE
+	MI_US_CONGRESS_DISTRICT_NAME
+	MI_COUNTY_COMMISSIONER_DISTRICT_CODE
+	MI_COUNTY_COMMISSIONER_DISTRICT_NAME
+	MI_VILLAGE_DISTRICT_CODE
+	MI_VILLAGE_DISTRICT_NAME
+	MI_VILLAGE_PRECINCT
+	MI_SCHOOL_PRECINCT
+	MI_IS_PERM_AV_BALLOT_VOTER
+	MI_VOTER_STATUS_TYPE_CODE
+	MI_UOCAVA_STATUS_CODE
+	MI_UOCAVA_STATUS_NAME
+)
+
+// MIColumns are the header names of the MI voter file, in file order
+var MIColumns = []string{
+	"LAST_NAME",
+	"FIRST_NAME",
+	"MIDDLE_NAME",
+	"NAME_SUFFIX",
+	"YEAR_OF_BIRTH",
+	"GENDER",
+	"REGISTRATION_DATE",
+	"STREET_NUMBER_PREFIX",
+	"STREET_NUMBER",
+	"STREET_NUMBER_SUFFIX",
+	"DIRECTION_PREFIX",
+	"STREET_NAME",
+	"STREET_TYPE",
+	"DIRECTION_SUFFIX",
+	"EXTENSION",
+	"CITY",
+	"STATE",
+	"ZIP_CODE",
+	"MAILING_ADDRESS_LINE_ONE",
+	"MAILING_ADDRESS_LINE_TWO",
+	"MAILING_ADDRESS_LINE_THREE",
+	"MAILING_ADDRESS_LINE_FOUR",
+	"MAILING_ADDRESS_LINE_FIVE",
+	"VOTER_IDENTIFICATION_NUMBER",
+	"COUNTY_CODE",
+	"COUNTY_NAME",
+	"JURISDICTION_CODE",
+	"JURISDICTION_NAME",
+	"PRECINCT",
+	"WARD",
+	"SCHOOL_DISTRICT_CODE",
+	"SCHOOL_DISTRICT_NAME",
+	"STATE_HOUSE_DISTRICT_CODE",
+	"STATE_HOUSE_DISTRICT_NAME",
+	"STATE_SENATE_DISTRICT_CODE",
+	"STATE_SENATE_DISTRICT_NAME",
+	"US_CONGRESS_DISTRICT_CODE",
+	"US_CONGRESS_DISTRICT_NAME",
+	"COUNTY_COMMISSIONER_DISTRICT_CODE",
+	"COUNTY_COMMISSIONER_DISTRICT_NAME",
+	"VILLAGE_DISTRICT_CODE",
+	"VILLAGE_DISTRICT_NAME",
+	"VILLAGE_PRECINCT",
+	"SCHOOL_PRECINCT",
+	"IS_PERM_AV_BALLOT_VOTER",
+	"VOTER_STATUS_TYPE_CODE",
+	"UOCAVA_STATUS_CODE",
+	"UOCAVA_STATUS_NAME",
+}
+
+var MI HciConfig = HciConfig{
+	Columns:        MIColumns,
+	MaxLineLength:  1500,
+	CITY:           MI_CITY,
+	STATE:          MI_STATE,
+	ZIP:            MI_ZIP_CODE,
+	StateAbbrev:    "MI",
+	STATE_VOTER_ID: MI_VOTER_IDENTIFICATION_NUMBER,
+	Delimiter:      ",",
+	Encoding:       UTF8,
+	Quoting:        QuoteCSV,
+	DateFormat:     "01/02/2006",
+	Road:           []int{MI_STREET_NUMBER_PREFIX, MI_STREET_NUMBER, MI_STREET_NUMBER_SUFFIX, MI_DIRECTION_PREFIX, MI_STREET_NAME, MI_STREET_TYPE, MI_DIRECTION_SUFFIX, MI_EXTENSION},
+	RoadNoUnit:     []int{MI_STREET_NUMBER_PREFIX, MI_STREET_NUMBER, MI_STREET_NUMBER_SUFFIX, MI_DIRECTION_PREFIX, MI_STREET_NAME, MI_STREET_TYPE, MI_DIRECTION_SUFFIX},
+	// V(erify) and C(hallenged) are still registered
+	StatusCodes: map[string]Status{
+		"A": StatusActive,
+		"V": StatusActive,
+		"C": StatusActive,
+	},
+	PII: map[int]PIIAction{
+		MI_LAST_NAME:                  PIIDrop,
+		MI_FIRST_NAME:                 PIIDrop,
+		MI_MIDDLE_NAME:                PIIDrop,
+		MI_NAME_SUFFIX:                PIIDrop,
+		MI_YEAR_OF_BIRTH:              PIIDrop,
+		MI_STREET_NUMBER_PREFIX:       PIIDrop,
+		MI_STREET_NUMBER:              PIIDrop,
+		MI_STREET_NUMBER_SUFFIX:       PIIDrop,
+		MI_STREET_NAME:                PIIDrop,
+		MI_EXTENSION:                  PIIDrop,
+		MI_MAILING_ADDRESS_LINE_ONE:   PIIDrop,
+		MI_MAILING_ADDRESS_LINE_TWO:   PIIDrop,
+		MI_MAILING_ADDRESS_LINE_THREE: PIIDrop,
+		MI_MAILING_ADDRESS_LINE_FOUR:  PIIDrop,
+		MI_MAILING_ADDRESS_LINE_FIVE:  PIIDrop,
+	},
+	Fields: map[string]int{
+		"County_id":         MI_COUNTY_CODE,
+		"County_desc":       MI_COUNTY_NAME,
+		"Status_cd":         MI_VOTER_STATUS_TYPE_CODE,
+		"Voter_status_desc": MI_VOTER_STATUS_TYPE_CODE,
+		"Last_name":         MI_LAST_NAME,
+		"First_name":        MI_FIRST_NAME,
+		"Midl_name":         MI_MIDDLE_NAME,
+		"Name_sufx_cd":      MI_NAME_SUFFIX,
+		"Sex_code":          MI_GENDER,
+		"Birthdate":         MI_YEAR_OF_BIRTH,
+		"Registr_dt":        MI_REGISTRATION_DATE,
+		"Precinct_abbrv":    MI_PRECINCT,
+	},
+}
@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package hcip2
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps a whole file into memory read-only; call the returned func to unmap it
+func mapFile(path string) ([]byte, func(), error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() == 0 {
+		return nil, func() {}, nil
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() { syscall.Munmap(data) }, nil
+}
@@ -97,122 +97,247 @@ const (
 	Age_group                           //			char 35         Age group range
 )
 
-// Counties maps from county ID -> name
-var Counties = []string{"", // nothing at ID 0
-	"ALAMANCE",
-	"ALEXANDER",
-	"ALLEGHANY",
-	"ANSON",
-	"ASHE",
-	"AVERY",
-	"BEAUFORT",
-	"BERTIE",
-	"BLADEN",
-	"BRUNSWICK",
-	"BUNCOMBE",
-	"BURKE",
-	"CABARRUS",
-	"CALDWELL",
-	"CAMDEN",
-	"CARTERET",
-	"CASWELL",
-	"CATAWBA",
-	"CHATHAM",
-	"CHEROKEE",
-	"CHOWAN",
-	"CLAY",
-	"CLEVELAND",
-	"COLUMBUS",
-	"CRAVEN",
-	"CUMBERLAND",
-	"CURRITUCK",
-	"DARE",
-	"DAVIDSON",
-	"DAVIE",
-	"DUPLIN",
-	"DURHAM",
-	"EDGECOMBE",
-	"FORSYTH",
-	"FRANKLIN",
-	"GASTON",
-	"GATES",
-	"GRAHAM",
-	"GRANVILLE",
-	"GREENE",
-	"GUILFORD",
-	"HALIFAX",
-	"HARNETT",
-	"HAYWOOD",
-	"HENDERSON",
-	"HERTFORD",
-	"HOKE",
-	"HYDE",
-	"IREDELL",
-	"JACKSON",
-	"JOHNSTON",
-	"JONES",
-	"LEE",
-	"LENOIR",
-	"LINCOLN",
-	"MACON",
-	"MADISON",
-	"MARTIN",
-	"MCDOWELL",
-	"MECKLENBURG",
-	"MITCHELL",
-	"MONTGOMERY",
-	"MOORE",
-	"NASH",
-	"NEWHANOVER",
-	"NORTHAMPTON",
-	"ONSLOW",
-	"ORANGE",
-	"PAMLICO",
-	"PASQUOTANK",
-	"PENDER",
-	"PERQUIMANS",
-	"PERSON",
-	"PITT",
-	"POLK",
-	"RANDOLPH",
-	"RICHMOND",
-	"ROBESON",
-	"ROCKINGHAM",
-	"ROWAN",
-	"RUTHERFORD",
-	"SAMPSON",
-	"SCOTLAND",
-	"STANLY",
-	"STOKES",
-	"SURRY",
-	"SWAIN",
-	"TRANSYLVANIA",
-	"TYRRELL",
-	"UNION",
-	"VANCE",
-	"WAKE",
-	"WARREN",
-	"WASHINGTON",
-	"WATAUGA",
-	"WAYNE",
-	"WILKES",
-	"WILSON",
-	"YADKIN",
-	"YANCEY",
+// Counties maps from county ID -> name, run together as in NEWHANOVER.
+// Deprecated: use NCCounties or CountyByID, which have the FIPS codes to join on.
+var Counties = countyNames(NCCounties)
+
+// NCColumns are the header names of the NC snapshot, in file order
+var NCColumns = []string{
+	"snapshot_dt",
+	"county_id",
+	"county_desc",
+	"voter_reg_num",
+	"ncid",
+	"status_cd",
+	"voter_status_desc",
+	"reason_cd",
+	"voter_status_reason_desc",
+	"absent_ind",
+	"name_prefx_cd",
+	"last_name",
+	"first_name",
+	"midl_name",
+	"name_sufx_cd",
+	"house_num",
+	"half_code",
+	"street_dir",
+	"street_name",
+	"street_type_cd",
+	"street_sufx_cd",
+	"unit_designator",
+	"unit_num",
+	"res_city_desc",
+	"state_cd",
+	"zip_code",
+	"mail_addr1",
+	"mail_addr2",
+	"mail_addr3",
+	"mail_addr4",
+	"mail_city",
+	"mail_state",
+	"mail_zipcode",
+	"area_cd",
+	"phone_num",
+	"race_code",
+	"race_desc",
+	"ethnic_code",
+	"ethnic_desc",
+	"party_cd",
+	"party_desc",
+	"sex_code",
+	"sex",
+	"age",
+	"birth_place",
+	"registr_dt",
+	"precinct_abbrv",
+	"precinct_desc",
+	"municipality_abbrv",
+	"municipality_desc",
+	"ward_abbrv",
+	"ward_desc",
+	"cong_dist_abbrv",
+	"cong_dist_desc",
+	"super_court_abbrv",
+	"super_court_desc",
+	"judic_dist_abbrv",
+	"judic_dist_desc",
+	"nc_senate_abbrv",
+	"nc_senate_desc",
+	"nc_house_abbrv",
+	"nc_house_desc",
+	"county_commiss_abbrv",
+	"county_commiss_desc",
+	"township_abbrv",
+	"township_desc",
+	"school_dist_abbrv",
+	"school_dist_desc",
+	"fire_dist_abbrv",
+	"fire_dist_desc",
+	"water_dist_abbrv",
+	"water_dist_desc",
+	"sewer_dist_abbrv",
+	"sewer_dist_desc",
+	"sanit_dist_abbrv",
+	"sanit_dist_desc",
+	"rescue_dist_abbrv",
+	"rescue_dist_desc",
+	"munic_dist_abbrv",
+	"munic_dist_desc",
+	"dist_1_abbrv",
+	"dist_1_desc",
+	"dist_2_abbrv",
+	"dist_2_desc",
+	"confidential_ind",
+	"cancellation_dt",
+	"vtd_abbrv",
+	"vtd_desc",
+	"load_dt",
+	"age_group",
 }
 
 var NC HciConfig = HciConfig{
+	Columns:        NCColumns,
 	MaxLineLength:  1500,
 	CITY:           Res_city_desc,
 	STATE:          State_cd,
 	ZIP:            Zip_code,
+	StateAbbrev:    "NC",
 	STATE_VOTER_ID: Ncid,
+	Delimiter:      "\t",
+	Encoding:       UTF16LE,
+	DateFormat:     "2006-01-02",
 	Road:           []int{House_num, Half_code, Street_dir, Street_name, Street_type_cd, Street_sufx_cd, Unit_num},
 	RoadNoUnit:     []int{House_num, Half_code, Street_dir, Street_name, Street_type_cd, Street_sufx_cd},
-	FilterStr: func(pieces []string) bool {
-		// fmt.Println(pieces[Confidential_ind])
-		return pieces[Status_cd] != "R" && pieces[Confidential_ind] != "Y" // ignore all the REMOVED and CONFIDENTIAL users
+	AddressFields: map[string]int{
+		"Number":   House_num,
+		"Fraction": Half_code,
+		"PreDir":   Street_dir,
+		"Name":     Street_name,
+		"Suffix":   Street_type_cd,
+		"PostDir":  Street_sufx_cd,
+		"UnitType": Unit_designator,
+		"UnitNum":  Unit_num,
+	},
+	StatusCodes: map[string]Status{
+		"A": StatusActive,
+		"S": StatusActive, // temporary: military and overseas voters
+		"I": StatusInactive,
+		"R": StatusRemoved,
+		"D": StatusDenied,
+	},
+	Confidential: func(pieces []string) bool {
+		return column(pieces, Confidential_ind) == "Y"
+	},
+	Codes: map[int][]string{
+		Race_code:   {"A", "B", "I", "M", "O", "P", "U", "W"},
+		Ethnic_code: {"HL", "NL", "UN"},
+		Party_cd:    ncParties,
+		Sex_code:    {"F", "M", "U"},
+	},
+	PII: map[int]PIIAction{
+		Voter_reg_num: PIIHash,
+		Last_name:     PIIDrop,
+		First_name:    PIIDrop,
+		Midl_name:     PIIDrop,
+		Name_sufx_cd:  PIIDrop,
+		House_num:     PIIDrop,
+		Half_code:     PIIDrop,
+		Street_name:   PIIDrop,
+		Unit_num:      PIIDrop,
+		Mail_addr1:    PIIDrop,
+		Mail_addr2:    PIIDrop,
+		Mail_addr3:    PIIDrop,
+		Mail_addr4:    PIIDrop,
+		Area_cd:       PIIDrop,
+		Phone_num:     PIIDrop,
+	},
+	Districts: map[DistrictType]DistrictColumns{
+		DistrictPrecinct:         {Precinct_abbrv, Precinct_desc},
+		DistrictVTD:              {Vtd_abbrv, Vtd_desc},
+		DistrictCongressional:    {Cong_dist_abbrv, Cong_dist_desc},
+		DistrictStateSenate:      {NC_senate_abbrv, NC_senate_desc},
+		DistrictStateHouse:       {NC_house_abbrv, NC_house_desc},
+		DistrictSuperiorCourt:    {Super_court_abbrv, Super_court_desc},
+		DistrictJudicial:         {Judic_dist_abbrv, Judic_dist_desc},
+		DistrictProsecutorial:    {Dist_1_abbrv, Dist_1_desc},
+		DistrictCountyCommission: {County_commiss_abbrv, County_commiss_desc},
+		DistrictSchool:           {School_dist_abbrv, School_dist_desc},
+		DistrictMunicipality:     {Municipality_abbrv, Municipality_desc},
+		DistrictWard:             {Ward_abbrv, Ward_desc},
+		DistrictMunicipal:        {Munic_dist_abbrv, Munic_dist_desc},
+		DistrictTownship:         {Township_abbrv, Township_desc},
+		DistrictFire:             {Fire_dist_abbrv, Fire_dist_desc},
+		DistrictWater:            {Water_dist_abbrv, Water_dist_desc},
+		DistrictSewer:            {Sewer_dist_abbrv, Sewer_dist_desc},
+		DistrictSanitation:       {Sanit_dist_abbrv, Sanit_dist_desc},
+		DistrictRescue:           {Rescue_dist_abbrv, Rescue_dist_desc},
+	},
+	Fields: map[string]int{
+		"County_id":                County_id,
+		"County_desc":              County_desc,
+		"Voter_reg_num":            Voter_reg_num,
+		"Status_cd":                Status_cd,
+		"Voter_status_desc":        Voter_status_desc,
+		"Reason_cd":                Reason_cd,
+		"Voter_status_reason_desc": Voter_status_reason_desc,
+		"Last_name":                Last_name,
+		"First_name":               First_name,
+		"Midl_name":                Midl_name,
+		"Name_sufx_cd":             Name_sufx_cd,
+		"Race_code":                Race_code,
+		"Race_desc":                Race_desc,
+		"Ethnic_code":              Ethnic_code,
+		"Ethnic_desc":              Ethnic_desc,
+		"Party_cd":                 Party_cd,
+		"Sex_code":                 Sex_code,
+		"Age":                      Age,
+		"Registr_dt":               Registr_dt,
+		"Precinct_abbrv":           Precinct_abbrv,
+		"Precinct_desc":            Precinct_desc,
+		"Cancellation_dt":          Cancellation_dt,
+		"Vtd_abbrv":                Vtd_abbrv,
+		"Vtd_desc":                 Vtd_desc,
+		"Age_group":                Age_group,
 	},
 }
 
 const VoterIDLength = 12
+
+// ncParties are the party codes NC registers voters under
+var ncParties = []string{"CST", "DEM", "GRE", "LIB", "REP", "UNA"}
+
+// NCHistoryColumns are the header names of the ncvhis voting history file, in file order
+var NCHistoryColumns = []string{
+	"county_id",
+	"county_desc",
+	"voter_reg_num",
+	"election_lbl",
+	"election_desc",
+	"voting_method",
+	"voted_party_cd",
+	"voted_party_desc",
+	"pct_label",
+	"pct_description",
+	"ncid",
+	"voted_county_id",
+	"voted_county_desc",
+	"vtd_label",
+	"vtd_description",
+}
+
+// NCHistory reads the ncvhis voting history file; it has no address, so only the voter ID is set
+var NCHistory HciConfig = HciConfig{
+	Columns:        NCHistoryColumns,
+	MaxLineLength:  1000,
+	StateAbbrev:    "NC",
+	STATE_VOTER_ID: 10, // ncid
+	CITY:           -1,
+	STATE:          -1,
+	ZIP:            -1,
+	Delimiter:      "\t",
+	Encoding:       UTF8,
+	DateFormat:     "01/02/2006",
+	Codes: map[int][]string{
+		6: ncParties, // voted_party_cd
+	},
+}
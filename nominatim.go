@@ -0,0 +1,73 @@
+package hcip2
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// Nominatim geocodes with OpenStreetMap's Nominatim, through its /search API
+type Nominatim struct {
+	BaseURL string       // e.g. http://localhost/nominatim
+	Client  *http.Client // http.DefaultClient if nil
+}
+
+func (n *Nominatim) Name() string { return "nominatim" }
+
+// Geocode runs a structured search
+func (n *Nominatim) Geocode(addr address.Address) ([]Match, error) {
+	query := url.Values{}
+	setIf(query, "street", addr.Line())
+	setIf(query, "city", addr.City)
+	setIf(query, "state", addr.State)
+	setIf(query, "postalcode", addr.Zip)
+	return n.search(query)
+}
+
+// Search runs a free-form search
+func (n *Nominatim) Search(text string) ([]Match, error) {
+	return n.search(url.Values{"q": {text}})
+}
+
+func (n *Nominatim) search(query url.Values) ([]Match, error) {
+	query.Set("country", "us")
+	query.Set("format", "jsonv2")
+	query.Set("addressdetails", "1")
+	u := n.BaseURL + "/search?" + query.Encode()
+	var results []JSONResult
+	if err := getJSON(n.Client, u, &results); err != nil {
+		return nil, fmt.Errorf("Error calling Nominatim: %s", err)
+	}
+	matches := make([]Match, 0, len(results))
+	for _, r := range results {
+		lat, err := strconv.ParseFloat(r.Lat, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Bad latitude %q from %s", r.Lat, u)
+		}
+		lon, err := strconv.ParseFloat(r.Lon, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Bad longitude %q from %s", r.Lon, u)
+		}
+		matches = append(matches, Match{
+			Lat:      lat,
+			Lon:      lon,
+			Label:    r.DisplayName,
+			Kind:     r.Objtype,
+			Category: r.Category,
+			Rank:     r.PlaceRank,
+			Zip:      r.Address["postcode"],
+			Score:    r.Importance,
+		})
+	}
+	return matches, nil
+}
+
+// setIf sets a query parameter, unless val is blank
+func setIf(query url.Values, key string, val string) {
+	if val != "" {
+		query.Set(key, val)
+	}
+}
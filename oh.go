@@ -0,0 +1,152 @@
+package hcip2
+
+// Ohio Secretary of State statewide voter file (SWVF), one file per county group, comma separated
+// with every value in double quotes.  After WARD come one column per election, named like
+// GENERAL-11/03/2020, which the header check ignores.
+
+const (
+	OH_SOS_VOTERID int = iota
+	OH_COUNTY_NUMBER
+	OH_COUNTY_ID
+	OH_LAST_NAME
+	OH_FIRST_NAME
+	OH_MIDDLE_NAME
+	OH_SUFFIX
+	OH_DATE_OF_BIRTH
+	OH_REGISTRATION_DATE
+	OH_VOTER_STATUS
+	OH_PARTY_AFFILIATION
+	OH_RESIDENTIAL_ADDRESS1
+	OH_RESIDENTIAL_SECONDARY_ADDR
+	OH_RESIDENTIAL_CITY
+	OH_RESIDENTIAL_STATE
+	OH_RESIDENTIAL_ZIP
+	OH_RESIDENTIAL_ZIP_PLUS4
+	OH_RESIDENTIAL_COUNTRY
+	OH_RESIDENTIAL_POSTALCODE
+	OH_MAILING_ADDRESS1
+	OH_MAILING_SECONDARY_ADDRESS
+	OH_MAILING_CITY
+	OH_MAILING_STATE
+	OH_MAILING_ZIP
+	OH_MAILING_ZIP_PLUS4
+	OH_MAILING_COUNTRY
+	OH_MAILING_POSTAL_CODE
+	OH_CAREER_CENTER
+	OH_CITY
+	OH_CITY_SCHOOL_DISTRICT
+	OH_COUNTY_COURT_DISTRICT
+	OH_CONGRESSIONAL_DISTRICT
+	OH_COURT_OF_APPEALS
+	OH_EDU_SERVICE_CENTER_DISTRICT
+	OH_EXEMPTED_VILL_SCHOOL_DISTRICT
+	OH_LIBRARY
+	OH_LOCAL_SCHOOL_DISTRICT
+	OH_MUNICIPAL_COURT_DISTRICT
+	OH_PRECINCT_NAME
+	OH_PRECINCT_CODE
+	OH_STATE_BOARD_OF_EDUCATION
+	OH_STATE_REPRESENTATIVE_DISTRICT
+	OH_STATE_SENATE_DISTRICT
+	OH_TOWNSHIP
+	OH_VILLAGE
+	OH_WARD
+)
+
+// OHColumns are the header names of the OH voter file, in file order
+var OHColumns = []string{
+	"SOS_VOTERID",
+	"COUNTY_NUMBER",
+	"COUNTY_ID",
+	"LAST_NAME",
+	"FIRST_NAME",
+	"MIDDLE_NAME",
+	"SUFFIX",
+	"DATE_OF_BIRTH",
+	"REGISTRATION_DATE",
+	"VOTER_STATUS",
+	"PARTY_AFFILIATION",
+	"RESIDENTIAL_ADDRESS1",
+	"RESIDENTIAL_SECONDARY_ADDR",
+	"RESIDENTIAL_CITY",
+	"RESIDENTIAL_STATE",
+	"RESIDENTIAL_ZIP",
+	"RESIDENTIAL_ZIP_PLUS4",
+	"RESIDENTIAL_COUNTRY",
+	"RESIDENTIAL_POSTALCODE",
+	"MAILING_ADDRESS1",
+	"MAILING_SECONDARY_ADDRESS",
+	"MAILING_CITY",
+	"MAILING_STATE",
+	"MAILING_ZIP",
+	"MAILING_ZIP_PLUS4",
+	"MAILING_COUNTRY",
+	"MAILING_POSTAL_CODE",
+	"CAREER_CENTER",
+	"CITY",
+	"CITY_SCHOOL_DISTRICT",
+	"COUNTY_COURT_DISTRICT",
+	"CONGRESSIONAL_DISTRICT",
+	"COURT_OF_APPEALS",
+	"EDU_SERVICE_CENTER_DISTRICT",
+	"EXEMPTED_VILL_SCHOOL_DISTRICT",
+	"LIBRARY",
+	"LOCAL_SCHOOL_DISTRICT",
+	"MUNICIPAL_COURT_DISTRICT",
+	"PRECINCT_NAME",
+	"PRECINCT_CODE",
+	"STATE_BOARD_OF_EDUCATION",
+	"STATE_REPRESENTATIVE_DISTRICT",
+	"STATE_SENATE_DISTRICT",
+	"TOWNSHIP",
+	"VILLAGE",
+	"WARD",
+}
+
+var OH HciConfig = HciConfig{
+	Columns:        OHColumns,
+	MaxLineLength:  2000,
+	CITY:           OH_RESIDENTIAL_CITY,
+	STATE:          OH_RESIDENTIAL_STATE,
+	ZIP:            OH_RESIDENTIAL_ZIP,
+	StateAbbrev:    "OH",
+	STATE_VOTER_ID: OH_SOS_VOTERID,
+	Delimiter:      ",",
+	Encoding:       UTF8,
+	Quoting:        QuoteCSV,
+	DateFormat:     "2006-01-02",
+	Road:           []int{OH_RESIDENTIAL_ADDRESS1, OH_RESIDENTIAL_SECONDARY_ADDR},
+	RoadNoUnit:     []int{OH_RESIDENTIAL_ADDRESS1},
+	// the only statuses published
+	StatusCodes: map[string]Status{
+		"ACTIVE":       StatusActive,
+		"CONFIRMATION": StatusInactive,
+	},
+	PII: map[int]PIIAction{
+		OH_COUNTY_ID:                  PIIHash,
+		OH_LAST_NAME:                  PIIDrop,
+		OH_FIRST_NAME:                 PIIDrop,
+		OH_MIDDLE_NAME:                PIIDrop,
+		OH_SUFFIX:                     PIIDrop,
+		OH_DATE_OF_BIRTH:              PIIDrop,
+		OH_RESIDENTIAL_ADDRESS1:       PIIDrop,
+		OH_RESIDENTIAL_SECONDARY_ADDR: PIIDrop,
+		OH_MAILING_ADDRESS1:           PIIDrop,
+		OH_MAILING_SECONDARY_ADDRESS:  PIIDrop,
+	},
+	Fields: map[string]int{
+		"County_id":         OH_COUNTY_NUMBER,
+		"Voter_reg_num":     OH_COUNTY_ID,
+		"Status_cd":         OH_VOTER_STATUS,
+		"Voter_status_desc": OH_VOTER_STATUS,
+		"Last_name":         OH_LAST_NAME,
+		"First_name":        OH_FIRST_NAME,
+		"Midl_name":         OH_MIDDLE_NAME,
+		"Name_sufx_cd":      OH_SUFFIX,
+		"Party_cd":          OH_PARTY_AFFILIATION,
+		"Birthdate":         OH_DATE_OF_BIRTH,
+		"Registr_dt":        OH_REGISTRATION_DATE,
+		"Precinct_abbrv":    OH_PRECINCT_CODE,
+		"Precinct_desc":     OH_PRECINCT_NAME,
+	},
+}
@@ -0,0 +1,154 @@
+package hcip2
+
+import "fmt"
+
+// Pennsylvania Department of State Full Voter Export (FVE), one tab separated file per county with
+// no header row and every value in double quotes.  After the fixed columns come 40 district codes,
+// then a vote method and party for each of 40 elections, then the phone, county and mail country.
+
+// PADistricts and PAElections are how many district and election slots every FVE record carries
+const (
+	PADistricts = 40
+	PAElections = 40
+)
+
+const (
+	PA_ID_Number int = iota
+	PA_Title
+	PA_Last_Name
+	PA_First_Name
+	PA_Middle_Name
+	PA_Suffix
+	PA_Gender
+	PA_DOB
+	PA_Registration_Date
+	PA_Voter_Status
+	PA_Status_Change_Date
+	PA_Party_Code
+	PA_House_Number
+	PA_House_Number_Suffix
+	PA_Street_Name
+	PA_Apartment_Number
+	PA_Address_Line_2
+	PA_City
+	PA_State
+	PA_Zip
+	PA_Mail_Address_1
+	PA_Mail_Address_2
+	PA_Mail_City
+	PA_Mail_State
+	PA_Mail_Zip
+	PA_Last_Vote_Date
+	PA_Precinct_Code
+	PA_Precinct_Split_ID
+	PA_Date_Last_Changed
+	PA_Custom_Data_1
+	PA_District_1 // first of PADistricts district codes
+)
+
+const (
+	PA_Election_1_Vote_Method int = PA_District_1 + PADistricts // first of PAElections method/party pairs
+	PA_Election_1_Party       int = PA_Election_1_Vote_Method + 1
+)
+
+const (
+	PA_Home_Phone int = PA_Election_1_Vote_Method + 2*PAElections + iota
+	PA_County
+	PA_Mail_Country
+)
+
+// PAColumns are the column names of the PA voter file, in file order
+var PAColumns = paColumns()
+
+func paColumns() []string {
+	columns := []string{
+		"ID Number",
+		"Title",
+		"Last Name",
+		"First Name",
+		"Middle Name",
+		"Suffix",
+		"Gender",
+		"DOB",
+		"Registration Date",
+		"Voter Status",
+		"Status Change Date",
+		"Party Code",
+		"House Number",
+		"House Number Suffix",
+		"Street Name",
+		"Apartment Number",
+		"Address Line 2",
+		"City",
+		"State",
+		"Zip",
+		"Mail Address 1",
+		"Mail Address 2",
+		"Mail City",
+		"Mail State",
+		"Mail Zip",
+		"Last Vote Date",
+		"Precinct Code",
+		"Precinct Split ID",
+		"Date Last Changed",
+		"Custom Data 1",
+	}
+	for i := 1; i <= PADistricts; i++ {
+		columns = append(columns, fmt.Sprintf("District %d", i))
+	}
+	for i := 1; i <= PAElections; i++ {
+		columns = append(columns, fmt.Sprintf("Election %d Vote Method", i), fmt.Sprintf("Election %d Party", i))
+	}
+	return append(columns, "Home Phone", "County", "Mail Country")
+}
+
+var PA HciConfig = HciConfig{
+	Columns:        PAColumns,
+	Header:         HeaderNone,
+	MaxLineLength:  2000,
+	CITY:           PA_City,
+	STATE:          PA_State,
+	ZIP:            PA_Zip,
+	StateAbbrev:    "PA",
+	STATE_VOTER_ID: PA_ID_Number,
+	Delimiter:      "\t",
+	Encoding:       UTF8,
+	Quoting:        QuoteCSV,
+	DateFormat:     "01/02/2006",
+	Road:           []int{PA_House_Number, PA_House_Number_Suffix, PA_Street_Name, PA_Apartment_Number},
+	RoadNoUnit:     []int{PA_House_Number, PA_House_Number_Suffix, PA_Street_Name},
+	StatusCodes: map[string]Status{
+		"A": StatusActive,
+		"I": StatusInactive,
+	},
+	PII: map[int]PIIAction{
+		PA_Title:               PIIDrop,
+		PA_Last_Name:           PIIDrop,
+		PA_First_Name:          PIIDrop,
+		PA_Middle_Name:         PIIDrop,
+		PA_Suffix:              PIIDrop,
+		PA_DOB:                 PIIDrop,
+		PA_House_Number:        PIIDrop,
+		PA_House_Number_Suffix: PIIDrop,
+		PA_Street_Name:         PIIDrop,
+		PA_Apartment_Number:    PIIDrop,
+		PA_Address_Line_2:      PIIDrop,
+		PA_Mail_Address_1:      PIIDrop,
+		PA_Mail_Address_2:      PIIDrop,
+		PA_Home_Phone:          PIIDrop,
+	},
+	Fields: map[string]int{
+		"County_desc":       PA_County,
+		"Status_cd":         PA_Voter_Status,
+		"Voter_status_desc": PA_Voter_Status,
+		"Last_name":         PA_Last_Name,
+		"First_name":        PA_First_Name,
+		"Midl_name":         PA_Middle_Name,
+		"Name_sufx_cd":      PA_Suffix,
+		"Party_cd":          PA_Party_Code,
+		"Sex_code":          PA_Gender,
+		"Birthdate":         PA_DOB,
+		"Registr_dt":        PA_Registration_Date,
+		"Precinct_abbrv":    PA_Precinct_Code,
+	},
+}
@@ -0,0 +1,67 @@
+package hcip2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// Pelias geocodes with Pelias, through /v1/search/structured and /v1/search
+type Pelias struct {
+	BaseURL string       // e.g. http://localhost:4000
+	Client  *http.Client // http.DefaultClient if nil
+	APIKey  string       // for hosted Pelias, e.g. geocode.earth; left out if empty
+}
+
+// peliasProperties are the parts of a Pelias feature's properties we use
+type peliasProperties struct {
+	Label      string  `json:"label"`
+	Layer      string  `json:"layer"`
+	PostalCode string  `json:"postalcode"`
+	Confidence float64 `json:"confidence"`
+}
+
+func (p *Pelias) Name() string { return "pelias" }
+
+// Geocode runs a structured search
+func (p *Pelias) Geocode(addr address.Address) ([]Match, error) {
+	query := url.Values{"country": {"USA"}}
+	setIf(query, "address", addr.Line())
+	setIf(query, "locality", addr.City)
+	setIf(query, "region", addr.State)
+	setIf(query, "postalcode", addr.Zip)
+	return p.search("/v1/search/structured", query)
+}
+
+// Search runs a free-form search
+func (p *Pelias) Search(text string) ([]Match, error) {
+	return p.search("/v1/search", url.Values{"text": {text}, "boundary.country": {"USA"}})
+}
+
+func (p *Pelias) search(path string, query url.Values) ([]Match, error) {
+	setIf(query, "api_key", p.APIKey)
+	u := p.BaseURL + path + "?" + query.Encode()
+	var fc geoJSON
+	if err := getJSON(p.Client, u, &fc); err != nil {
+		return nil, fmt.Errorf("Error calling Pelias: %s", err)
+	}
+	matches := make([]Match, 0, len(fc.Features))
+	for _, f := range fc.Features {
+		var props peliasProperties
+		if err := json.Unmarshal(f.Properties, &props); err != nil {
+			return nil, fmt.Errorf("Error decoding JSON from %s: %s", p.BaseURL+path, err)
+		}
+		matches = append(matches, Match{
+			Lat:   f.Geometry.Coordinates[1],
+			Lon:   f.Geometry.Coordinates[0],
+			Label: props.Label,
+			Kind:  props.Layer,
+			Zip:   props.PostalCode,
+			Score: props.Confidence,
+		})
+	}
+	return matches, nil
+}
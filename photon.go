@@ -0,0 +1,83 @@
+package hcip2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// Photon geocodes with komoot's Photon, through its /api search.  Photon only takes free-form
+// text, so Geocode writes the address out on one line.
+type Photon struct {
+	BaseURL string       // e.g. http://localhost:2322
+	Client  *http.Client // http.DefaultClient if nil
+	Limit   int          // how many matches to ask for; 5 if 0
+}
+
+// photonProperties are the parts of a Photon feature's properties we use
+type photonProperties struct {
+	Name        string `json:"name"`
+	HouseNumber string `json:"housenumber"`
+	Street      string `json:"street"`
+	City        string `json:"city"`
+	State       string `json:"state"`
+	Postcode    string `json:"postcode"`
+	OSMKey      string `json:"osm_key"`
+	OSMValue    string `json:"osm_value"`
+	Type        string `json:"type"`
+}
+
+func (p *Photon) Name() string { return "photon" }
+
+// Geocode searches for the address written out on one line
+func (p *Photon) Geocode(addr address.Address) ([]Match, error) {
+	return p.Search(addr.String())
+}
+
+// Search runs a free-form search
+func (p *Photon) Search(text string) ([]Match, error) {
+	limit := p.Limit
+	if limit == 0 {
+		limit = 5
+	}
+	u := p.BaseURL + "/api?" + url.Values{"q": {text}, "limit": {fmt.Sprint(limit)}}.Encode()
+	var fc geoJSON
+	if err := getJSON(p.Client, u, &fc); err != nil {
+		return nil, fmt.Errorf("Error calling Photon: %s", err)
+	}
+	matches := make([]Match, 0, len(fc.Features))
+	for _, f := range fc.Features {
+		var props photonProperties
+		if err := json.Unmarshal(f.Properties, &props); err != nil {
+			return nil, fmt.Errorf("Error decoding JSON from %s: %s", u, err)
+		}
+		kind := props.Type
+		if kind == "" {
+			kind = props.OSMValue
+		}
+		matches = append(matches, Match{
+			Lat:      f.Geometry.Coordinates[1],
+			Lon:      f.Geometry.Coordinates[0],
+			Label:    props.label(),
+			Kind:     kind,
+			Category: props.OSMKey,
+			Zip:      props.Postcode,
+		})
+	}
+	return matches, nil
+}
+
+// label writes the properties out the way Nominatim's display_name would
+func (props photonProperties) label() string {
+	var parts []string
+	for _, part := range []string{props.Name, strings.TrimSpace(props.HouseNumber + " " + props.Street), props.City, props.State, props.Postcode} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
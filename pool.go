@@ -0,0 +1,127 @@
+package hcip2
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// GeocodePool geocodes many addresses at once on a fixed number of workers, optionally held
+// to a number of requests per second.  The answers come back in the order the addresses went
+// in, however the requests finish.
+type GeocodePool struct {
+	Geocoder Geocoder
+	Workers  int // how many requests can be in flight at once
+	limiter  *rateLimiter
+}
+
+// NewGeocodePool makes a pool of workers sending g's requests, no more than rate a second
+// between them if rate is above 0.  Give g a client from NewHTTPClient so the workers' connections
+// are kept open.
+func NewGeocodePool(g Geocoder, workers int, rate float64) *GeocodePool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &GeocodePool{Geocoder: g, Workers: workers}
+	if rate > 0 {
+		p.limiter = &rateLimiter{interval: time.Duration(float64(time.Second) / rate)}
+	}
+	return p
+}
+
+// NewHTTPClient makes a client that keeps up to conns connections to the geocoder alive between
+// requests, since http.DefaultClient keeps only two and a pool would keep reconnecting, and that
+// retries failed requests as policy says
+func NewHTTPClient(conns int, policy RetryPolicy) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = conns
+	transport.MaxIdleConnsPerHost = conns
+	return &http.Client{Transport: &retryTransport{base: transport, policy: policy}}
+}
+
+// GeocodeAll geocodes every address, returning its matches and its error at the same index.
+// An error is the geocoder failing on that address and says nothing about the others.
+// Geocoders that take batches get the addresses split into one batch per worker.
+func (p *GeocodePool) GeocodeAll(addrs []address.Address) ([][]Match, []error) {
+	matches := make([][]Match, len(addrs))
+	errs := make([]error, len(addrs))
+	bg, batches := p.Geocoder.(BatchGeocoder)
+	if !batches {
+		p.run(len(addrs), 1, func(i int, _ int) {
+			matches[i], errs[i] = p.Geocoder.Geocode(addrs[i])
+		})
+		return matches, errs
+	}
+	p.run(len(addrs), (len(addrs)+p.Workers-1)/p.Workers, func(start int, end int) {
+		batch, err := bg.GeocodeBatch(addrs[start:end])
+		for i := start; i < end; i++ {
+			if err != nil {
+				errs[i] = err
+			} else {
+				matches[i] = batch[i-start]
+			}
+		}
+	})
+	return matches, errs
+}
+
+// SearchAll is GeocodeAll for free-form searches
+func (p *GeocodePool) SearchAll(texts []string) ([][]Match, []error) {
+	matches := make([][]Match, len(texts))
+	errs := make([]error, len(texts))
+	p.run(len(texts), 1, func(i int, _ int) {
+		matches[i], errs[i] = p.Geocoder.Search(texts[i])
+	})
+	return matches, errs
+}
+
+// run splits n items into chunks of size and has the workers call call on each, as the rate
+// allows, returning once they're all done
+func (p *GeocodePool) run(n int, size int, call func(start int, end int)) {
+	starts := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < p.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range starts {
+				end := start + size
+				if end > n {
+					end = n
+				}
+				p.limiter.wait()
+				call(start, end)
+			}
+		}()
+	}
+	for start := 0; start < n; start += size {
+		starts <- start
+	}
+	close(starts)
+	wg.Wait()
+}
+
+// rateLimiter spaces calls to wait at least interval apart; a nil *rateLimiter never waits
+type rateLimiter struct {
+	interval time.Duration
+	next     time.Time
+	mu       sync.Mutex
+}
+
+// wait blocks until the caller's turn to send a request
+func (rl *rateLimiter) wait() {
+	if rl == nil {
+		return
+	}
+	rl.mu.Lock()
+	now := time.Now()
+	slot := rl.next
+	if slot.Before(now) {
+		slot = now
+	}
+	rl.next = slot.Add(rl.interval)
+	rl.mu.Unlock()
+	time.Sleep(slot.Sub(now))
+}
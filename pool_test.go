@@ -0,0 +1,133 @@
+package hcip2
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// slowGeocoder answers each address with its house number as the latitude, after a pause, and
+// keeps track of how many requests it has in flight
+type slowGeocoder struct {
+	pause    time.Duration
+	mu       sync.Mutex
+	inFlight int
+	most     int
+	batches  [][]address.Address
+}
+
+func (g *slowGeocoder) Name() string { return "slow" }
+
+func (g *slowGeocoder) Geocode(addr address.Address) ([]Match, error) {
+	g.mu.Lock()
+	g.inFlight++
+	if g.inFlight > g.most {
+		g.most = g.inFlight
+	}
+	g.mu.Unlock()
+	time.Sleep(g.pause)
+	g.mu.Lock()
+	g.inFlight--
+	g.mu.Unlock()
+	if addr.Number == "13" {
+		return nil, errors.New("unlucky")
+	}
+	var lat float64
+	fmt.Sscan(addr.Number, &lat)
+	return []Match{{Lat: lat}}, nil
+}
+
+func (g *slowGeocoder) Search(text string) ([]Match, error) {
+	return g.Geocode(address.Address{Number: text})
+}
+
+// slowBatchGeocoder is a slowGeocoder that takes batches
+type slowBatchGeocoder struct {
+	slowGeocoder
+}
+
+func (g *slowBatchGeocoder) GeocodeBatch(addrs []address.Address) ([][]Match, error) {
+	g.mu.Lock()
+	g.batches = append(g.batches, addrs)
+	g.mu.Unlock()
+	matches := make([][]Match, len(addrs))
+	for i, addr := range addrs {
+		var err error
+		if matches[i], err = g.Geocode(addr); err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+func numbered(n int) []address.Address {
+	addrs := make([]address.Address, n)
+	for i := range addrs {
+		addrs[i].Number = fmt.Sprint(i)
+	}
+	return addrs
+}
+
+func TestGeocodePool(t *testing.T) {
+	g := &slowGeocoder{pause: 5 * time.Millisecond}
+	pool := NewGeocodePool(g, 4, 0)
+	matches, errs := pool.GeocodeAll(numbered(20))
+	for i := range matches {
+		switch {
+		case i == 13:
+			if errs[i] == nil || matches[i] != nil {
+				t.Errorf("address 13: %v, %v", matches[i], errs[i])
+			}
+		case errs[i] != nil || len(matches[i]) != 1 || matches[i][0].Lat != float64(i):
+			t.Errorf("address %d: %v, %v", i, matches[i], errs[i])
+		}
+	}
+	if g.most != 4 {
+		t.Errorf("%d requests in flight at most, want 4", g.most)
+	}
+
+	matches, errs = pool.SearchAll([]string{"7", "13"})
+	if len(matches[0]) != 1 || matches[0][0].Lat != 7 || errs[0] != nil || errs[1] == nil {
+		t.Errorf("SearchAll: %v, %v", matches, errs)
+	}
+
+	if NewGeocodePool(g, 0, 0).Workers != 1 {
+		t.Error("a pool needs a worker")
+	}
+}
+
+func TestGeocodePoolBatches(t *testing.T) {
+	g := &slowBatchGeocoder{}
+	NewGeocodePool(g, 3, 0).GeocodeAll(numbered(10))
+	sizes := map[string]int{}
+	for _, batch := range g.batches {
+		sizes[batch[0].Number] = len(batch)
+	}
+	if want := map[string]int{"0": 4, "4": 4, "8": 2}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("batches %v, want %v", sizes, want)
+	}
+
+	// a failed batch fails every address in it
+	g = &slowBatchGeocoder{}
+	matches, errs := NewGeocodePool(g, 4, 0).GeocodeAll(numbered(16))
+	for i := range matches {
+		failed := i >= 12
+		if failed != (errs[i] != nil) || !failed && matches[i][0].Lat != float64(i) {
+			t.Errorf("address %d: %v, %v", i, matches[i], errs[i])
+		}
+	}
+}
+
+func TestGeocodePoolRate(t *testing.T) {
+	start := time.Now()
+	NewGeocodePool(&slowGeocoder{}, 4, 100).GeocodeAll(numbered(6))
+	// the first goes right away, then one every 10ms
+	if took := time.Since(start); took < 50*time.Millisecond {
+		t.Errorf("6 requests at 100 a second took %s", took)
+	}
+}
@@ -0,0 +1,126 @@
+package hcip2
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// PIIAction says what privacy mode does with a column of personal information
+type PIIAction int
+
+const (
+	PIIDrop PIIAction = iota + 1 // blank the value out
+	PIIHash                      // replace the value with a keyed hash, so files can still be joined on it
+)
+
+// piiActions maps the names accepted in a layout file onto PIIActions
+var piiActions = map[string]PIIAction{
+	"drop": PIIDrop,
+	"hash": PIIHash,
+}
+
+// PrivacyKeyEnv names the environment variable LoadPrivacyKey falls back on
+const PrivacyKeyEnv = "HCIP2_PRIVACY_KEY"
+
+// Redactor applies a layout's PII metadata to what the commands write out.  A nil *Redactor
+// is privacy mode turned off: everything passes through untouched.
+type Redactor struct {
+	pii       map[int]PIIAction
+	key       []byte
+	delimiter string
+}
+
+// NewRedactor makes a Redactor for config's PII columns.  The state voter ID is always hashed.
+// Pseudonyms depend only on the key, so use the same key for files that need to be joined.
+func NewRedactor(config HciConfig, key []byte) (*Redactor, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("privacy mode needs a key")
+	}
+	r := &Redactor{pii: make(map[int]PIIAction), key: key, delimiter: config.Delimiter}
+	for col, action := range config.PII {
+		r.pii[col] = action
+	}
+	if config.STATE_VOTER_ID >= 0 {
+		r.pii[config.STATE_VOTER_ID] = PIIHash
+	}
+	return r, nil
+}
+
+// LoadPrivacyKey reads the pseudonymization key from a file, or from $HCIP2_PRIVACY_KEY if
+// path is empty
+func LoadPrivacyKey(path string) ([]byte, error) {
+	if path == "" {
+		key := os.Getenv(PrivacyKeyEnv)
+		if key == "" {
+			return nil, fmt.Errorf("no privacy key file given and %s isn't set", PrivacyKeyEnv)
+		}
+		return []byte(key), nil
+	}
+	key, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading privacy key: %s", err)
+	}
+	key = []byte(strings.TrimSpace(string(key)))
+	if len(key) == 0 {
+		return nil, fmt.Errorf("privacy key file %s is empty", path)
+	}
+	return key, nil
+}
+
+// Pseudonym is the keyed hash that stands in for a value; blank values stay blank
+func (r *Redactor) Pseudonym(val string) string {
+	if r == nil || val == "" {
+		return val
+	}
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(val))
+	return hex.EncodeToString(mac.Sum(nil)[:10])
+}
+
+// Record returns a copy of a split record with its PII columns dropped or hashed
+func (r *Redactor) Record(pieces []string) []string {
+	if r == nil {
+		return pieces
+	}
+	out := make([]string, len(pieces))
+	for i, val := range pieces {
+		switch r.pii[i] {
+		case PIIDrop:
+			out[i] = ""
+		case PIIHash:
+			out[i] = r.Pseudonym(val)
+		default:
+			out[i] = val
+		}
+	}
+	return out
+}
+
+// Line is Record joined back up with the layout's delimiter, for the files that keep whole
+// voter lines; raw is returned as it is when privacy mode is off
+func (r *Redactor) Line(pieces []string, raw string) string {
+	if r == nil {
+		return raw
+	}
+	out := r.Record(pieces)
+	for i, val := range out {
+		if strings.Contains(val, r.delimiter) || strings.Contains(val, `"`) {
+			out[i] = `"` + strings.Replace(val, `"`, `""`, -1) + `"`
+		}
+	}
+	return strings.Join(out, r.delimiter)
+}
+
+// Reason is what the reject file says about an error.  Geocoder errors quote the request, which
+// has the address in it, so in privacy mode they're only filed as a geocoder error.
+func (r *Redactor) Reason(err error) string {
+	if r == nil {
+		return err.Error()
+	}
+	return "geocoder error; details withheld in privacy mode"
+}
@@ -0,0 +1,128 @@
+package hcip2
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skemper/hcip2/address"
+)
+
+func TestRedactor(t *testing.T) {
+	config := HciConfig{
+		STATE_VOTER_ID: 0,
+		Delimiter:      "|",
+		PII:            map[int]PIIAction{1: PIIDrop, 2: PIIHash},
+	}
+	r, err := NewRedactor(config, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := []string{"VOTER1", "ANN LEE", "REG9", "WAKE", "a|b"}
+	got := r.Record(record)
+	if got[0] == "VOTER1" || got[0] != r.Pseudonym("VOTER1") || len(got[0]) != 20 {
+		t.Errorf("the voter ID became %q", got[0])
+	}
+	if got[1] != "" || got[2] != r.Pseudonym("REG9") || got[3] != "WAKE" {
+		t.Errorf("Record = %q", got)
+	}
+	if record[1] != "ANN LEE" {
+		t.Error("Record changed the record it was given")
+	}
+	if line := r.Line(record, "raw"); line != got[0]+"||"+got[2]+`|WAKE|"a|b"` {
+		t.Errorf("Line = %q", line)
+	}
+	if r.Pseudonym("") != "" {
+		t.Error("a blank value got a pseudonym")
+	}
+
+	// pseudonyms join across files under one key, and only under that key
+	again, _ := NewRedactor(config, []byte("secret"))
+	other, _ := NewRedactor(config, []byte("another"))
+	if again.Pseudonym("VOTER1") != r.Pseudonym("VOTER1") || other.Pseudonym("VOTER1") == r.Pseudonym("VOTER1") {
+		t.Error("pseudonyms don't depend on the key alone")
+	}
+
+	var off *Redactor
+	if !reflect.DeepEqual(off.Record(record), record) || off.Line(record, "raw") != "raw" || off.Pseudonym("VOTER1") != "VOTER1" {
+		t.Error("a nil Redactor changed something")
+	}
+	if _, err := NewRedactor(config, nil); err == nil {
+		t.Error("a Redactor was made without a key")
+	}
+}
+
+func TestLoadPrivacyKey(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "key", "  from file\n")
+	if key, err := LoadPrivacyKey(path); err != nil || string(key) != "from file" {
+		t.Errorf("LoadPrivacyKey(%s) = %q, %v", path, key, err)
+	}
+	writeFile(t, dir, "key", "\n")
+	if _, err := LoadPrivacyKey(path); err == nil {
+		t.Error("an empty key file was accepted")
+	}
+
+	defer os.Setenv(PrivacyKeyEnv, os.Getenv(PrivacyKeyEnv))
+	os.Setenv(PrivacyKeyEnv, "from env")
+	if key, err := LoadPrivacyKey(""); err != nil || string(key) != "from env" {
+		t.Errorf("LoadPrivacyKey from $%s = %q, %v", PrivacyKeyEnv, key, err)
+	}
+	os.Setenv(PrivacyKeyEnv, "")
+	if _, err := LoadPrivacyKey(""); err == nil {
+		t.Error("no key at all was accepted")
+	}
+}
+
+func TestRedactorRejects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	geocoder := &Nominatim{BaseURL: server.URL}
+	_, gerr := geocoder.Geocode(address.Address{Number: "12", Name: "HIDDEN", Suffix: "LN", City: "APEX", State: "NC"})
+	if gerr == nil || !strings.Contains(gerr.Error(), "HIDDEN") {
+		t.Fatalf("the geocoder's error should quote the request: %v", gerr)
+	}
+
+	config := HciConfig{STATE_VOTER_ID: 0, Delimiter: "|", PII: map[int]PIIAction{1: PIIDrop, 2: PIIDrop}}
+	record := []string{"VOTER1", "ANN LEE", "12 HIDDEN LN", "APEX"}
+	raw := strings.Join(record, "|")
+	dir := t.TempDir()
+	file := func(r *Redactor, name string) string {
+		rj, err := NewRejects(filepath.Join(dir, name), Budget{Count: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rj.Row()
+		if err = rj.Add(7, "geocode", r.Reason(gerr), r.Line(record, raw)); err != nil {
+			t.Fatal(err)
+		}
+		rj.Close()
+		data, err := ioutil.ReadFile(rj.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	r, err := NewRedactor(config, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejects := file(r, "private.csv")
+	for _, pii := range []string{"VOTER1", "ANN LEE", "HIDDEN"} {
+		if strings.Contains(rejects, pii) {
+			t.Errorf("the reject file has %q in it:\n%s", pii, rejects)
+		}
+	}
+	if rejects = file(nil, "plain.csv"); !strings.Contains(rejects, "down for maintenance") || !strings.Contains(rejects, raw) {
+		t.Errorf("without privacy mode the reject file should say what went wrong:\n%s", rejects)
+	}
+}
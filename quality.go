@@ -0,0 +1,289 @@
+package hcip2
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxDistinct is how many different values ProfileFile tracks per column before it stops counting
+const maxDistinct = 1000
+
+// maxExamples is how many bad values a ColumnProfile keeps to show
+const maxExamples = 5
+
+// minAge and maxAge bound the believable ages; NC preregisters 16 and 17 year olds
+const minAge, maxAge = 16, 120
+
+// QualityOptions says what ProfileFile checks beyond the layout itself
+type QualityOptions struct {
+	Key         []int // columns that together identify a record; the state voter ID if empty
+	DateColumns []int // columns to check against DateFormat, on top of the layout's date fields
+}
+
+// ValueCount is one of a column's most common values
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// ColumnProfile is what ProfileFile found in one column
+type ColumnProfile struct {
+	Name           string         `json:"name"`
+	Field          string         `json:"field,omitempty"` // the Voter fields the column is read into, if any
+	Nulls          int            `json:"nulls"`
+	NullRate       float64        `json:"null_rate"`
+	Distinct       int            `json:"distinct"`
+	DistinctCapped bool           `json:"distinct_capped,omitempty"` // there are more than Distinct values
+	Top            []ValueCount   `json:"top,omitempty"`             // left out for PII columns and when capped
+	InvalidDates   int            `json:"invalid_dates,omitempty"`
+	InvalidNumbers int            `json:"invalid_numbers,omitempty"`
+	AgeOutOfRange  int            `json:"age_out_of_range,omitempty"`
+	UnknownCodes   map[string]int `json:"unknown_codes,omitempty"` // values missing from the layout's Codes or StatusCodes
+	Examples       []string       `json:"examples,omitempty"`      // where the bad values are; PII values are left out
+
+	counts map[string]int
+	date   bool
+	number bool
+	age    bool
+	pii    bool
+	codes  map[string]bool
+}
+
+// QualityReport is the profile of a whole voter or history file
+type QualityReport struct {
+	File                string          `json:"file"`
+	State               string          `json:"state"`
+	Rows                int             `json:"rows"`
+	ShortRows           int             `json:"short_rows"`     // fewer values than the layout has columns
+	LongRows            int             `json:"long_rows"`      // more values than the layout has columns
+	OverlongLines       int             `json:"overlong_lines"` // longer than MaxLineLength
+	DuplicateKeys       int             `json:"duplicate_keys"`
+	DuplicateExamples   []string        `json:"duplicate_examples,omitempty"`
+	MissingHouseNumbers int             `json:"missing_house_numbers"` // a street, but no number on it
+	Columns             []ColumnProfile `json:"columns"`
+}
+
+// ProfileFile reads every record of a file, filters and status policy aside, and profiles it
+// column by column.  Unlike the loaders it doesn't stop at bad values; it counts them.
+func ProfileFile(path string, config HciConfig, opts QualityOptions) (*QualityReport, error) {
+	rr, err := OpenRecords(path, config)
+	if err != nil {
+		return nil, err
+	}
+	defer rr.Close()
+
+	report := &QualityReport{File: path, State: config.StateAbbrev}
+	report.Columns = make([]ColumnProfile, len(config.Columns))
+	for i, name := range config.Columns {
+		report.Columns[i] = ColumnProfile{Name: name, counts: make(map[string]int)}
+	}
+	profile := func(col int) *ColumnProfile {
+		if col < 0 || col >= len(report.Columns) {
+			return nil
+		}
+		return &report.Columns[col]
+	}
+
+	voterType := reflect.TypeOf(Voter{})
+	fields := make([]string, 0, len(config.Fields))
+	for field := range config.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		p := profile(config.Fields[field])
+		if p == nil {
+			continue
+		}
+		if p.Field != "" {
+			p.Field += ","
+		}
+		p.Field += field
+		if f, ok := voterType.FieldByName(field); ok {
+			p.date = p.date || f.Type == timeType
+			p.number = p.number || f.Type.Kind() == reflect.Int
+		}
+		p.age = p.age || field == "Age" || field == "Birthdate"
+	}
+	for _, col := range opts.DateColumns {
+		if p := profile(col); p != nil {
+			p.date = true
+		}
+	}
+	for col := range config.PII {
+		if p := profile(col); p != nil {
+			p.pii = true
+		}
+	}
+	if p := profile(config.STATE_VOTER_ID); p != nil {
+		p.pii = true
+	}
+	for col, codes := range config.Codes {
+		if p := profile(col); p != nil {
+			p.codes = make(map[string]bool)
+			for _, code := range codes {
+				p.codes[code] = true
+			}
+		}
+	}
+	if col, ok := config.Fields["Status_cd"]; ok && len(config.StatusCodes) > 0 {
+		if p := profile(col); p != nil {
+			p.codes = make(map[string]bool)
+			for code := range config.StatusCodes {
+				p.codes[code] = true
+			}
+		}
+	}
+
+	key := opts.Key
+	if len(key) == 0 && config.STATE_VOTER_ID >= 0 {
+		key = []int{config.STATE_VOTER_ID}
+	}
+	seen := make(map[string]bool)
+	hasStreet := len(config.Road) > 0 || len(config.AddressFields) > 0
+	now := time.Now()
+
+	for {
+		pieces, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error reading %s: %s", path, err)
+		}
+		report.Rows++
+		where := fmt.Sprintf("line %d", rr.Line)
+
+		if config.MaxLineLength > 0 && len(rr.Raw) > config.MaxLineLength {
+			report.OverlongLines++
+		}
+		switch n := len(rr.split(rr.Raw)); {
+		case n < len(config.Columns):
+			report.ShortRows++
+		case n > len(config.Columns):
+			report.LongRows++
+		}
+
+		for i := range report.Columns {
+			report.Columns[i].add(column(pieces, i), where, config.DateFormat, now)
+		}
+
+		if len(key) > 0 {
+			id := ""
+			for _, col := range key {
+				id += column(pieces, col) + "\x00"
+			}
+			if seen[id] {
+				report.DuplicateKeys++
+				if len(report.DuplicateExamples) < maxExamples {
+					report.DuplicateExamples = append(report.DuplicateExamples, where)
+				}
+			}
+			seen[id] = true
+		}
+
+		if hasStreet {
+			addr := config.Address(pieces)
+			if addr.Name != "" && addr.Number == "" && addr.POBox == "" {
+				report.MissingHouseNumbers++
+			}
+		}
+	}
+
+	for i := range report.Columns {
+		report.Columns[i].finish(report.Rows)
+	}
+	return report, nil
+}
+
+// add profiles one value of the column
+func (p *ColumnProfile) add(val string, where string, dateFormat string, now time.Time) {
+	if val == "" || val == "*" { // FL redacts exempt voters with a lone *
+		p.Nulls++
+		return
+	}
+	if _, ok := p.counts[val]; ok || len(p.counts) < maxDistinct {
+		p.counts[val]++
+	} else {
+		p.DistinctCapped = true
+	}
+
+	bad := false
+	switch {
+	case p.date:
+		format := dateFormat
+		if len(val) == 4 {
+			format = "2006"
+		}
+		t, err := time.Parse(format, val)
+		if err != nil {
+			p.InvalidDates++
+			bad = true
+		} else if p.age {
+			if age := ageOn(t, now); age < minAge || age > maxAge {
+				p.AgeOutOfRange++
+				bad = true
+			}
+		}
+	case p.number:
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			p.InvalidNumbers++
+			bad = true
+		} else if p.age && (n < minAge || n > maxAge) {
+			p.AgeOutOfRange++
+			bad = true
+		}
+	}
+	if p.codes != nil && !p.codes[val] {
+		if p.UnknownCodes == nil {
+			p.UnknownCodes = make(map[string]int)
+		}
+		p.UnknownCodes[val]++
+		bad = true
+	}
+
+	if bad && len(p.Examples) < maxExamples {
+		if p.pii {
+			p.Examples = append(p.Examples, where)
+		} else {
+			p.Examples = append(p.Examples, fmt.Sprintf("%s: %q", where, val))
+		}
+	}
+}
+
+// finish works out the rates and the most common values once every row is in
+func (p *ColumnProfile) finish(rows int) {
+	if rows > 0 {
+		p.NullRate = float64(p.Nulls) / float64(rows)
+	}
+	p.Distinct = len(p.counts)
+	if !p.pii && !p.DistinctCapped {
+		for val, n := range p.counts {
+			p.Top = append(p.Top, ValueCount{val, n})
+		}
+		sort.Slice(p.Top, func(i, j int) bool {
+			if p.Top[i].Count != p.Top[j].Count {
+				return p.Top[i].Count > p.Top[j].Count
+			}
+			return p.Top[i].Value < p.Top[j].Value
+		})
+		if len(p.Top) > 5 {
+			p.Top = p.Top[:5]
+		}
+	}
+	p.counts = nil
+}
+
+// Problems counts the bad values found in the column
+func (p *ColumnProfile) Problems() int {
+	n := p.InvalidDates + p.InvalidNumbers + p.AgeOutOfRange
+	for _, count := range p.UnknownCodes {
+		n += count
+	}
+	return n
+}
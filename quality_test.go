@@ -0,0 +1,93 @@
+package hcip2
+
+import (
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileFile(t *testing.T) {
+	dir := t.TempDir()
+	config := loadLayout(t, dir, `{
+		"state_abbrev": "XX",
+		"columns": ["id", "party", "status", "birth", "street", "city", "zip", "last"],
+		"delimiter": "|",
+		"max_line_length": 60,
+		"voter_id": "id",
+		"road": ["street"],
+		"city": "city",
+		"zip": "zip",
+		"fields": {"Party_cd": "party", "Status_cd": "status", "Birthdate": "birth", "Last_name": "last"},
+		"status_codes": {"A": "active", "R": "removed"},
+		"codes": {"party": ["DEM", "REP"]},
+		"pii": {"last": "drop"}
+	}`)
+
+	born := strconv.Itoa(time.Now().Year() - 40)
+	rows := []string{
+		"id|party|status|birth|street|city|zip|last",
+		"1|DEM|A|01/01/" + born[2:] + "|1 MAIN ST|APEX|27502|LEE",
+		"2|GRN|A|1900-01-01|MAIN ST|APEX|27502|KAY",
+		"2|REP|X|bad|2 OAK ST|APEX|27502|SMITH-" + strings.Repeat("X", 30),
+		"3|DEM|R|" + born + "-02-03|3 ELM ST|APEX|27502",
+		"4|DEM|A|" + born + "-02-03|4 ELM ST|APEX|27502|FOX|extra",
+	}
+	path := writeFile(t, dir, "voters.txt", strings.Join(rows, "\n")+"\n")
+
+	report, err := ProfileFile(path, config, QualityOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Rows != 5 || report.ShortRows != 1 || report.LongRows != 1 || report.OverlongLines != 1 {
+		t.Errorf("rows %d, short %d, long %d, overlong %d", report.Rows, report.ShortRows, report.LongRows, report.OverlongLines)
+	}
+	if report.DuplicateKeys != 1 || !reflect.DeepEqual(report.DuplicateExamples, []string{"line 4"}) {
+		t.Errorf("duplicates %d at %q", report.DuplicateKeys, report.DuplicateExamples)
+	}
+	if report.MissingHouseNumbers != 1 {
+		t.Errorf("%d addresses without a house number", report.MissingHouseNumbers)
+	}
+
+	cols := make(map[string]ColumnProfile)
+	for _, col := range report.Columns {
+		cols[col.Name] = col
+	}
+	if party := cols["party"]; !reflect.DeepEqual(party.UnknownCodes, map[string]int{"GRN": 1}) || party.Top[0] != (ValueCount{"DEM", 3}) {
+		t.Errorf("party: unknown %v, top %v", party.UnknownCodes, party.Top)
+	}
+	if status := cols["status"]; !reflect.DeepEqual(status.UnknownCodes, map[string]int{"X": 1}) {
+		t.Errorf("status: unknown %v", status.UnknownCodes)
+	}
+	if birth := cols["birth"]; birth.InvalidDates != 2 || birth.AgeOutOfRange != 1 {
+		t.Errorf("birth: %d invalid, %d out of range (%q)", birth.InvalidDates, birth.AgeOutOfRange, birth.Examples)
+	}
+	if last := cols["last"]; last.Nulls != 1 || last.Top != nil || last.NullRate != 0.2 {
+		t.Errorf("last: %d nulls, rate %v, top %v", last.Nulls, last.NullRate, last.Top)
+	}
+	if id := cols["id"]; id.Top != nil {
+		t.Errorf("the voter IDs were listed: %v", id.Top)
+	}
+}
+
+func TestProfileFixtures(t *testing.T) {
+	for _, state := range []string{"FL", "GA", "MI", "OH", "PA"} {
+		path := filepath.Join("testdata", strings.ToLower(state)+"_voters.txt")
+		report, err := ProfileFile(path, Configs[state], QualityOptions{})
+		if err != nil {
+			t.Errorf("%s: %s", state, err)
+			continue
+		}
+		// OH's rows run long with the election history columns, so only short rows are a problem
+		if report.Rows == 0 || report.ShortRows != 0 {
+			t.Errorf("%s: rows %d, short %d", state, report.Rows, report.ShortRows)
+		}
+		for _, col := range report.Columns {
+			if col.InvalidDates != 0 {
+				t.Errorf("%s: %s has %d invalid dates: %q", state, col.Name, col.InvalidDates, col.Examples)
+			}
+		}
+	}
+}
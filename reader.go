@@ -0,0 +1,219 @@
+package hcip2
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encoding is the text encoding a voter file is assumed to be in when it has no byte order mark
+type Encoding int
+
+const (
+	UTF8 Encoding = iota
+	UTF16LE
+	UTF16BE
+	Windows1252
+)
+
+// Quoting says how a layout quotes its values
+type Quoting int
+
+const (
+	QuoteStrip Quoting = iota // split on every delimiter, then strip stray quotes off each value
+	QuoteNone                 // values are never quoted; quotes are kept as data
+	QuoteCSV                  // RFC 4180: a quoted value may hold the delimiter, and "" is a literal quote
+)
+
+// HeaderPolicy says what to do with the first line of a voter file
+type HeaderPolicy int
+
+const (
+	HeaderResolve HeaderPolicy = iota // check the header against Columns and read columns by name
+	HeaderSkip                        // throw the first line away unchecked
+	HeaderNone                        // there is no header; the first line is a record
+)
+
+// RecordReader reads the records of a voter file as clean []string rows in Columns order,
+// taking care of the layout's encoding, delimiter, quoting and header
+type RecordReader struct {
+	Line    int    // line number of the last record read
+	Raw     string // the last record read, as it appeared in the file (after decoding)
+	config  *HciConfig
+	scanner *bufio.Scanner
+	cols    ColumnMap
+	closer  io.Closer
+}
+
+// decoder returns a transformer that decodes enc to UTF-8, but abides by a BOM if the file has one
+func (enc Encoding) decoder() *encoding.Decoder {
+	var dec *encoding.Decoder
+	switch enc {
+	case UTF16LE:
+		dec = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder()
+	case UTF16BE:
+		dec = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder()
+	case Windows1252:
+		dec = charmap.Windows1252.NewDecoder()
+	default:
+		dec = unicode.UTF8.NewDecoder()
+	}
+	return &encoding.Decoder{Transformer: unicode.BOMOverride(dec)}
+}
+
+// OpenRecords opens a voter file (or a compressed one, see OpenSource) for reading with
+// NewRecordReader; Close it when done
+func OpenRecords(path string, config HciConfig) (*RecordReader, error) {
+	file, err := OpenSource(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening %s: %s", path, err)
+	}
+	rr, err := NewRecordReader(file, config)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("Error reading %s: %s", path, err)
+	}
+	rr.closer = file
+	return rr, nil
+}
+
+// NewRecordReader decodes r according to config and deals with the header row, if any
+func NewRecordReader(r io.Reader, config HciConfig) (*RecordReader, error) {
+	rr := &RecordReader{
+		config:  &config,
+		scanner: bufio.NewScanner(transform.NewReader(r, config.Encoding.decoder())),
+	}
+	if config.MaxLineLength > bufio.MaxScanTokenSize/4 {
+		rr.scanner.Buffer(nil, 4*config.MaxLineLength) // decoded text can be longer than the raw bytes
+	}
+
+	if config.Header == HeaderNone {
+		return rr, nil
+	}
+	if !rr.scanner.Scan() {
+		if err := rr.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return rr, nil // an empty file has no header to check
+	}
+	rr.Line++
+	if config.Header == HeaderResolve {
+		cols, err := config.ResolveHeader(rr.split(rr.scanner.Text()))
+		if err != nil {
+			return nil, err
+		}
+		rr.cols = cols
+	}
+	return rr, nil
+}
+
+// Read returns the next record, or io.EOF once the file is done.  Blank lines are skipped.
+func (rr *RecordReader) Read() ([]string, error) {
+	for rr.scanner.Scan() {
+		rr.Line++
+		rr.Raw = rr.scanner.Text()
+		if rr.Raw == "" {
+			continue
+		}
+		return rr.cols.Apply(rr.split(rr.Raw)), nil
+	}
+	if err := rr.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %s", rr.Line+1, err)
+	}
+	return nil, io.EOF
+}
+
+// ReadVoter returns the next record as a typed Voter, or io.EOF once the file is done
+func (rr *RecordReader) ReadVoter() (*Voter, error) {
+	pieces, err := rr.Read()
+	if err != nil {
+		return nil, err
+	}
+	voter, err := ParseVoter(pieces, *rr.config)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %s", rr.Line, err)
+	}
+	return voter, nil
+}
+
+// Close closes the underlying file, if OpenRecords opened one
+func (rr *RecordReader) Close() error {
+	if rr.closer == nil {
+		return nil
+	}
+	return rr.closer.Close()
+}
+
+// split breaks one line into clean values according to the layout's delimiter and quoting
+func (rr *RecordReader) split(line string) []string {
+	switch rr.config.Quoting {
+	case QuoteCSV:
+		return splitQuoted(line, rr.config.Delimiter)
+	case QuoteNone:
+		pieces := strings.Split(line, rr.config.Delimiter)
+		for i := range pieces {
+			pieces[i] = strings.TrimSpace(pieces[i])
+		}
+		return pieces
+	default:
+		pieces := strings.Split(line, rr.config.Delimiter)
+		for i := range pieces {
+			pieces[i] = cleanField(pieces[i])
+		}
+		return pieces
+	}
+}
+
+// splitQuoted splits a line of RFC 4180 style values.  Voter files never put newlines in a
+// value, so unlike encoding/csv this works a line at a time.
+func splitQuoted(line string, delim string) []string {
+	var pieces []string
+	var val strings.Builder
+	for {
+		val.Reset()
+		rest := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(rest, `"`) {
+			rest = rest[1:]
+			for {
+				i := strings.IndexByte(rest, '"')
+				if i < 0 { // unterminated; take the rest as-is
+					val.WriteString(rest)
+					rest = ""
+					break
+				}
+				val.WriteString(rest[:i])
+				rest = rest[i+1:]
+				if !strings.HasPrefix(rest, `"`) {
+					break
+				}
+				val.WriteByte('"') // a doubled quote
+				rest = rest[1:]
+			}
+			// anything between the closing quote and the delimiter is kept too
+			end := strings.Index(rest, delim)
+			if end < 0 {
+				end = len(rest)
+			}
+			val.WriteString(rest[:end])
+			line = rest[end:]
+		} else {
+			end := strings.Index(line, delim)
+			if end < 0 {
+				end = len(line)
+			}
+			val.WriteString(line[:end])
+			line = line[end:]
+		}
+		pieces = append(pieces, strings.TrimSpace(val.String()))
+		if line == "" {
+			return pieces
+		}
+		line = line[len(delim):]
+	}
+}
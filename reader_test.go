@@ -0,0 +1,107 @@
+package hcip2
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readAll(t *testing.T, input string, config HciConfig) [][]string {
+	rr, err := NewRecordReader(strings.NewReader(input), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records [][]string
+	for {
+		pieces, err := rr.Read()
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, pieces)
+	}
+}
+
+func TestSplitQuoted(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`a,b,c`, []string{"a", "b", "c"}},
+		{`"a,b",c`, []string{"a,b", "c"}},
+		{`"say ""hi""",x`, []string{`say "hi"`, "x"}},
+		{`a,,`, []string{"a", "", ""}},
+		{` "a" ,b`, []string{"a", "b"}},
+		{`"unterminated,b`, []string{"unterminated,b"}},
+	}
+	for _, tt := range tests {
+		if got := splitQuoted(tt.line, ","); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitQuoted(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestRecordReaderQuoting(t *testing.T) {
+	input := "\"a\"| \"b\" \n"
+	if got := readAll(t, input, HciConfig{Header: HeaderNone, Delimiter: "|", Quoting: QuoteStrip}); !reflect.DeepEqual(got, [][]string{{"a", "b"}}) {
+		t.Errorf("strip gave %q", got)
+	}
+	if got := readAll(t, input, HciConfig{Header: HeaderNone, Delimiter: "|", Quoting: QuoteNone}); !reflect.DeepEqual(got, [][]string{{`"a"`, `"b"`}}) {
+		t.Errorf("none gave %q", got)
+	}
+}
+
+func TestRecordReaderEncodings(t *testing.T) {
+	utf16le := func(s string) string {
+		var b strings.Builder
+		for _, r := range s {
+			b.WriteByte(byte(r))
+			b.WriteByte(byte(r >> 8))
+		}
+		return b.String()
+	}
+	config := HciConfig{Columns: []string{"id", "city"}, Delimiter: "\t"}
+	want := [][]string{{"7", "CAÑON"}}
+
+	config.Encoding = UTF16LE
+	if got := readAll(t, utf16le("id\tcity\n7\tCAÑON\n"), config); !reflect.DeepEqual(got, want) {
+		t.Errorf("utf-16le gave %q", got)
+	}
+	config.Encoding = UTF8
+	if got := readAll(t, "\xff\xfe"+utf16le("id\tcity\n7\tCAÑON\n"), config); !reflect.DeepEqual(got, want) {
+		t.Errorf("utf-16 with a BOM gave %q", got)
+	}
+	config.Encoding = Windows1252
+	if got := readAll(t, "id\tcity\n7\tCA\xd1ON\n", config); !reflect.DeepEqual(got, want) {
+		t.Errorf("windows-1252 gave %q", got)
+	}
+}
+
+func TestRecordReaderHeaders(t *testing.T) {
+	config := HciConfig{Columns: []string{"id", "city"}, Delimiter: ","}
+
+	config.Header = HeaderSkip
+	if got := readAll(t, "whatever\n1,A\n\n2,B\n", config); !reflect.DeepEqual(got, [][]string{{"1", "A"}, {"2", "B"}}) {
+		t.Errorf("skip gave %q", got)
+	}
+	config.Header = HeaderNone
+	if got := readAll(t, "1,A\n", config); !reflect.DeepEqual(got, [][]string{{"1", "A"}}) {
+		t.Errorf("none gave %q", got)
+	}
+	config.Header = HeaderResolve
+	if _, err := NewRecordReader(strings.NewReader("id,zip\n"), config); err == nil {
+		t.Error("a header missing a column was accepted")
+	}
+}
+
+func TestRecordReaderLongLines(t *testing.T) {
+	long := strings.Repeat("x", 100000)
+	config := HciConfig{Header: HeaderNone, Delimiter: "\t", MaxLineLength: 100000}
+	got := readAll(t, "1\t"+long+"\n", config)
+	if len(got) != 1 || got[0][1] != long {
+		t.Errorf("a %d byte line didn't come back whole", len(long)+2)
+	}
+}
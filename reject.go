@@ -0,0 +1,190 @@
+package hcip2
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// minBudgetRows is how many rows have to be read before a Budget's Rate is enforced, so a few
+// bad rows at the top of a file don't abort the run
+const minBudgetRows = 1000
+
+// Budget is how many rejected rows a run can take before it's aborted: a count, or a fraction
+// of the rows read.  A negative Count means there's no limit.
+type Budget struct {
+	Count int
+	Rate  float64
+}
+
+// ParseBudget reads a budget as a count ("100"), a percentage ("0.5%") or "none"
+func ParseBudget(s string) (Budget, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "none") {
+		return Budget{Count: -1}, nil
+	}
+	if strings.HasSuffix(s, "%") {
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || pct < 0 || pct > 100 {
+			return Budget{}, fmt.Errorf("bad reject budget %s", s)
+		}
+		return Budget{Rate: pct / 100}, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return Budget{}, fmt.Errorf("bad reject budget %s; use a count, a percentage or none", s)
+	}
+	return Budget{Count: n}, nil
+}
+
+func (b Budget) String() string {
+	switch {
+	case b.Count < 0:
+		return "none"
+	case b.Rate > 0:
+		return strconv.FormatFloat(100*b.Rate, 'f', -1, 64) + "%"
+	}
+	return strconv.Itoa(b.Count)
+}
+
+// Rejects files the rows that fail parsing or processing in a CSV of line number, stage,
+// reason and the record itself, so a run can carry on past them.  A nil *Rejects turns every
+// reject into an error, which stops the run at the first bad row.
+type Rejects struct {
+	Path   string
+	Budget Budget
+	count  int
+	rows   int
+	file   *os.File
+	w      *csv.Writer
+	mu     sync.Mutex
+}
+
+// NewRejects creates the reject file at path
+func NewRejects(path string, budget Budget) (*Rejects, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	rj := &Rejects{Path: path, Budget: budget, file: file, w: csv.NewWriter(file)}
+	rj.w.Write([]string{"line", "stage", "reason", "record"})
+	return rj, nil
+}
+
+// RejectState is where a reject file stood at a checkpoint
+type RejectState struct {
+	Size  int64 `json:"size"`
+	Count int   `json:"count"`
+	Rows  int   `json:"rows"`
+}
+
+// ResumeRejects reopens the reject file at path as it stood at a checkpoint, dropping whatever
+// was filed after it, and carries on counting against the budget from there
+func ResumeRejects(path string, budget Budget, state RejectState) (*Rejects, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < state.Size {
+		return nil, fmt.Errorf("%s is shorter than it was at the checkpoint", path)
+	}
+	if err = os.Truncate(path, state.Size); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	rj := &Rejects{Path: path, Budget: budget, count: state.Count, rows: state.Rows, file: file, w: csv.NewWriter(file)}
+	if state.Size == 0 {
+		rj.w.Write([]string{"line", "stage", "reason", "record"})
+	}
+	return rj, nil
+}
+
+// Sync flushes the reject file to disk and says where it stands, for a checkpoint
+func (rj *Rejects) Sync() (RejectState, error) {
+	if rj == nil {
+		return RejectState{}, nil
+	}
+	rj.mu.Lock()
+	defer rj.mu.Unlock()
+	rj.w.Flush()
+	if err := rj.w.Error(); err != nil {
+		return RejectState{}, err
+	}
+	if err := rj.file.Sync(); err != nil {
+		return RejectState{}, err
+	}
+	info, err := rj.file.Stat()
+	if err != nil {
+		return RejectState{}, err
+	}
+	return RejectState{Size: info.Size(), Count: rj.count, Rows: rj.rows}, nil
+}
+
+// Row counts a row read, for Budgets given as a rate
+func (rj *Rejects) Row() {
+	if rj == nil {
+		return
+	}
+	rj.mu.Lock()
+	rj.rows++
+	rj.mu.Unlock()
+}
+
+// Add files a rejected row.  The error it returns, once the budget is spent, should end the run.
+func (rj *Rejects) Add(line int, stage string, reason string, record string) error {
+	if rj == nil {
+		return fmt.Errorf("line %d: %s", line, reason)
+	}
+	rj.mu.Lock()
+	defer rj.mu.Unlock()
+	rj.count++
+	rj.w.Write([]string{strconv.Itoa(line), stage, reason, record})
+
+	over := false
+	switch {
+	case rj.Budget.Count < 0:
+	case rj.Budget.Rate > 0:
+		over = rj.rows >= minBudgetRows && float64(rj.count) > rj.Budget.Rate*float64(rj.rows)
+	default:
+		over = rj.count > rj.Budget.Count
+	}
+	if over {
+		rj.w.Flush()
+		return fmt.Errorf("%d rows rejected, over the budget of %s (last at line %d: %s); see %s", rj.count, rj.Budget, line, reason, rj.Path)
+	}
+	return nil
+}
+
+// Close flushes the reject file and says how many rows went into it
+func (rj *Rejects) Close() error {
+	if rj == nil {
+		return nil
+	}
+	rj.mu.Lock()
+	defer rj.mu.Unlock()
+	rj.w.Flush()
+	if rj.count > 0 {
+		fmt.Printf("WARNING: %d rows rejected; see %s\n", rj.count, rj.Path)
+	}
+	if err := rj.w.Error(); err != nil {
+		rj.file.Close()
+		return err
+	}
+	return rj.file.Close()
+}
+
+// Rejected is how many rows have been rejected so far
+func (rj *Rejects) Rejected() int {
+	if rj == nil {
+		return 0
+	}
+	rj.mu.Lock()
+	defer rj.mu.Unlock()
+	return rj.count
+}
@@ -0,0 +1,189 @@
+package hcip2
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseBudget(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Budget
+		str  string
+	}{
+		{"100", Budget{Count: 100}, "100"},
+		{"0", Budget{}, "0"},
+		{" 0.5% ", Budget{Rate: 0.005}, "0.5%"},
+		{"NONE", Budget{Count: -1}, "none"},
+	}
+	for _, test := range tests {
+		got, err := ParseBudget(test.in)
+		if err != nil {
+			t.Errorf("ParseBudget(%q): %s", test.in, err)
+			continue
+		}
+		if got != test.want || got.String() != test.str {
+			t.Errorf("ParseBudget(%q) = %#v (%s), want %#v (%s)", test.in, got, got, test.want, test.str)
+		}
+	}
+	for _, in := range []string{"", "-1", "lots", "101%", "-2%", "x%"} {
+		if _, err := ParseBudget(in); err == nil {
+			t.Errorf("ParseBudget(%q) should have failed", in)
+		}
+	}
+}
+
+// readRejects returns the rows of a reject file, header and all
+func readRejects(t *testing.T, path string) [][]string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestRejectsBudget(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		budget Budget
+		rows   int
+		adds   int // how many rejects the budget takes before Add fails; -1 if it never does
+	}{
+		{Budget{Count: 2}, 0, 3},
+		{Budget{Count: 0}, 0, 1},
+		{Budget{Count: -1}, 0, -1},
+		{Budget{Rate: 0.01}, 10, -1}, // too few rows to hold it to the rate
+		{Budget{Rate: 0.01}, minBudgetRows, 11},
+	}
+	for i, test := range tests {
+		path := filepath.Join(dir, "rejects.csv")
+		rj, err := NewRejects(path, test.budget)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for r := 0; r < test.rows; r++ {
+			rj.Row()
+		}
+		failedAt := -1
+		for n := 1; n <= 20; n++ {
+			if err := rj.Add(n, "parse", "bad", "a|b"); err != nil {
+				failedAt = n
+				break
+			}
+		}
+		if failedAt != test.adds {
+			t.Errorf("%d: budget %s over at reject %d, want %d", i, test.budget, failedAt, test.adds)
+		}
+		if err := rj.Close(); err != nil {
+			t.Fatal(err)
+		}
+		rows := readRejects(t, path)
+		if want := rj.Rejected() + 1; len(rows) != want {
+			t.Errorf("%d: %d rows in the reject file, want %d", i, len(rows), want)
+		}
+	}
+}
+
+func TestRejectsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rejects.csv")
+
+	rj, err := NewRejects(path, Budget{Count: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rj.Add(3, "parse", "Error converting Age x to integer", `1|"x"`)
+	state, err := rj.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Count != 1 {
+		t.Errorf("state %+v", state)
+	}
+	rj.Add(7, "geocode", "no match", "2|y") // after the checkpoint, so resuming drops it
+	rj.Close()
+
+	rj, err = ResumeRejects(path, Budget{Count: 1}, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rj.Rejected() != 1 {
+		t.Errorf("resumed with %d rejects, want 1", rj.Rejected())
+	}
+	if err := rj.Add(9, "parse", "short row", "3"); err == nil {
+		t.Error("the resumed count didn't go against the budget")
+	}
+	rj.Close()
+
+	want := [][]string{
+		{"line", "stage", "reason", "record"},
+		{"3", "parse", "Error converting Age x to integer", `1|"x"`},
+		{"9", "parse", "short row", "3"},
+	}
+	if got := readRejects(t, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("reject file %q, want %q", got, want)
+	}
+
+	if _, err := ResumeRejects(path, Budget{}, RejectState{Size: 1 << 20}); err == nil {
+		t.Error("resumed a reject file shorter than the checkpoint")
+	}
+}
+
+func TestNilRejects(t *testing.T) {
+	var rj *Rejects
+	rj.Row()
+	if err := rj.Add(5, "parse", "bad date", "x"); err == nil || err.Error() != "line 5: bad date" {
+		t.Errorf("nil Rejects.Add = %v", err)
+	}
+	if rj.Rejected() != 0 || rj.Close() != nil {
+		t.Error("nil Rejects should be a no-op")
+	}
+	if state, err := rj.Sync(); err != nil || state != (RejectState{}) {
+		t.Errorf("nil Rejects.Sync = %+v, %v", state, err)
+	}
+}
+
+func TestScanVotersRejects(t *testing.T) {
+	dir := t.TempDir()
+	config := loadLayout(t, dir, `{
+		"state_abbrev": "XX",
+		"columns": ["id", "age", "city", "zip"],
+		"delimiter": "|",
+		"voter_id": "id",
+		"city": "city",
+		"zip": "zip",
+		"fields": {"Age": "age"}
+	}`)
+	path := writeFile(t, dir, "voters.txt", "id|age|city|zip\n1|40|APEX|27502\n2|forty|APEX|27502\n3|50|APEX|27502\n")
+
+	if _, err := LoadVoters(path, config); err == nil {
+		t.Error("without Rejects, a bad row should stop the load")
+	}
+
+	rejects, err := NewRejects(filepath.Join(dir, "rejects.csv"), Budget{Count: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Rejects = rejects
+	voters, err := LoadVoters(path, config)
+	config.Rejects.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(voters) != 2 || voters["3"] == nil || voters["3"].Age != 50 {
+		t.Errorf("loaded %v", voters)
+	}
+	rows := readRejects(t, config.Rejects.Path)
+	if len(rows) != 2 || rows[1][0] != "3" || rows[1][1] != "parse" || rows[1][3] != "2|forty|APEX|27502" {
+		t.Errorf("reject file %q", rows)
+	}
+}
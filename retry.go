@@ -0,0 +1,304 @@
+package hcip2
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryPolicy is how a client from NewHTTPClient handles a geocoder that's failing: each
+// attempt gets Timeout, and a failure that might go away by itself (the connection failing,
+// the attempt timing out, a 429 or a 5xx) is retried after a backoff that doubles each time,
+// with jitter so the workers don't all come back at once.  A Retry-After header is honored.
+type RetryPolicy struct {
+	Retries    int             // how many times to retry a request
+	Backoff    time.Duration   // the wait before the first retry
+	MaxBackoff time.Duration   // the longest wait between retries
+	Timeout    time.Duration   // how long each attempt has, body and all; no limit if 0
+	Breaker    *CircuitBreaker // shared by every request; none if nil
+}
+
+// RetryFlags are the command-line flags the geocoding commands build their RetryPolicy from
+type RetryFlags struct {
+	Retries         *int
+	Backoff         *time.Duration
+	MaxBackoff      *time.Duration
+	Timeout         *time.Duration
+	Breaker         *int
+	BreakerCooldown *time.Duration
+	MaxPause        *time.Duration
+}
+
+// NewRetryFlags defines the retry and circuit breaker flags on fs, usually flag.CommandLine
+func NewRetryFlags(fs *flag.FlagSet) *RetryFlags {
+	return &RetryFlags{
+		Retries:         fs.Int("retries", 5, "how many times to retry a geocoding request that failed in a way that might pass: no connection, a timeout, a 429 or a 5xx"),
+		Backoff:         fs.Duration("backoff", time.Second, "how long to wait before retrying; doubled, with jitter, for each retry after"),
+		MaxBackoff:      fs.Duration("max-backoff", time.Minute, "the longest wait between retries"),
+		Timeout:         fs.Duration("timeout", 0, "how long each geocoding request has; 30s, or 10m for census, if 0"),
+		Breaker:         fs.Int("breaker", 20, "failed requests in a row that pause the run until the geocoder is back; never if 0"),
+		BreakerCooldown: fs.Duration("breaker-cooldown", 30*time.Second, "how often to try the geocoder while the run is paused"),
+		MaxPause:        fs.Duration("max-pause", 0, "how long to stay paused before failing the requests; forever if 0"),
+	}
+}
+
+// Policy is the RetryPolicy the flags ask for, with the named geocoder's DefaultTimeout if they
+// don't give one
+func (f *RetryFlags) Policy(geocoder string) RetryPolicy {
+	policy := RetryPolicy{Retries: *f.Retries, Backoff: *f.Backoff, MaxBackoff: *f.MaxBackoff, Timeout: *f.Timeout}
+	if policy.Timeout == 0 {
+		policy.Timeout = DefaultTimeout(geocoder)
+	}
+	if *f.Breaker > 0 {
+		policy.Breaker = NewCircuitBreaker(*f.Breaker, *f.BreakerCooldown, *f.MaxPause)
+	}
+	return policy
+}
+
+// DefaultTimeout is how long a request to the named geocoder should have: 30 seconds, but the
+// Census geocoder takes minutes over a batch
+func DefaultTimeout(geocoder string) time.Duration {
+	if strings.ToLower(geocoder) == "census" {
+		return 10 * time.Minute
+	}
+	return 30 * time.Second
+}
+
+// ErrGeocoderDown is what requests fail with once a CircuitBreaker has waited MaxPause for the
+// geocoder to come back
+var ErrGeocoderDown = errors.New("geocoder is down")
+
+// CircuitBreaker pauses every request to a geocoder once Threshold requests in a row have
+// failed, rather than have each fail on its own.  While it's open, one request at a time is let
+// through every Cooldown to see whether the geocoder is back, and the rest wait.
+type CircuitBreaker struct {
+	Threshold int           // failures in a row that open the breaker
+	Cooldown  time.Duration // how often to try the geocoder while it's down
+	MaxPause  time.Duration // how long to wait for it to come back before failing; forever if 0
+	failures  int
+	open      bool
+	openedAt  time.Time
+	nextProbe time.Time
+	probing   bool
+	mu        sync.Mutex
+}
+
+// NewCircuitBreaker makes a breaker that opens after threshold failures in a row
+func NewCircuitBreaker(threshold int, cooldown time.Duration, maxPause time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown, MaxPause: maxPause}
+}
+
+// wait blocks while the breaker is open, until it's the caller's turn to try the geocoder; a
+// nil *CircuitBreaker never waits
+func (cb *CircuitBreaker) wait(ctx context.Context) error {
+	if cb == nil {
+		return nil
+	}
+	for {
+		cb.mu.Lock()
+		now := time.Now()
+		switch {
+		case !cb.open:
+			cb.mu.Unlock()
+			return nil
+		case cb.MaxPause > 0 && now.Sub(cb.openedAt) > cb.MaxPause:
+			cb.mu.Unlock()
+			return fmt.Errorf("%s: no answer for %s", ErrGeocoderDown, cb.MaxPause)
+		case !cb.probing && !now.Before(cb.nextProbe):
+			cb.probing = true
+			cb.mu.Unlock()
+			return nil
+		}
+		sleep := cb.nextProbe.Sub(now)
+		if sleep <= 0 {
+			sleep = cb.Cooldown // someone else is probing
+		}
+		cb.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+	}
+}
+
+// success closes the breaker
+func (cb *CircuitBreaker) success() {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.open {
+		fmt.Printf("Geocoder is back after %s; carrying on\n", time.Since(cb.openedAt).Round(time.Second))
+	}
+	cb.failures, cb.open, cb.probing = 0, false, false
+}
+
+// failure counts a failed attempt, opening the breaker at Threshold
+func (cb *CircuitBreaker) failure(err error) {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures++
+	now := time.Now()
+	if cb.open {
+		if cb.probing {
+			cb.probing = false
+			cb.nextProbe = now.Add(cb.Cooldown)
+		}
+		return
+	}
+	if cb.failures >= cb.Threshold {
+		fmt.Printf("Geocoder looks down (%s); pausing, trying again every %s\n", err, cb.Cooldown)
+		cb.open, cb.openedAt, cb.nextProbe = true, now, now.Add(cb.Cooldown)
+	}
+}
+
+// release gives up a turn to try the geocoder without having found out anything
+func (cb *CircuitBreaker) release() {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	cb.probing = false
+	cb.mu.Unlock()
+}
+
+// isOpen is whether the breaker is pausing requests
+func (cb *CircuitBreaker) isOpen() bool {
+	if cb == nil {
+		return false
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.open
+}
+
+// retryTransport carries out a RetryPolicy around another RoundTripper
+type retryTransport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a body can only be sent again if it can be got again
+	retries := t.policy.Retries
+	if req.Body != nil && req.GetBody == nil {
+		retries = 0
+	}
+	for attempt := 0; ; attempt++ {
+		if err := t.policy.Breaker.wait(req.Context()); err != nil {
+			return nil, err
+		}
+		resp, err := t.once(req, attempt)
+		if req.Context().Err() != nil {
+			t.policy.Breaker.release()
+			return resp, err
+		}
+		if !transient(resp, err) {
+			t.policy.Breaker.success()
+			return resp, err
+		}
+		reason := err
+		if reason == nil {
+			reason = fmt.Errorf("%s from %s", resp.Status, req.URL.Host)
+		}
+		t.policy.Breaker.failure(reason)
+		// while the breaker's open, the request waits for the geocoder rather than failing
+		if attempt >= retries && !t.policy.Breaker.isOpen() {
+			return resp, err
+		}
+		wait := t.backoff(attempt, resp)
+		if resp != nil {
+			closeBody(resp)
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// once makes one attempt, under its own timeout
+func (t *retryTransport) once(req *http.Request, attempt int) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if t.policy.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.policy.Timeout)
+	}
+	r := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the timeout covers reading the body, so it's only let go once the body's closed
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff is how long to wait before the next attempt: what Retry-After asks for, or
+// Backoff doubled for each attempt so far, capped at MaxBackoff, with up to half of it jittered
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	wait := t.policy.Backoff
+	for i := 0; i < attempt && i < 20; i++ {
+		wait *= 2
+		if t.policy.MaxBackoff > 0 && wait > t.policy.MaxBackoff {
+			wait = t.policy.MaxBackoff
+			break
+		}
+	}
+	if wait <= 0 {
+		return 0
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// transient is whether a failure might go away by itself: the request not getting through, or
+// the geocoder saying it's overloaded or broken
+func transient(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// cancelBody lets go of an attempt's context once its body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,204 @@
+package hcip2
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with status, then answers with the request's
+// body; it counts the requests
+func flakyServer(failures int32, status int) (*httptest.Server, *int32) {
+	var count int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= failures {
+			http.Error(w, "try later", status)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	return srv, &count
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int32
+		status   int
+		retries  int
+		want     int // the status that comes back
+		requests int32
+	}{
+		{"retried until it works", 2, http.StatusServiceUnavailable, 3, http.StatusOK, 3},
+		{"out of retries", 5, http.StatusBadGateway, 2, http.StatusBadGateway, 3},
+		{"rate limited", 1, http.StatusTooManyRequests, 1, http.StatusOK, 2},
+		{"not found isn't retried", 1, http.StatusNotFound, 3, http.StatusNotFound, 1},
+	}
+	for _, test := range tests {
+		srv, count := flakyServer(test.failures, test.status)
+		client := NewHTTPClient(2, RetryPolicy{Retries: test.retries, Backoff: time.Millisecond})
+		resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("100 MAIN ST"))
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		} else {
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != test.want || resp.StatusCode == http.StatusOK && string(body) != "100 MAIN ST" {
+				t.Errorf("%s: %s %q", test.name, resp.Status, body)
+			}
+		}
+		if atomic.LoadInt32(count) != test.requests {
+			t.Errorf("%s: %d requests, want %d", test.name, *count, test.requests)
+		}
+		srv.Close()
+	}
+}
+
+func TestRetryTimeout(t *testing.T) {
+	var count int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) == 1 {
+			time.Sleep(500 * time.Millisecond)
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+	client := NewHTTPClient(2, RetryPolicy{Retries: 1, Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	var v []Match
+	if err := getJSON(client, srv.URL, &v); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took > 400*time.Millisecond || atomic.LoadInt32(&count) != 2 {
+		t.Errorf("%d requests in %s", count, took)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	rt := &retryTransport{policy: RetryPolicy{Backoff: 10 * time.Millisecond, MaxBackoff: 40 * time.Millisecond}}
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, 5 * time.Millisecond, 10 * time.Millisecond},
+		{1, 10 * time.Millisecond, 20 * time.Millisecond},
+		{5, 20 * time.Millisecond, 40 * time.Millisecond},
+		{100, 20 * time.Millisecond, 40 * time.Millisecond},
+	}
+	for _, test := range tests {
+		for i := 0; i < 20; i++ {
+			if wait := rt.backoff(test.attempt, nil); wait < test.min || wait > test.max {
+				t.Errorf("attempt %d waits %s, want %s to %s", test.attempt, wait, test.min, test.max)
+			}
+		}
+	}
+	resp := &http.Response{Header: http.Header{"Retry-After": {"3"}}}
+	if wait := rt.backoff(0, resp); wait != 3*time.Second {
+		t.Errorf("Retry-After: 3 waits %s", wait)
+	}
+}
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		status int
+		err    error
+		want   bool
+	}{
+		{0, errors.New("connection refused"), true},
+		{http.StatusOK, nil, false},
+		{http.StatusBadRequest, nil, false},
+		{http.StatusTooManyRequests, nil, true},
+		{http.StatusInternalServerError, nil, true},
+		{http.StatusGatewayTimeout, nil, true},
+		{http.StatusNotImplemented, nil, false},
+	}
+	for _, test := range tests {
+		var resp *http.Response
+		if test.err == nil {
+			resp = &http.Response{StatusCode: test.status}
+		}
+		if got := transient(resp, test.err); got != test.want {
+			t.Errorf("transient(%d, %v) = %v", test.status, test.err, got)
+		}
+	}
+	if DefaultTimeout("Census") != 10*time.Minute || DefaultTimeout("nominatim") != 30*time.Second {
+		t.Error("DefaultTimeout")
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	// down for the first four requests: the breaker opens after two, and the request waits
+	// for the geocoder rather than running out of retries
+	srv, count := flakyServer(4, http.StatusServiceUnavailable)
+	breaker := NewCircuitBreaker(2, 10*time.Millisecond, time.Second)
+	client := NewHTTPClient(2, RetryPolicy{Retries: 1, Backoff: time.Millisecond, Breaker: breaker})
+	resp, err := client.Get(srv.URL)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("through the breaker: %v, %v", resp, err)
+	} else {
+		resp.Body.Close()
+	}
+	if atomic.LoadInt32(count) != 5 || breaker.isOpen() {
+		t.Errorf("%d requests, breaker open %v", *count, breaker.isOpen())
+	}
+	srv.Close()
+
+	// down for good: the request gives up after MaxPause
+	srv, _ = flakyServer(1000, http.StatusServiceUnavailable)
+	defer srv.Close()
+	breaker = NewCircuitBreaker(1, 10*time.Millisecond, 50*time.Millisecond)
+	client = NewHTTPClient(2, RetryPolicy{Retries: 1, Backoff: time.Millisecond, Breaker: breaker})
+	start := time.Now()
+	_, err = client.Get(srv.URL)
+	if err == nil || !strings.Contains(err.Error(), ErrGeocoderDown.Error()) {
+		t.Errorf("with the geocoder down: %v", err)
+	}
+	if took := time.Since(start); took < 50*time.Millisecond || took > time.Second {
+		t.Errorf("gave up after %s", took)
+	}
+}
+
+func TestNilCircuitBreaker(t *testing.T) {
+	var cb *CircuitBreaker
+	if err := cb.wait(context.Background()); err != nil {
+		t.Error(err)
+	}
+	cb.failure(errors.New("down"))
+	cb.success()
+	cb.release()
+	if cb.isOpen() {
+		t.Error("a nil breaker is never open")
+	}
+}
+
+func TestRetryFlags(t *testing.T) {
+	parse := func(args ...string) *RetryFlags {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags := NewRetryFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return flags
+	}
+
+	policy := parse().Policy("census")
+	if policy.Retries != 5 || policy.Backoff != time.Second || policy.MaxBackoff != time.Minute || policy.Timeout != 10*time.Minute {
+		t.Errorf("default policy %+v", policy)
+	}
+	if b := policy.Breaker; b == nil || b.Threshold != 20 || b.Cooldown != 30*time.Second || b.MaxPause != 0 {
+		t.Errorf("default breaker %+v", b)
+	}
+
+	policy = parse("-retries", "2", "-timeout", "5s", "-breaker", "0").Policy("nominatim")
+	if policy.Retries != 2 || policy.Timeout != 5*time.Second || policy.Breaker != nil {
+		t.Errorf("policy %+v", policy)
+	}
+}
@@ -0,0 +1,199 @@
+package hcip2
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// Point is a latitude and longitude
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// Km is the great-circle distance between two points, in kilometers
+func (p Point) Km(q Point) float64 {
+	const earthRadius = 6371.0
+	lat1, lat2 := p.Lat*math.Pi/180, q.Lat*math.Pi/180
+	dLat, dLon := lat2-lat1, (q.Lon-p.Lon)*math.Pi/180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// samePlaceKm is how close two matches have to be to count as one place found twice, like a
+// house's address point and its building outline
+const samePlaceKm = 0.1
+
+// The weights of what Scorer looks at.  A match is only scored on what's known about it: the
+// weights of what isn't are left out of the average.
+const (
+	precisionWeight = 0.35 // how precise a place it is: a house beats a street beats a postcode
+	rankWeight      = 0.10 // Nominatim's place_rank
+	scoreWeight     = 0.15 // the geocoder's own importance or confidence
+	zipWeight       = 0.20 // whether it's in the ZIP that was asked for
+	distanceWeight  = 0.20 // how near it is to the middle of the voter's county
+)
+
+// Scorer picks between the matches a geocoder found for an address, accepting the best of
+// them when it's clearly ahead and leaving the rest for a person to look at
+type Scorer struct {
+	Centroids     map[string]Point // county centroids by FIPS code; distance isn't scored without them
+	MinPoints     float64          // the fewest points the best match can have and still be accepted
+	MinConfidence float64          // how far ahead of the next-best place the best has to be
+}
+
+// NewScorer makes a Scorer with the usual thresholds
+func NewScorer(centroids map[string]Point) *Scorer {
+	return &Scorer{Centroids: centroids, MinPoints: 0.5, MinConfidence: 0.15}
+}
+
+// Candidate is a match with the points a Scorer gave it, from 0 to 1
+type Candidate struct {
+	Match
+	Points float64
+}
+
+// Pick is what a Scorer made of a geocoder's matches
+type Pick struct {
+	Candidates []Candidate // every match, best first
+	Confidence float64     // how many points the best is ahead of the next-best place, or all of its points if there's none
+	OK         bool        // whether the best was accepted
+}
+
+// Best is the best-scoring match
+func (p Pick) Best() Match {
+	if len(p.Candidates) == 0 {
+		return Match{}
+	}
+	return p.Candidates[0].Match
+}
+
+// Pick scores the matches found for addr, for a voter in the county with the given FIPS code
+// ("" if unknown), and decides whether the best one is clearly right.  Matches within
+// samePlaceKm of the best are taken to be the same place, not rivals to it.
+func (s *Scorer) Pick(matches []Match, addr address.Address, fips string) Pick {
+	centroid, hasCentroid := s.Centroids[fips]
+	candidates := make([]Candidate, len(matches))
+	for i, m := range matches {
+		candidates[i] = Candidate{Match: m}
+		if located(m) {
+			candidates[i].Points = s.points(m, addr, centroid, hasCentroid)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Points > candidates[j].Points })
+
+	pick := Pick{Candidates: candidates}
+	if len(candidates) == 0 || !located(candidates[0].Match) {
+		return pick
+	}
+	best := candidates[0]
+	pick.Confidence = best.Points
+	for _, c := range candidates[1:] {
+		if !located(c.Match) || pointOf(c.Match).Km(pointOf(best.Match)) > samePlaceKm {
+			pick.Confidence = best.Points - c.Points
+			break
+		}
+	}
+	pick.OK = best.Points >= s.MinPoints && pick.Confidence >= s.MinConfidence
+	return pick
+}
+
+// points weighs up everything known about a match
+func (s *Scorer) points(m Match, addr address.Address, centroid Point, hasCentroid bool) float64 {
+	total, weights := 0.0, 0.0
+	add := func(weight float64, val float64) {
+		total += weight * val
+		weights += weight
+	}
+	add(precisionWeight, precision(m))
+	if m.Rank > 0 {
+		add(rankWeight, math.Min(float64(m.Rank), 30)/30)
+	}
+	if m.Score > 0 {
+		add(scoreWeight, math.Min(m.Score, 1))
+	}
+	if want, got := zip5(addr.Zip), zip5(m.Zip); want != "" && got != "" {
+		if want == got {
+			add(zipWeight, 1)
+		} else {
+			add(zipWeight, 0)
+		}
+	}
+	if hasCentroid {
+		// a county is a few dozen kilometers across; a namesake street in the next state is not
+		add(distanceWeight, 1/(1+pointOf(m).Km(centroid)/20))
+	}
+	return total / weights
+}
+
+// precision is how closely a match pins the address down, from 1 for a house to 0.1 for a
+// town, by each geocoder's names for kinds of place
+func precision(m Match) float64 {
+	switch {
+	case m.Category == "building", m.Kind == "house", m.Kind == "address", m.Kind == "building":
+		return 1
+	case m.Kind == "venue":
+		return 0.8
+	case m.Category == "highway", m.Kind == "street":
+		return 0.6
+	case m.Kind == "postcode", m.Kind == "postalcode", m.Kind == "postal_code":
+		return 0.3
+	}
+	return 0.1
+}
+
+// located is whether a match has coordinates; the Census geocoder's ties don't
+func located(m Match) bool {
+	return m.Lat != 0 || m.Lon != 0
+}
+
+func pointOf(m Match) Point {
+	return Point{Lat: m.Lat, Lon: m.Lon}
+}
+
+func zip5(zip string) string {
+	if len(zip) > 5 {
+		return zip[:5]
+	}
+	return zip
+}
+
+// WithoutLabels blanks the candidates' labels, which spell out the address, for privacy mode
+func (p Pick) WithoutLabels() Pick {
+	candidates := make([]Candidate, len(p.Candidates))
+	for i, c := range p.Candidates {
+		c.Label = ""
+		candidates[i] = c
+	}
+	p.Candidates = candidates
+	return p
+}
+
+// PickHeader is the header of the file of accepted picks
+var PickHeader = []string{"id", "lat", "lon", "confidence", "candidates", "kind", "label"}
+
+// PickRow is a row of the file of accepted picks, for the record with the given ID
+func (p Pick) PickRow(id string) []string {
+	best := p.Best()
+	return []string{id, formatFloat(best.Lat), formatFloat(best.Lon), strconv.FormatFloat(p.Confidence, 'f', 3, 64), strconv.Itoa(len(p.Candidates)), best.Kind, best.Label}
+}
+
+// ReviewHeader is the header of the review file
+var ReviewHeader = []string{"id", "query", "candidate", "lat", "lon", "points", "kind", "zip", "label"}
+
+// ReviewRows are the review file's rows for a pick that wasn't accepted: one per candidate, best
+// first, under the record's ID and what was asked
+func (p Pick) ReviewRows(id string, query string) [][]string {
+	rows := make([][]string, len(p.Candidates))
+	for i, c := range p.Candidates {
+		rows[i] = []string{id, query, strconv.Itoa(i + 1), formatFloat(c.Lat), formatFloat(c.Lon), strconv.FormatFloat(c.Points, 'f', 3, 64), c.Kind, c.Zip, c.Label}
+	}
+	return rows
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
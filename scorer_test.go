@@ -0,0 +1,113 @@
+package hcip2
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/skemper/hcip2/address"
+)
+
+func TestPointKm(t *testing.T) {
+	raleigh, charlotte := Point{35.7796, -78.6382}, Point{35.2271, -80.8431}
+	if km := raleigh.Km(charlotte); math.Abs(km-207) > 2 {
+		t.Errorf("Raleigh to Charlotte is %.1f km", km)
+	}
+	if km := raleigh.Km(raleigh); km != 0 {
+		t.Errorf("Raleigh to itself is %f km", km)
+	}
+}
+
+func TestScorerPick(t *testing.T) {
+	wake := Point{35.79, -78.65}
+	s := NewScorer(map[string]Point{"37183": wake})
+	addr := address.Address{Number: "100", Name: "MAIN", Suffix: "ST", City: "RALEIGH", State: "NC", Zip: "27601-1234"}
+	house := Match{Lat: 35.78, Lon: -78.64, Kind: "house", Rank: 30, Zip: "27601", Label: "100 Main St"}
+	outline := Match{Lat: 35.7801, Lon: -78.6401, Kind: "building", Zip: "27601"} // the same house, some meters off
+	street := Match{Lat: 35.77, Lon: -78.60, Kind: "street", Category: "highway", Rank: 26, Zip: "27601"}
+	namesake := Match{Lat: 34.05, Lon: -118.24, Kind: "house", Rank: 30, Zip: "90012"} // a Main St in another state
+	elsewhere := Match{Lat: 36.1, Lon: -80.2, Kind: "house", Rank: 30, Zip: "27601"}   // a mistyped ZIP, across the state
+	tie := Match{Kind: "tie"}
+
+	tests := []struct {
+		name    string
+		matches []Match
+		fips    string
+		ok      bool
+		best    Match
+	}{
+		{"nothing", nil, "37183", false, Match{}},
+		{"one house", []Match{house}, "37183", true, house},
+		{"a house and its outline", []Match{outline, house}, "37183", true, house},
+		{"a house beats its street", []Match{street, house}, "37183", true, house},
+		{"a house beats a namesake far away", []Match{namesake, house}, "37183", true, house},
+		{"a house beats another ZIP without a county", []Match{namesake, house}, "", true, house},
+		{"two houses far apart without a county", []Match{elsewhere, house}, "", false, elsewhere},
+		{"a house nearer the county", []Match{elsewhere, house}, "37183", true, house},
+		{"only a street", []Match{street}, "37183", true, street},
+		{"a Census tie", []Match{tie, tie}, "37183", false, tie},
+	}
+	for _, test := range tests {
+		pick := s.Pick(test.matches, addr, test.fips)
+		if pick.OK != test.ok || pick.Best() != test.best {
+			t.Errorf("%s: ok %v, best %+v (%+v)", test.name, pick.OK, pick.Best(), pick.Candidates)
+		}
+		for i := 1; i < len(pick.Candidates); i++ {
+			if pick.Candidates[i].Points > pick.Candidates[i-1].Points {
+				t.Errorf("%s: candidates out of order: %+v", test.name, pick.Candidates)
+			}
+		}
+	}
+
+	// the best's outline isn't a rival to it, so all its points are confidence
+	pick := s.Pick([]Match{house, outline}, addr, "37183")
+	if pick.Confidence != pick.Candidates[0].Points {
+		t.Errorf("confidence %.3f with %+v", pick.Confidence, pick.Candidates)
+	}
+	pick = s.Pick([]Match{house, street}, addr, "37183")
+	if want := pick.Candidates[0].Points - pick.Candidates[1].Points; pick.Confidence != want {
+		t.Errorf("confidence %.3f, want %.3f", pick.Confidence, want)
+	}
+}
+
+func TestScorerPoints(t *testing.T) {
+	s := NewScorer(nil)
+	addr := address.Address{Zip: "27601"}
+	// with only the kind known, the points are its precision
+	if p := s.points(Match{Kind: "postcode"}, addr, Point{}, false); p != 0.3 {
+		t.Errorf("a postcode scored %.3f", p)
+	}
+	inZip := s.points(Match{Kind: "house", Zip: "27601"}, addr, Point{}, false)
+	otherZip := s.points(Match{Kind: "house", Zip: "27701"}, addr, Point{}, false)
+	if inZip != 1 || otherZip >= inZip {
+		t.Errorf("a house in the ZIP scored %.3f, in another ZIP %.3f", inZip, otherZip)
+	}
+}
+
+func TestPickRows(t *testing.T) {
+	pick := Pick{
+		Candidates: []Candidate{
+			{Match: Match{Lat: 35.5, Lon: -78.25, Kind: "house", Zip: "27601", Label: "100 Main St"}, Points: 0.9},
+			{Match: Match{Lat: 36, Lon: -79, Kind: "street", Label: "Main St"}, Points: 0.4},
+		},
+		Confidence: 0.5,
+		OK:         true,
+	}
+	if row := pick.PickRow("7"); !reflect.DeepEqual(row, []string{"7", "35.5", "-78.25", "0.500", "2", "house", "100 Main St"}) {
+		t.Errorf("PickRow = %q", row)
+	}
+	rows := pick.WithoutLabels().ReviewRows("7", "100 MAIN ST")
+	want := [][]string{
+		{"7", "100 MAIN ST", "1", "35.5", "-78.25", "0.900", "house", "27601", ""},
+		{"7", "100 MAIN ST", "2", "36", "-79", "0.400", "street", "", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("ReviewRows = %q", rows)
+	}
+	if pick.Candidates[0].Label != "100 Main St" {
+		t.Error("WithoutLabels changed the pick it was called on")
+	}
+	if len(PickHeader) != len(pick.PickRow("7")) || len(ReviewHeader) != len(rows[0]) {
+		t.Error("the rows don't line up with their headers")
+	}
+}
@@ -0,0 +1,149 @@
+package hcip2
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is where a voter's registration stands, whatever codes the state uses for it
+type Status int
+
+const (
+	StatusActive Status = iota
+	StatusInactive
+	StatusRemoved // removed, cancelled, or any code the layout doesn't list
+	StatusDenied
+)
+
+// statusNames are the names the -status flags and layout files use
+var statusNames = map[string]Status{
+	"active":   StatusActive,
+	"inactive": StatusInactive,
+	"removed":  StatusRemoved,
+	"denied":   StatusDenied,
+}
+
+func (s Status) String() string {
+	for name, status := range statusNames {
+		if status == s {
+			return name
+		}
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// StatusPolicy says which voters the loaders let through.  Confidential voters (NC's
+// Confidential_ind, FL's exemption) are left out unless Confidential is set, whatever their status.
+type StatusPolicy struct {
+	Active       bool
+	Inactive     bool
+	Removed      bool
+	Denied       bool
+	Confidential bool
+}
+
+// DefaultStatusPolicy keeps the registered voters, active and inactive, and nobody confidential.
+// An HciConfig with a zero Policy uses it.
+var DefaultStatusPolicy = StatusPolicy{Active: true, Inactive: true}
+
+// ParseStatusPolicy builds a policy from a comma-separated list of statuses, e.g.
+// "active,inactive", or "all"
+func ParseStatusPolicy(statuses string, confidential bool) (StatusPolicy, error) {
+	policy := StatusPolicy{Confidential: confidential}
+	for _, name := range strings.Split(statuses, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "all" {
+			policy.Active, policy.Inactive, policy.Removed, policy.Denied = true, true, true, true
+			continue
+		}
+		status, ok := statusNames[name]
+		if !ok {
+			return StatusPolicy{}, fmt.Errorf("unknown status %q; use active, inactive, removed, denied or all", name)
+		}
+		policy.set(status)
+	}
+	return policy, nil
+}
+
+func (p *StatusPolicy) set(status Status) {
+	switch status {
+	case StatusActive:
+		p.Active = true
+	case StatusInactive:
+		p.Inactive = true
+	case StatusRemoved:
+		p.Removed = true
+	case StatusDenied:
+		p.Denied = true
+	}
+}
+
+// Allows reports whether the policy keeps a voter with the given status
+func (p StatusPolicy) Allows(status Status, confidential bool) bool {
+	if confidential && !p.Confidential {
+		return false
+	}
+	switch status {
+	case StatusActive:
+		return p.Active
+	case StatusInactive:
+		return p.Inactive
+	case StatusDenied:
+		return p.Denied
+	}
+	return p.Removed
+}
+
+func (p StatusPolicy) String() string {
+	var names []string
+	for _, status := range []Status{StatusActive, StatusInactive, StatusRemoved, StatusDenied} {
+		if p.Allows(status, false) {
+			names = append(names, status.String())
+		}
+	}
+	if p.Confidential {
+		names = append(names, "confidential")
+	}
+	return strings.Join(names, ",")
+}
+
+// Status reads a record's registration status from its Status_cd column.  Layouts that don't
+// say what their codes mean, or have no status column, have only active voters.
+func (config *HciConfig) Status(pieces []string) Status {
+	col, ok := config.Fields["Status_cd"]
+	if len(config.StatusCodes) == 0 || !ok {
+		return StatusActive
+	}
+	if status, ok := config.StatusCodes[column(pieces, col)]; ok {
+		return status
+	}
+	return StatusRemoved
+}
+
+// Keep reports whether a record passes both the status policy and the filters.  Every loader
+// goes through it (or KeepBytes), so confidential voters can't slip into an output by accident.
+func (config *HciConfig) Keep(pieces []string) bool {
+	return config.allowed(pieces) && (config.FilterStr == nil || config.FilterStr(pieces))
+}
+
+// KeepBytes is Keep for split-byte records
+func (config *HciConfig) KeepBytes(pieces [][]byte) bool {
+	strs := make([]string, len(pieces))
+	for i, piece := range pieces {
+		strs[i] = cleanField(string(piece))
+	}
+	return config.allowed(strs) && (config.FilterBytes == nil || config.FilterBytes(pieces))
+}
+
+// allowed applies the status policy alone
+func (config *HciConfig) allowed(pieces []string) bool {
+	confidential := config.Confidential != nil && config.Confidential(pieces)
+	return config.statusPolicy().Allows(config.Status(pieces), confidential)
+}
+
+func (config *HciConfig) statusPolicy() StatusPolicy {
+	if config.Policy == (StatusPolicy{}) {
+		return DefaultStatusPolicy
+	}
+	return config.Policy
+}
@@ -0,0 +1,70 @@
+package hcip2
+
+import "testing"
+
+func TestParseStatusPolicy(t *testing.T) {
+	policy, err := ParseStatusPolicy("Active, removed", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (StatusPolicy{Active: true, Removed: true}); policy != want {
+		t.Errorf("policy = %+v, want %+v", policy, want)
+	}
+	if policy.String() != "active,removed" {
+		t.Errorf("String() = %q", policy.String())
+	}
+	if all, _ := ParseStatusPolicy("all", true); all.String() != "active,inactive,removed,denied,confidential" {
+		t.Errorf("all is %q", all.String())
+	}
+	if _, err := ParseStatusPolicy("active,gone", false); err == nil {
+		t.Error("an unknown status was accepted")
+	}
+}
+
+func TestKeepAppliesStatusPolicy(t *testing.T) {
+	config := NC
+	record := func(status string, confidential string) []string {
+		pieces := make([]string, len(NCColumns))
+		pieces[Status_cd] = status
+		pieces[Confidential_ind] = confidential
+		return pieces
+	}
+	tests := []struct {
+		policy       StatusPolicy
+		status       string
+		confidential string
+		want         bool
+	}{
+		{StatusPolicy{}, "A", "N", true}, // the default policy
+		{StatusPolicy{}, "S", "N", true},
+		{StatusPolicy{}, "I", "N", true},
+		{StatusPolicy{}, "R", "N", false},
+		{StatusPolicy{}, "D", "N", false},
+		{StatusPolicy{}, "?", "N", false}, // an unknown code counts as removed
+		{StatusPolicy{}, "A", "Y", false},
+		{StatusPolicy{Active: true, Confidential: true}, "A", "Y", true},
+		{StatusPolicy{Removed: true}, "?", "N", true},
+		{StatusPolicy{Denied: true}, "D", "N", true},
+		{StatusPolicy{Denied: true}, "A", "N", false},
+	}
+	for _, tt := range tests {
+		config.Policy = tt.policy
+		pieces := record(tt.status, tt.confidential)
+		if got := config.Keep(pieces); got != tt.want {
+			t.Errorf("%+v kept status %s, confidential %s: %v, want %v", tt.policy, tt.status, tt.confidential, got, tt.want)
+		}
+		bytes := make([][]byte, len(pieces))
+		for i, val := range pieces {
+			bytes[i] = []byte(val)
+		}
+		if got := config.KeepBytes(bytes); got != tt.want {
+			t.Errorf("%+v kept status %s, confidential %s as bytes: %v, want %v", tt.policy, tt.status, tt.confidential, got, tt.want)
+		}
+	}
+
+	// layouts that don't say what their codes mean have only active voters
+	var plain HciConfig
+	if plain.Status([]string{"R"}) != StatusActive {
+		t.Error("a layout without status codes has removed voters")
+	}
+}
@@ -0,0 +1,86 @@
+package hcip2
+
+import (
+	"fmt"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// AddressBook collects the distinct addresses in a voter file, so that each is geocoded once
+// however many voters live there.  Addresses are told apart by their street without its unit,
+// city, state and five-digit ZIP, and geocoded without their units.
+type AddressBook struct {
+	Addrs   []address.Address // the distinct addresses, in the order they were first seen
+	FIPS    []string          // the county of the first voter seen at each address, if known
+	Answers []Answer          // what was found for each address, once Geocode has run
+	index   map[string]int
+	voters  int
+}
+
+// NewAddressBook makes an empty AddressBook
+func NewAddressBook() *AddressBook {
+	return &AddressBook{index: make(map[string]int)}
+}
+
+// Add counts a voter at addr, in the county with the given FIPS code, keeping the address if
+// it hasn't been seen before
+func (b *AddressBook) Add(addr address.Address, fips string) {
+	b.voters++
+	addr.UnitType, addr.UnitNum = "", ""
+	key := AddressQuery(addr)
+	if _, ok := b.index[key]; ok {
+		return
+	}
+	b.index[key] = len(b.Addrs)
+	b.Addrs = append(b.Addrs, addr)
+	b.FIPS = append(b.FIPS, fips)
+}
+
+// Len is how many distinct addresses there are
+func (b *AddressBook) Len() int {
+	return len(b.Addrs)
+}
+
+// Voters is how many voters were added
+func (b *AddressBook) Voters() int {
+	return b.voters
+}
+
+// Geocode runs every address through the cascade of steps on pool, batchSize at a time,
+// calling done after each batch with how many addresses are done so far.  settled is as for
+// GeocodePool.Cascade, with i indexing Addrs.
+func (b *AddressBook) Geocode(pool *GeocodePool, steps []Strategy, settled func(i int, matches []Match) bool, batchSize int, done func(n int)) {
+	b.Answers = make([]Answer, len(b.Addrs))
+	for start := 0; start < len(b.Addrs); start += batchSize {
+		end := start + batchSize
+		if end > len(b.Addrs) {
+			end = len(b.Addrs)
+		}
+		answers := pool.Cascade(b.Addrs[start:end], steps, func(i int, matches []Match) bool {
+			return settled(start+i, matches)
+		})
+		copy(b.Answers[start:end], answers)
+		if done != nil {
+			done(end)
+		}
+	}
+}
+
+// Lookup answers for addrs out of what Geocode found, the way GeocodePool.Cascade would have.
+// An address that was never added is an error.
+func (b *AddressBook) Lookup(addrs []address.Address) []Answer {
+	answers := make([]Answer, len(addrs))
+	for i, addr := range addrs {
+		addr.UnitType, addr.UnitNum = "", ""
+		j, ok := b.index[AddressQuery(addr)]
+		switch {
+		case !ok:
+			answers[i].Err = fmt.Errorf("address %s wasn't collected before geocoding", addr)
+		case j >= len(b.Answers):
+			answers[i].Err = fmt.Errorf("address %s wasn't geocoded", addr)
+		default:
+			answers[i] = b.Answers[j]
+		}
+	}
+	return answers
+}
@@ -0,0 +1,53 @@
+package hcip2
+
+import (
+	"testing"
+
+	"github.com/skemper/hcip2/address"
+)
+
+func TestAddressBook(t *testing.T) {
+	apt := func(street, unit, zip string) address.Address {
+		addr := address.ParseStreet(street)
+		addr.UnitType, addr.UnitNum = "APT", unit
+		addr.City, addr.State, addr.Zip = "APEX", "NC", zip
+		return addr
+	}
+	book := NewAddressBook()
+	book.Add(apt("100 N MAIN ST", "1", "27502-1234"), "37183")
+	book.Add(apt("100 North Main Street", "2", "27502"), "37037") // the same building
+	book.Add(apt("200 N MAIN ST", "", "27502"), "37183")
+	book.Add(apt("100 N MAIN ST", "1", "27523"), "37183") // another ZIP is another address
+
+	if book.Len() != 3 || book.Voters() != 4 {
+		t.Fatalf("%d addresses for %d voters, want 3 for 4", book.Len(), book.Voters())
+	}
+	if book.Addrs[0].UnitNum != "" || book.FIPS[0] != "37183" {
+		t.Errorf("first address %+v in %s", book.Addrs[0], book.FIPS[0])
+	}
+
+	lookup := []address.Address{apt("100 N MAIN ST", "7", "27502")}
+	if answers := book.Lookup(lookup); answers[0].Err == nil {
+		t.Error("looked up an address before geocoding")
+	}
+
+	g := &recordingGeocoder{}
+	var progress []int
+	book.Geocode(NewGeocodePool(g, 2, 0), []Strategy{StrategyRoad}, func(i int, matches []Match) bool {
+		return len(matches) > 0
+	}, 2, func(n int) { progress = append(progress, n) })
+	if len(g.sent) != 3 {
+		t.Errorf("geocoded %d addresses, want 3", len(g.sent))
+	}
+	if len(progress) != 2 || progress[0] != 2 || progress[1] != 3 {
+		t.Errorf("progress %v", progress)
+	}
+
+	answers := book.Lookup(append(lookup, apt("9 ELM ST", "", "27502")))
+	if answers[0].Err != nil || len(answers[0].Matches) != 1 || answers[0].Strategy != StrategyRoad {
+		t.Errorf("an apartment's answer %+v", answers[0])
+	}
+	if answers[1].Err == nil {
+		t.Error("looked up an address that was never added")
+	}
+}
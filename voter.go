@@ -0,0 +1,244 @@
+package hcip2
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skemper/hcip2/address"
+)
+
+// Voter is the typed view of one voter registration record, shared by every state layout
+type Voter struct {
+	StateVoterID             string          // Ncid for NC, StateVoterID for WA
+	County_id                int             // County identification number
+	County_desc              string          // County description
+	Voter_reg_num            string          // Voter registration number (unique by county)
+	Status_cd                byte            // Status code for voter registration
+	Status                   Status          // Registration status, as the layout's StatusCodes read Status_cd
+	Confidential             bool            // Confidential or exempt, as the layout's Confidential reads it
+	Voter_status_desc        string          // Status code description
+	Reason_cd                string          // Reason code for voter registration status
+	Voter_status_reason_desc string          // Reason code description
+	Last_name                string          // Voter last name
+	First_name               string          // Voter first name
+	Midl_name                string          // Voter middle name
+	Name_sufx_cd             string          // Voter name suffix
+	Road                     string          // Residential street address, built from HciConfig.Road
+	Address                  address.Address // Residential address, standardized to USPS Pub 28
+	Res_city_desc            string          // Residential address city name
+	State_cd                 string          // Residential address state code
+	Zip_code                 string          // Residential address zip code
+	Race_code                string          // Race code
+	Race_desc                string          // Race description
+	Ethnic_code              string          // Ethnicity code
+	Ethnic_desc              string          // Ethnicity description
+	Party_cd                 string          // Party affiliation code
+	Sex_code                 byte            // Gender code
+	Age                      int             // Age, computed from Birthdate when the layout has no age column
+	Birthdate                time.Time       // Birth date, if the layout has one
+	Registr_dt               time.Time       // Voter registration date
+	Precinct_abbrv           string          // Precinct abbreviation
+	Precinct_desc            string          // Precinct name
+	Cancellation_dt          time.Time       // Cancellation date
+	Vtd_abbrv                string          // Voter tabuluation district abbreviation
+	Vtd_desc                 string          // Voter tabuluation district name
+	Age_group                string          // Age group range
+	Districts                Districts       // District assignments, indexed by DistrictType
+	Lat                      float64         // filled in later from geocoded coordinates
+	Lon                      float64
+}
+
+// cleanField strips the whitespace and stray quotes the voter files pad their columns with
+func cleanField(s string) string {
+	return strings.Trim(strings.TrimSpace(s), "\"")
+}
+
+// ParseVoter converts one split record into a Voter, using config.Fields to find each value
+func ParseVoter(pieces []string, config HciConfig) (*Voter, error) {
+	var err error
+	voter := new(Voter)
+
+	str := func(field string) string {
+		col, ok := config.Fields[field]
+		if !ok {
+			return ""
+		}
+		return unredacted(column(pieces, col))
+	}
+	code := func(field string) byte {
+		val := str(field)
+		if val == "" {
+			return 0
+		}
+		return val[0]
+	}
+	num := func(field string) int {
+		val := str(field)
+		if val == "" || err != nil {
+			return 0
+		}
+		n, convErr := strconv.Atoi(val)
+		if convErr != nil {
+			err = fmt.Errorf("Error converting %s %s to integer: %s", field, val, convErr)
+		}
+		return n
+	}
+	date := func(field string) time.Time {
+		val := str(field)
+		if val == "" || err != nil {
+			return time.Time{}
+		}
+		format := config.DateFormat
+		if len(val) == 4 {
+			format = "2006" // some states only publish the year of birth
+		}
+		t, convErr := time.Parse(format, val)
+		if convErr != nil {
+			err = fmt.Errorf("Error converting %s %s to date: %s", field, val, convErr)
+		}
+		return t
+	}
+
+	voter.StateVoterID = column(pieces, config.STATE_VOTER_ID)
+	voter.County_id = num("County_id")
+	voter.County_desc = str("County_desc")
+	if voter.County_id == 0 && voter.County_desc != "" {
+		// WA has only its two-letter county codes; the reference table has the numbers
+		if county, ok := LookupCounty(config.StateAbbrev, voter.County_desc); ok {
+			voter.County_id = county.ID
+		}
+	}
+	voter.Voter_reg_num = str("Voter_reg_num")
+	voter.Status_cd = code("Status_cd")
+	voter.Status = config.Status(pieces)
+	voter.Confidential = config.Confidential != nil && config.Confidential(pieces)
+	voter.Voter_status_desc = str("Voter_status_desc")
+	voter.Reason_cd = str("Reason_cd")
+	voter.Voter_status_reason_desc = str("Voter_status_reason_desc")
+	voter.Last_name = str("Last_name")
+	voter.First_name = str("First_name")
+	voter.Midl_name = str("Midl_name")
+	voter.Name_sufx_cd = str("Name_sufx_cd")
+	voter.Road = unredacted(joinRoad(pieces, config.Road))
+	voter.Res_city_desc = unredacted(column(pieces, config.CITY))
+	voter.State_cd = config.ResidentialState(pieces)
+	voter.Zip_code = unredacted(column(pieces, config.ZIP))
+	voter.Address = config.Address(pieces)
+	voter.Race_code = str("Race_code")
+	voter.Race_desc = str("Race_desc")
+	voter.Ethnic_code = str("Ethnic_code")
+	voter.Ethnic_desc = str("Ethnic_desc")
+	voter.Party_cd = str("Party_cd")
+	voter.Sex_code = code("Sex_code")
+	voter.Age = num("Age")
+	voter.Birthdate = date("Birthdate")
+	voter.Registr_dt = date("Registr_dt")
+	voter.Precinct_abbrv = str("Precinct_abbrv")
+	voter.Precinct_desc = str("Precinct_desc")
+	voter.Cancellation_dt = date("Cancellation_dt")
+	voter.Vtd_abbrv = str("Vtd_abbrv")
+	voter.Vtd_desc = str("Vtd_desc")
+	voter.Age_group = str("Age_group")
+	voter.Districts = config.districts(pieces)
+	if err != nil {
+		return nil, fmt.Errorf("%s for %s", err, voter.StateVoterID)
+	}
+
+	setAge(voter, config, time.Now())
+	return voter, nil
+}
+
+// setAge works out a voter's age on the given day from their birth date, for layouts with no
+// age column
+func setAge(voter *Voter, config HciConfig, day time.Time) {
+	if _, ok := config.Fields["Age"]; !ok && !voter.Birthdate.IsZero() {
+		voter.Age = ageOn(voter.Birthdate, day)
+	}
+}
+
+// column returns the value at col, or "" if the record is too short to have it
+func column(pieces []string, col int) string {
+	if col < 0 || col >= len(pieces) {
+		return ""
+	}
+	return pieces[col]
+}
+
+// unredacted blanks a value FL has redacted with a lone *, as it does for exempt voters
+func unredacted(val string) string {
+	if val == "*" {
+		return ""
+	}
+	return val
+}
+
+// joinRoad glues the street address columns together, skipping the empty ones
+func joinRoad(pieces []string, columns []int) string {
+	parts := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if val := column(pieces, col); val != "" {
+			parts = append(parts, val)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// ageOn returns how many whole years old someone born on birth is on the given day
+func ageOn(birth time.Time, day time.Time) int {
+	age := day.Year() - birth.Year()
+	// by month and day, not YearDay, which a leap day shifts
+	if day.Month() < birth.Month() || (day.Month() == birth.Month() && day.Day() < birth.Day()) {
+		age--
+	}
+	return age
+}
+
+// ScanVoters streams every record of a voter file that config.Keep lets through to fn, stopping
+// at the first error.  Records that don't parse go to config.Rejects, if it's set.
+func ScanVoters(path string, config HciConfig, fn func(*Voter) error) error {
+	rr, err := OpenRecords(path, config)
+	if err != nil {
+		return err
+	}
+	defer rr.Close()
+
+	for {
+		pieces, err := rr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Error reading %s: %s", path, err)
+		}
+		if !config.Keep(pieces) {
+			continue
+		}
+		config.Rejects.Row()
+		voter, err := ParseVoter(pieces, config)
+		if err != nil {
+			if err = config.Rejects.Add(rr.Line, "parse", err.Error(), rr.Raw); err != nil {
+				return fmt.Errorf("Error reading %s: %s", path, err)
+			}
+			continue
+		}
+		if err = fn(voter); err != nil {
+			return err
+		}
+	}
+}
+
+// LoadVoters reads a whole voter file into memory, keyed by state voter ID
+func LoadVoters(path string, config HciConfig) (map[string]*Voter, error) {
+	voters := make(map[string]*Voter)
+	err := ScanVoters(path, config, func(voter *Voter) error {
+		voters[voter.StateVoterID] = voter
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return voters, nil
+}
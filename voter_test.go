@@ -0,0 +1,58 @@
+package hcip2
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgeOn(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		birth string
+		on    string
+		want  int
+	}{
+		{"2000-03-01", "2022-02-28", 21},
+		{"2000-03-01", "2022-03-01", 22},
+		{"2001-03-01", "2024-02-29", 22},
+		{"2001-03-01", "2024-03-01", 23},
+		{"2000-02-29", "2023-02-28", 22},
+		{"2000-02-29", "2023-03-01", 23},
+		{"1990-12-31", "2020-12-30", 29},
+		{"1990-12-31", "2020-12-31", 30},
+		{"1990-01-01", "2020-01-01", 30},
+	}
+	for _, tt := range tests {
+		if got := ageOn(day(tt.birth), day(tt.on)); got != tt.want {
+			t.Errorf("ageOn(%s, %s) = %d, want %d", tt.birth, tt.on, got, tt.want)
+		}
+	}
+}
+
+func TestCheckWidth(t *testing.T) {
+	config := HciConfig{STATE_VOTER_ID: 0, Road: []int{3, 4}, CITY: 1, STATE: -1, ZIP: 2}
+	short := []string{"VOTER1", "APEX", "27502", "12 MAIN ST"}
+	if err := config.CheckWidth(len(short)); err == nil {
+		t.Error("a row without the second street column passed")
+	}
+	if id := config.VoterID(short); id != "VOTER1" {
+		t.Errorf("VoterID = %q", id)
+	}
+	if err := config.CheckWidth(5); err != nil {
+		t.Error(err)
+	}
+	if id := config.VoterID(nil); id != "" {
+		t.Errorf("VoterID of an empty row = %q", id)
+	}
+
+	config.AddressFields = map[string]int{"Number": 3, "Name": 7}
+	if err := config.CheckWidth(7); err == nil {
+		t.Error("a row without an address field's column passed")
+	}
+}
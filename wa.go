@@ -42,13 +42,107 @@ const (
 	StatusCode
 )
 
+// WAColumns are the header names of the WA VRDB extract, in file order
+var WAColumns = []string{
+	"StateVoterID",
+	"FName",
+	"MName",
+	"LName",
+	"NameSuffix",
+	"birthdate",
+	"Gender",
+	"RegStNum",
+	"RegStFrac",
+	"RegStName",
+	"RegStType",
+	"RegUnitType",
+	"RegStPreDirection",
+	"RegStPostDirection",
+	"RegStUnitNum",
+	"RegCity",
+	"RegState",
+	"RegZipCode",
+	"CountyCode",
+	"PrecinctCode",
+	"PrecinctPart",
+	"LegislativeDistrict",
+	"CongressionalDistrict",
+	"Mail1",
+	"Mail2",
+	"Mail3",
+	"Mail4",
+	"MailCity",
+	"MailZip",
+	"MailState",
+	"MailCountry",
+	"Registrationdate",
+	"AbsenteeType",
+	"LastVoted",
+	"StatusCode",
+}
+
 var WA HciConfig = HciConfig{
+	Columns:        WAColumns,
 	MaxLineLength:  1000,
 	CITY:           City,
 	STATE:          State,
 	ZIP:            Zip,
+	StateAbbrev:    "WA",
 	STATE_VOTER_ID: StateVoterID,
+	Delimiter:      "|",
+	Encoding:       UTF8,
+	DateFormat:     "1/2/2006",
 	Road:           []int{StreetNum, StreetFrac, PreDirection, StreetName, StreetType, PostDirection, UnitType, UnitNum},
 	RoadNoUnit:     []int{StreetNum, StreetFrac, PreDirection, StreetName, StreetType, PostDirection},
-	FilterBytes:    NopFilterBytes,
+	AddressFields: map[string]int{
+		"Number":   StreetNum,
+		"Fraction": StreetFrac,
+		"PreDir":   PreDirection,
+		"Name":     StreetName,
+		"Suffix":   StreetType,
+		"PostDir":  PostDirection,
+		"UnitType": UnitType,
+		"UnitNum":  UnitNum,
+	},
+	FilterBytes: NopFilterBytes,
+	StatusCodes: map[string]Status{
+		"A":        StatusActive,
+		"Active":   StatusActive,
+		"I":        StatusInactive,
+		"Inactive": StatusInactive,
+	},
+	PII: map[int]PIIAction{
+		FirstName:  PIIDrop,
+		MiddleName: PIIDrop,
+		LastName:   PIIDrop,
+		NameSuffix: PIIDrop,
+		Birthdate:  PIIDrop,
+		StreetNum:  PIIDrop,
+		StreetFrac: PIIDrop,
+		StreetName: PIIDrop,
+		UnitNum:    PIIDrop,
+		Mail1:      PIIDrop,
+		Mail2:      PIIDrop,
+		Mail3:      PIIDrop,
+		Mail4:      PIIDrop,
+	},
+	Districts: map[DistrictType]DistrictColumns{
+		DistrictPrecinct:      {PrecinctCode, -1},
+		DistrictCongressional: {CongDistrict, -1},
+		DistrictStateSenate:   {LegDistrict, -1},
+		DistrictStateHouse:    {LegDistrict, -1},
+	},
+	Fields: map[string]int{
+		"County_desc":       County,
+		"Status_cd":         StatusCode,
+		"Voter_status_desc": StatusCode,
+		"Last_name":         LastName,
+		"First_name":        FirstName,
+		"Midl_name":         MiddleName,
+		"Name_sufx_cd":      NameSuffix,
+		"Sex_code":          Gender,
+		"Birthdate":         Birthdate,
+		"Registr_dt":        RegDate,
+		"Precinct_abbrv":    PrecinctCode,
+	},
 }